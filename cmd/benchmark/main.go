@@ -8,26 +8,20 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
-)
 
-var (
-	ignoreDirs = map[string]bool{
-		"node_modules": true,
-		".git":         true,
-		"Library":      true,
-		"Applications": true,
-		"Downloads":    true, // Often huge, better to skip for benchmark
-		"Music":        true,
-		"Movies":       true,
-		"Pictures":     true,
-		".npm":         true,
-		".cache":       true,
-		".gemini":      true, // Avoid circular ref
-	}
+	"geminictl/internal/config"
 )
 
+// benchmarkExcludes are skipped in addition to the configured exclude rules.
+var benchmarkExcludes = []string{
+	"Applications",
+	"Downloads", // Often huge, better to skip for benchmark
+	"Music",
+	"Movies",
+	"Pictures",
+}
+
 func main() {
 	root := flag.String("root", "", "Root directory to scan (default: user home)")
 	flag.Parse()
@@ -41,21 +35,27 @@ func main() {
 		scanRoot = home
 	}
 
+	cfg, err := config.Load("")
+	if err != nil {
+		panic(err)
+	}
+	cfg.Resolve.Exclude = append(cfg.Resolve.Exclude, benchmarkExcludes...)
+	skip := cfg.Resolve.Matcher()
+
 	fmt.Printf("Starting benchmark scan of: %s\n", scanRoot)
 	start := time.Now()
 	
 dirsScanned := 0
 hashesComputed := 0
 
-	err := filepath.WalkDir(scanRoot, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(scanRoot, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			// Permission denied or other error, skip
 			return nil
 		}
 
 		if d.IsDir() {
-			name := d.Name()
-			if ignoreDirs[name] || strings.HasPrefix(name, ".") {
+			if path != scanRoot && skip.Skip(path) {
 				return filepath.SkipDir
 			}
 			dirsScanned++
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"geminictl/internal/archive"
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveOutput string
+	archiveTo     string
+	archiveSkip   bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Back up projects or move them between machines",
+	Long: `Write projects to portable archives and import them again, on this machine
+or another one.
+
+An archive is a tar.gz holding the storage directory of a project (its
+sessions, logs.json and checkpoints), the directory it belonged to, and what
+geminictl records about it: alias, tags, notes and session names.`,
+}
+
+var archiveExportCmd = &cobra.Command{
+	Use:   "export <project>",
+	Short: "Write a project to a tar.gz archive",
+	Long: `Write a project to a tar.gz archive. The archive is named after the
+project directory and the date unless --output is given; use --output - to
+write it to standard output.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		m := archive.Manifest{
+			ProjectPath: cachedPath(st, p.ID),
+			Project:     st.cache.ProjectMeta(p.ID),
+			SessionMeta: st.cache.SessionsMeta(p.SessionIDs()...),
+		}
+
+		if archiveOutput == "-" {
+			if _, err := archive.Export(os.Stdout, st.scanner.RootDir, p.ID, m); err != nil {
+				fatal(exitFailure, err)
+			}
+			return
+		}
+
+		out := archiveOutput
+		if out == "" {
+			out = archive.FileName(p.ID, m.ProjectPath, time.Now())
+		}
+		if out, err = absPath(out); err != nil {
+			fatal(exitUsage, err)
+		}
+		if _, err := os.Stat(out); err == nil && !confirm(fmt.Sprintf("Overwrite %s?", out), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+
+		// Write next to the destination first so that a failed export does
+		// not leave a truncated archive behind.
+		tmp := out + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		m, err = archive.Export(f, st.scanner.RootDir, p.ID, m)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp, out)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			fatal(exitFailure, err)
+		}
+
+		size := ""
+		if info, err := os.Stat(out); err == nil {
+			size = ", " + humanize.Bytes(uint64(info.Size()))
+		}
+		fmt.Printf("Archived project [%s] (%d sessions, %d files%s) to %s\n", gemini.ShortID(p.ID), m.Sessions, m.Files, size, out)
+	},
+}
+
+var archiveImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Restore a project from a tar.gz archive",
+	Long: `Restore a project from an archive written by 'geminictl archive export'.
+
+The project is imported for the directory given with --to, by default the one
+it was archived from. It is stored under the hash of that directory and the
+projectHash of its sessions is rewritten, as 'geminictl project move' does, so
+that Gemini CLI picks up the history when run there. If the project already
+exists, the sessions of the archive are added to it.
+
+Sessions that already exist in any project, and other files that exist in the
+project, are conflicts: the import is refused unless --skip-existing is given,
+which imports everything else.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		opts := archive.ImportOptions{SkipConflicts: archiveSkip, DryRun: true}
+		if archiveTo != "" {
+			if opts.Path, err = absPath(archiveTo); err != nil {
+				fatal(exitUsage, err)
+			}
+		}
+
+		// Check the archive first, to confirm what will be imported.
+		res, err := importArchive(args[0], st.scanner.RootDir, opts)
+		var conflicts *archive.ConflictError
+		if errors.As(err, &conflicts) {
+			for _, c := range conflicts.Conflicts {
+				fmt.Fprintf(os.Stderr, "  %s\n", c)
+			}
+			fatal(exitFailure, fmt.Errorf("%w; use --skip-existing to import the rest", err))
+		}
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		for _, c := range res.Skipped {
+			fmt.Fprintf(os.Stderr, "Skipping: %s\n", c)
+		}
+		if _, err := os.Stat(res.Path); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: %s does not exist, the project will be orphaned\n", res.Path)
+		}
+		into := "new project"
+		if res.Exists {
+			into = "existing project"
+		}
+		summary := fmt.Sprintf("%d sessions (%d files) of project [%s] into %s [%s] %s",
+			len(res.Sessions), res.Files, gemini.ShortID(res.Manifest.ProjectID), into, gemini.ShortID(res.ProjectID), res.Path)
+		if res.Files == 0 {
+			fmt.Println("Nothing to import")
+			return
+		}
+		if dryRun {
+			fmt.Printf("Would import %s\n", summary)
+			return
+		}
+		if !confirm(fmt.Sprintf("Import %s?", summary), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+
+		opts.DryRun = false
+		if res, err = importArchive(args[0], st.scanner.RootDir, opts); err != nil {
+			fatal(exitFailure, err)
+		}
+		for _, w := range importMeta(st.cache, res) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if err := st.cache.Save(); err != nil {
+			fatal(exitFailure, fmt.Errorf("project imported but cache could not be saved: %w", err))
+		}
+		fmt.Printf("Imported %s\n", summary)
+	},
+}
+
+func importArchive(path, rootDir string, opts archive.ImportOptions) (archive.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return archive.Result{}, err
+	}
+	defer f.Close()
+	return archive.Import(f, rootDir, opts)
+}
+
+// importMeta records the path and the metadata of an imported project in
+// the cache, keeping what was already recorded. It returns warnings about
+// metadata that could not be kept.
+func importMeta(c *cache.Cache, res archive.Result) []string {
+	var warnings []string
+	c.Set(res.ProjectID, res.Path)
+
+	archived := res.Manifest.Project
+	meta := c.ProjectMeta(res.ProjectID)
+	if meta.Alias == "" {
+		meta.Alias = archived.Alias
+	}
+	if meta.Note == "" {
+		meta.Note = archived.Note
+	}
+	meta.Tags = cache.ParseTags(append(meta.Tags, archived.Tags...)...)
+	if err := c.SetProjectMeta(res.ProjectID, meta); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, the alias is not imported", err))
+		meta.Alias = c.ProjectMeta(res.ProjectID).Alias
+		_ = c.SetProjectMeta(res.ProjectID, meta)
+	}
+
+	for _, id := range res.Sessions {
+		if m, ok := res.Manifest.SessionMeta[id]; ok && c.SessionMeta(id).IsZero() {
+			c.SetSessionMeta(id, m)
+		}
+	}
+	return warnings
+}
+
+func init() {
+	archiveExportCmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "Archive file to write, - for standard output")
+	archiveExportCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Overwrite an existing archive without asking")
+	archiveImportCmd.Flags().StringVar(&archiveTo, "to", "", "Directory to import the project for (default: the archived one)")
+	archiveImportCmd.Flags().BoolVar(&archiveSkip, "skip-existing", false, "Skip sessions and files that already exist instead of failing")
+	archiveImportCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without changing anything")
+	archiveImportCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	archiveCmd.AddCommand(archiveExportCmd, archiveImportCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/scanner"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkpointFormat string
+	checkpointAs     string
+)
+
+// listCheckpoint is the serialized form of a checkpoint in the list output.
+type listCheckpoint struct {
+	ProjectID    string    `json:"projectId"`
+	ProjectPath  string    `json:"projectPath"`
+	Name         string    `json:"name"`
+	MessageCount int       `json:"messageCount"`
+	Size         int64     `json:"size"`
+	SavedAt      time.Time `json:"savedAt"`
+}
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Manage conversations saved with '/chat save'",
+	Long: `Manage Gemini CLI checkpoints, the conversations saved with '/chat save <name>'
+and resumed with '/chat resume <name>'.
+
+Projects are referenced by their alias, a unique prefix of their hash or
+their directory path.`,
+}
+
+var checkpointListCmd = &cobra.Command{
+	Use:   "list [project]",
+	Short: "List checkpoints of all projects or a single project",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		projects := st.projects
+		if len(args) == 1 {
+			p, err := findProject(st, args[0])
+			if err != nil {
+				fatal(exitUsage, err)
+			}
+			projects = []scanner.ProjectData{p}
+		}
+
+		checkpoints := []listCheckpoint{}
+		for _, p := range projects {
+			for _, cp := range p.Checkpoints {
+				checkpoints = append(checkpoints, listCheckpoint{
+					ProjectID:    p.ID,
+					ProjectPath:  cachedPath(st, p.ID),
+					Name:         cp.Name,
+					MessageCount: cp.MessageCount,
+					Size:         cp.Size,
+					SavedAt:      cp.SavedAt,
+				})
+			}
+		}
+
+		switch checkpointFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(checkpoints); err != nil {
+				fatal(exitFailure, err)
+			}
+		case "table":
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "PROJECT\tPATH\tCHECKPOINT\tMESSAGES\tSIZE\tSAVED")
+			for _, cp := range checkpoints {
+				path := cp.ProjectPath
+				if path == "" {
+					path = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+					gemini.ShortID(cp.ProjectID), path, cp.Name, cp.MessageCount, humanize.IBytes(uint64(cp.Size)), cp.SavedAt.Local().Format("2006-01-02 15:04"))
+			}
+			_ = tw.Flush()
+		default:
+			fatal(exitUsage, fmt.Errorf("unknown format %q (expected table or json)", checkpointFormat))
+		}
+	},
+}
+
+var checkpointShowCmd = &cobra.Command{
+	Use:   "show <project> <name>",
+	Short: "Print the conversation stored in a checkpoint",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		cp, err := gemini.ReadCheckpoint(st.scanner.RootDir, p.ID, args[1])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		fmt.Printf("Checkpoint %q of project [%s], saved %s\n", cp.Name, gemini.ShortID(p.ID), cp.ModTime.Local().Format("2006-01-02 15:04"))
+		for _, c := range cp.History {
+			text := c.Text()
+			if text == "" {
+				continue
+			}
+			role := "User"
+			if c.Role == "model" {
+				role = "Gemini"
+			}
+			fmt.Printf("\n%s:\n%s\n", role, text)
+		}
+	},
+}
+
+var checkpointDeleteCmd = &cobra.Command{
+	Use:   "delete <project> <name>",
+	Short: "Permanently delete a checkpoint",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		cp, err := gemini.ReadCheckpoint(st.scanner.RootDir, p.ID, args[1])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		summary := fmt.Sprintf("checkpoint %q (%d messages) of project [%s]", cp.Name, len(cp.History), gemini.ShortID(p.ID))
+		if dryRun {
+			fmt.Printf("Would delete %s\n", summary)
+			return
+		}
+		if !confirm(fmt.Sprintf("Permanently delete %s?", summary), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+		if err := gemini.DeleteCheckpoint(st.scanner.RootDir, p.ID, cp.Name); err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Deleted %s\n", summary)
+	},
+}
+
+var checkpointRenameCmd = &cobra.Command{
+	Use:   "rename <project> <name> <new-name>",
+	Short: "Rename a checkpoint",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		if args[2] == "" {
+			fatal(exitUsage, fmt.Errorf("empty checkpoint name"))
+		}
+
+		if dryRun {
+			fmt.Printf("Would rename checkpoint %q of project [%s] to %q\n", args[1], gemini.ShortID(p.ID), args[2])
+			return
+		}
+		if err := gemini.RenameCheckpoint(st.scanner.RootDir, p.ID, args[1], args[2]); err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Renamed checkpoint %q of project [%s] to %q\n", args[1], gemini.ShortID(p.ID), args[2])
+	},
+}
+
+var checkpointCopyCmd = &cobra.Command{
+	Use:   "copy <project> <name> <target-project>",
+	Short: "Copy a checkpoint into another project",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		target, err := findProject(st, args[2])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		newName := checkpointAs
+		if newName == "" {
+			newName = args[1]
+		}
+
+		summary := fmt.Sprintf("checkpoint %q of project [%s] to %q in project [%s]", args[1], gemini.ShortID(p.ID), newName, gemini.ShortID(target.ID))
+		if dryRun {
+			fmt.Printf("Would copy %s\n", summary)
+			return
+		}
+		if err := gemini.CopyCheckpoint(st.scanner.RootDir, p.ID, args[1], target.ID, newName); err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Copied %s\n", summary)
+	},
+}
+
+var checkpointPromoteCmd = &cobra.Command{
+	Use:   "promote <project> <name>",
+	Short: "Turn a checkpoint into a session that can be resumed with 'gemini --resume'",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		if dryRun {
+			fmt.Printf("Would create a session from checkpoint %q of project [%s]\n", args[1], gemini.ShortID(p.ID))
+			return
+		}
+		s, err := gemini.PromoteCheckpoint(st.scanner.RootDir, p.ID, args[1])
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Created session %s (%d messages) from checkpoint %q\n", s.ID, len(s.Messages), args[1])
+	},
+}
+
+func init() {
+	checkpointListCmd.Flags().StringVarP(&checkpointFormat, "output", "o", "table", "Output format: table or json")
+	for _, c := range []*cobra.Command{checkpointDeleteCmd, checkpointRenameCmd, checkpointCopyCmd, checkpointPromoteCmd} {
+		c.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without changing anything")
+	}
+	checkpointDeleteCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	checkpointCopyCmd.Flags().StringVar(&checkpointAs, "as", "", "Name of the copy (defaults to the original name)")
+	checkpointCmd.AddCommand(checkpointListCmd, checkpointShowCmd, checkpointDeleteCmd, checkpointRenameCmd, checkpointCopyCmd, checkpointPromoteCmd)
+	rootCmd.AddCommand(checkpointCmd)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"geminictl/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configForce bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or create the geminictl config file",
+	Long: `geminictl reads its settings from config.yaml in its state directory. The
+file configures where the paths of projects are searched for: the shell
+histories and other places harvested for candidate directories, the roots
+searched in order, how deep below each root the search goes, gitignore-style
+exclude patterns, whether hidden directories are skipped and whether the search
+crosses into other filesystems.
+
+Settings missing from the file keep their defaults. 'geminictl config init'
+writes a commented file with the default settings to start from.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load(testbedDir)
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		path, err := config.FilePath(testbedDir)
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		if cfg.Path != "" {
+			fmt.Printf("Config file: %s\n\n", path)
+		} else {
+			fmt.Printf("Config file: %s (not found, using defaults)\n\n", path)
+		}
+
+		r := cfg.Resolve
+		fmt.Println("Roots:")
+		for i, root := range r.Roots {
+			depth := "unlimited"
+			if root.MaxDepth > 0 {
+				depth = fmt.Sprint(root.MaxDepth)
+			}
+			fmt.Printf("  %d. %s (max depth: %s)\n", i+1, root.Path, depth)
+		}
+		sources := "(none)"
+		if len(r.Sources) > 0 {
+			sources = strings.Join(r.Sources, ", ")
+		}
+		fmt.Printf("Sources: %s\n", sources)
+		fmt.Println("Exclude:")
+		if len(r.Exclude) == 0 {
+			fmt.Println("  (none)")
+		}
+		for _, p := range r.Exclude {
+			fmt.Printf("  %s\n", p)
+		}
+		fmt.Println("Mounts:")
+		if len(r.Mounts) == 0 {
+			fmt.Println("  (none)")
+		}
+		for _, m := range r.Mounts {
+			fmt.Printf("  %s <-> %s\n", m.From, m.To)
+		}
+
+		budget := "none"
+		if r.Budget > 0 {
+			budget = r.Budget.String()
+		}
+		workers := fmt.Sprint(r.Workers)
+		if r.Workers == 0 {
+			workers = "default"
+		}
+		fmt.Printf("Skip hidden:       %s\n", yesNo(r.SkipHidden))
+		fmt.Printf("Cross filesystems: %s\n", yesNo(r.CrossFilesystems))
+		fmt.Printf("Budget:            %s\n", budget)
+		fmt.Printf("Workers:           %s\n", workers)
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a config file with the default settings",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := config.FilePath(testbedDir)
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		if _, err := os.Stat(path); err == nil && !configForce {
+			fatal(exitUsage, fmt.Errorf("%s already exists (use --force to overwrite)", path))
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fatal(exitFailure, err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			fatal(exitFailure, err)
+		}
+		if err := os.WriteFile(path, []byte(config.DefaultYAML), 0644); err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	},
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	configInitCmd.Flags().BoolVarP(&configForce, "force", "f", false, "Overwrite an existing config file")
+	configCmd.AddCommand(configShowCmd, configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
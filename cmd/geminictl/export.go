@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"geminictl/internal/export"
+	"geminictl/internal/gemini"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat     string
+	exportOutput     string
+	exportDir        string
+	exportThoughts   bool
+	exportTokens     bool
+	exportTimestamps bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [<session>]",
+	Short: "Export sessions as Markdown, HTML, plain text or JSONL transcripts",
+	Long: `Export a session as a transcript that can be read or shared outside of
+Gemini CLI. Sessions split across several files are merged first.
+
+Formats:
+  md      Markdown, with thoughts in collapsible <details> blocks
+  html    a self-contained page with highlighted code and collapsible thoughts
+  txt     plain text
+  jsonl   a JSON object per message
+
+The transcript is written to standard output unless --output or --dir is
+given. The format defaults to the extension of --output, or Markdown.
+
+Without a session, --project and --dir export every session of the project
+into the directory, one file per session.`,
+	Example: `  geminictl export 3f2a -o session.html
+  geminictl export 3f2a --thoughts --tokens | less
+  geminictl export -p ~/src/app -d ~/transcripts -f md`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := exportFormatFor(exportFormat, exportOutput)
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		if exportOutput != "" && exportDir != "" {
+			fatal(exitUsage, fmt.Errorf("--output and --dir cannot be used together"))
+		}
+		if len(args) == 0 && (sessionProject == "" || exportDir == "") {
+			fatal(exitUsage, fmt.Errorf("give a session, or --project and --dir to export every session of a project"))
+		}
+
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		opts := export.Options{
+			Thoughts:   exportThoughts,
+			Tokens:     exportTokens,
+			Timestamps: exportTimestamps,
+		}
+
+		var sessions []gemini.Session
+		if len(args) == 1 {
+			p, s, err := findSessionArg(st, args[0])
+			if err != nil {
+				fatal(exitUsage, err)
+			}
+			full, err := gemini.GetSession(st.scanner.RootDir, p.ID, s.ID)
+			if err != nil {
+				fatal(exitFailure, err)
+			}
+			sessions = append(sessions, full)
+		} else {
+			p, err := findProject(st, sessionProject)
+			if err != nil {
+				fatal(exitUsage, err)
+			}
+			if sessions, err = gemini.Sessions(st.scanner.RootDir, p.ID); err != nil {
+				fatal(exitFailure, err)
+			}
+			if len(sessions) == 0 {
+				fatal(exitFailure, fmt.Errorf("project [%s] has no sessions", gemini.ShortID(p.ID)))
+			}
+		}
+
+		if exportOutput == "" && exportDir == "" {
+			opts.Title = st.cache.SessionTitle(sessions[0].ID, gemini.SummarizeSession(sessions[0]).Title)
+			if err := export.Write(os.Stdout, sessions[0], format, opts); err != nil {
+				fatal(exitFailure, err)
+			}
+			return
+		}
+
+		// Resolve the files first so that overwriting is confirmed once.
+		paths := make([]string, len(sessions))
+		var existing []string
+		for i, s := range sessions {
+			if exportDir != "" {
+				dir, err := absPath(exportDir)
+				if err != nil {
+					fatal(exitUsage, err)
+				}
+				title := st.cache.SessionTitle(s.ID, gemini.SummarizeSession(s).Title)
+				paths[i] = filepath.Join(dir, export.FileName(s, title, format))
+			} else if paths[i], err = absPath(exportOutput); err != nil {
+				fatal(exitUsage, err)
+			}
+			if _, err := os.Stat(paths[i]); err == nil {
+				existing = append(existing, paths[i])
+			}
+		}
+		if len(existing) == 1 && !confirm(fmt.Sprintf("Overwrite %s?", existing[0]), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+		if len(existing) > 1 && !confirm(fmt.Sprintf("Overwrite %d existing files in %s?", len(existing), filepath.Dir(existing[0])), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+
+		for i, s := range sessions {
+			opts.Title = st.cache.SessionTitle(s.ID, gemini.SummarizeSession(s).Title)
+			if err := export.WriteFile(paths[i], s, format, opts); err != nil {
+				fatal(exitFailure, err)
+			}
+			fmt.Printf("Exported session [%s] to %s\n", gemini.ShortID(s.ID), paths[i])
+		}
+		if len(sessions) > 1 {
+			fmt.Printf("Exported %d sessions\n", len(sessions))
+		}
+	},
+}
+
+// exportFormatFor returns the format given with --format, or the one of the
+// extension of the output file, defaulting to Markdown.
+func exportFormatFor(format, output string) (string, error) {
+	if format == "" {
+		if f, ok := export.FormatOf(output); ok {
+			return f, nil
+		}
+		return export.Markdown, nil
+	}
+	format = strings.ToLower(format)
+	if f, ok := export.FormatOf("." + format); ok {
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected %s)", format, strings.Join(export.Formats, ", "))
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "Format: md, html, txt or jsonl (default: from the --output extension, or md)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the transcript to this file instead of standard output")
+	exportCmd.Flags().StringVarP(&exportDir, "dir", "d", "", "Write the transcripts to this directory, named after the date and title")
+	exportCmd.Flags().StringVarP(&sessionProject, "project", "p", "", "Only look for the session in this project, or the project to export with --dir")
+	exportCmd.Flags().BoolVar(&exportThoughts, "thoughts", false, "Include the thoughts of the model")
+	exportCmd.Flags().BoolVar(&exportTokens, "tokens", false, "Include the models and token usage")
+	exportCmd.Flags().BoolVar(&exportTimestamps, "timestamps", true, "Include the time of the session and of each message")
+	exportCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Overwrite existing files without asking")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/scanner"
+	"geminictl/internal/variant"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listFormat string
+	listTag    string
+)
+
+// listProject is the serialized form of a project in the list output.
+type listProject struct {
+	ID            string           `json:"id"`
+	Path          string           `json:"path"`
+	Status        string           `json:"status"`
+	Matched       *variant.Variant `json:"matched,omitempty"`       // Variant of Path the hash matched, if not Path itself
+	PreviousPaths []string         `json:"previousPaths,omitempty"` // Former locations, most recent first
+	FirstSeen     time.Time        `json:"firstSeen,omitzero"`      // When geminictl first saw the project
+	Alias         string           `json:"alias,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Note          string           `json:"note,omitempty"`
+	Pinned        bool             `json:"pinned,omitempty"`
+	Sessions      []listSession    `json:"sessions"`
+}
+
+// listSession is the serialized form of a session in the list output.
+type listSession struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"` // Name given by the user, or derived from the first prompt
+	MessageCount int       `json:"messageCount"`
+	LastUpdate   time.Time `json:"lastUpdate"`
+	Alias        string    `json:"alias,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print all projects and sessions in a machine-readable format",
+	Long: `Print every project (hash, resolved path and status) and its sessions
+(ID, title, message count and last update) without launching the TUI.
+
+Projects whose location has not been resolved yet are reported with the
+status "Scanning". Run 'geminictl status' to resolve them.
+
+With --tag, only projects tagged with the tag, or with a session tagged with
+it, are printed. Pinned projects are printed first. The JSON output includes
+the aliases, tags and notes of projects and sessions, and the former
+locations of projects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var write func(io.Writer, []listProject) error
+		switch listFormat {
+		case "table":
+			write = writeListTable
+		case "json":
+			write = writeListJSON
+		case "csv":
+			write = writeListCSV
+		default:
+			fatal(exitUsage, fmt.Errorf("unknown format %q (expected table, json or csv)", listFormat))
+		}
+
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		tag := ""
+		if tags := cache.ParseTags(listTag); len(tags) > 0 {
+			tag = tags[0]
+		}
+
+		projects := make([]listProject, 0, len(st.projects))
+		for _, p := range st.projects {
+			if tag != "" && !hasTag(st, p, tag) {
+				continue
+			}
+			path, status := st.cache.Resolve(p.ID)
+			if path == p.ID {
+				path = ""
+			}
+			meta := st.cache.ProjectMeta(p.ID)
+			lp := listProject{
+				ID:       p.ID,
+				Path:     path,
+				Status:   status.String(),
+				Alias:    meta.Alias,
+				Tags:     meta.Tags,
+				Note:     meta.Note,
+				Pinned:   meta.Pinned,
+				Sessions: make([]listSession, 0, len(p.Sessions)),
+			}
+			if r, ok := st.cache.Record(p.ID); ok {
+				lp.Matched = r.Matched
+				lp.PreviousPaths = r.PreviousPaths
+				lp.FirstSeen = r.FirstSeen
+			}
+			for _, s := range p.Sessions {
+				meta := st.cache.SessionMeta(s.ID)
+				lp.Sessions = append(lp.Sessions, listSession{
+					ID:           s.ID,
+					Title:        st.cache.SessionTitle(s.ID, s.Title),
+					MessageCount: s.MessageCount,
+					LastUpdate:   s.LastUpdate,
+					Alias:        meta.Alias,
+					Tags:         meta.Tags,
+					Note:         meta.Note,
+				})
+			}
+			projects = append(projects, lp)
+		}
+
+		// Same ordering as the TUI: pinned projects first, then by path,
+		// with unresolved projects by hash.
+		sort.Slice(projects, func(i, j int) bool {
+			if projects[i].Pinned != projects[j].Pinned {
+				return projects[i].Pinned
+			}
+			return sortKey(projects[i]) < sortKey(projects[j])
+		})
+
+		if err := write(os.Stdout, projects); err != nil {
+			fatal(exitFailure, fmt.Errorf("writing output: %w", err))
+		}
+	},
+}
+
+// hasTag reports whether a project or one of its sessions is tagged with tag.
+func hasTag(st *state, p scanner.ProjectData, tag string) bool {
+	if st.cache.ProjectMeta(p.ID).HasTag(tag) {
+		return true
+	}
+	for _, s := range p.Sessions {
+		if st.cache.SessionMeta(s.ID).HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortKey(p listProject) string {
+	if p.Path == "" {
+		return p.ID
+	}
+	return p.Path
+}
+
+func writeListTable(w io.Writer, projects []listProject) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tSTATUS\tPATH\tSESSION\tTITLE\tMESSAGES\tLAST UPDATE")
+	for _, p := range projects {
+		path := p.Path
+		if path == "" {
+			path = "-"
+		}
+		if len(p.Sessions) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t-\t-\t-\t-\n", gemini.ShortID(p.ID), p.Status, path)
+			continue
+		}
+		for _, s := range p.Sessions {
+			title := s.Title
+			if title == "" {
+				title = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+				gemini.ShortID(p.ID), p.Status, path, gemini.ShortID(s.ID), truncateTitle(title, listTitleLen), s.MessageCount, s.LastUpdate.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	return tw.Flush()
+}
+
+func writeListJSON(w io.Writer, projects []listProject) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(projects)
+}
+
+func writeListCSV(w io.Writer, projects []listProject) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"project_id", "project_path", "project_status", "session_id", "message_count", "last_update", "session_title"})
+	for _, p := range projects {
+		if len(p.Sessions) == 0 {
+			_ = cw.Write([]string{p.ID, p.Path, p.Status, "", "", "", ""})
+			continue
+		}
+		for _, s := range p.Sessions {
+			_ = cw.Write([]string{p.ID, p.Path, p.Status, s.ID, strconv.Itoa(s.MessageCount), s.LastUpdate.Format(time.RFC3339), s.Title})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// listTitleLen is the length in runes titles are truncated to in tables.
+const listTitleLen = 40
+
+// truncateTitle shortens a title to max runes, ending it with an ellipsis.
+func truncateTitle(title string, max int) string {
+	r := []rune(title)
+	if len(r) <= max {
+		return title
+	}
+	if max < 1 {
+		return ""
+	}
+	return string(r[:max-1]) + "…"
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&listFormat, "output", "o", "table", "Output format: table, json or csv")
+	listCmd.Flags().StringVarP(&listTag, "tag", "t", "", "Only list projects tagged with this tag")
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metaClear  bool
+	metaRemove bool
+)
+
+// metaTarget is a project or session whose metadata a command edits.
+type metaTarget struct {
+	label string
+	get   func() cache.Meta
+	set   func(cache.Meta) error
+}
+
+// projectMetaTarget looks up the project given on the command line.
+func projectMetaTarget(st *state, ref string) metaTarget {
+	p, err := findProject(st, ref)
+	if err != nil {
+		fatal(exitUsage, err)
+	}
+	return metaTarget{
+		label: fmt.Sprintf("project [%s]", gemini.ShortID(p.ID)),
+		get:   func() cache.Meta { return st.cache.ProjectMeta(p.ID) },
+		set:   func(m cache.Meta) error { return st.cache.SetProjectMeta(p.ID, m) },
+	}
+}
+
+// sessionMetaTarget looks up the session given on the command line,
+// honouring the --project flag.
+func sessionMetaTarget(st *state, ref string) metaTarget {
+	_, s, err := findSessionArg(st, ref)
+	if err != nil {
+		fatal(exitUsage, err)
+	}
+	return metaTarget{
+		label: fmt.Sprintf("session [%s]", gemini.ShortID(s.ID)),
+		get:   func() cache.Meta { return st.cache.SessionMeta(s.ID) },
+		set: func(m cache.Meta) error {
+			st.cache.SetSessionMeta(s.ID, m)
+			return nil
+		},
+	}
+}
+
+// newMetaCmds returns the alias, tag and note commands for projects or
+// sessions.
+func newMetaCmds(noun string, lookup func(*state, string) metaTarget) []*cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:   fmt.Sprintf("alias <%s> [alias]", noun),
+		Short: fmt.Sprintf("Show or set the alias of a %s", noun),
+		Long: fmt.Sprintf(`Show or set the alias of a %[1]s, a short name shown instead of its hash
+and accepted wherever a %[1]s is referenced.`, noun),
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			runMeta(args[0], lookup, func(m *cache.Meta) bool {
+				switch {
+				case metaClear:
+					m.Alias = ""
+				case len(args) == 2:
+					m.Alias = strings.TrimSpace(args[1])
+				default:
+					fmt.Println(m.Alias)
+					return false
+				}
+				return true
+			})
+		},
+	}
+	aliasCmd.Flags().BoolVar(&metaClear, "clear", false, "Remove the alias")
+
+	tagCmd := &cobra.Command{
+		Use:   fmt.Sprintf("tag <%s> [tag...]", noun),
+		Short: fmt.Sprintf("Show, add or remove the tags of a %s", noun),
+		Long: fmt.Sprintf(`Show, add or remove the tags of a %s. Tags are case insensitive and may
+be separated by commas or spaces. Filter by tag with 'geminictl list --tag'
+or the [f] key of the TUI.`, noun),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runMeta(args[0], lookup, func(m *cache.Meta) bool {
+				tags := cache.ParseTags(args[1:]...)
+				switch {
+				case metaClear:
+					m.Tags = nil
+				case len(tags) == 0:
+					fmt.Println(strings.Join(m.Tags, " "))
+					return false
+				case metaRemove:
+					var kept []string
+					for _, t := range m.Tags {
+						if !(cache.Meta{Tags: tags}).HasTag(t) {
+							kept = append(kept, t)
+						}
+					}
+					m.Tags = kept
+				default:
+					m.Tags = cache.ParseTags(append(m.Tags, tags...)...)
+				}
+				return true
+			})
+		},
+	}
+	tagCmd.Flags().BoolVarP(&metaRemove, "remove", "r", false, "Remove the given tags instead of adding them")
+	tagCmd.Flags().BoolVar(&metaClear, "clear", false, "Remove all tags")
+
+	noteCmd := &cobra.Command{
+		Use:   fmt.Sprintf("note <%s> [text|-]", noun),
+		Short: fmt.Sprintf("Show or set the note of a %s", noun),
+		Long: fmt.Sprintf(`Show or set the note of a %s, free-form markdown shown in the TUI. With
+"-", the note is read from standard input.`, noun),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runMeta(args[0], lookup, func(m *cache.Meta) bool {
+				switch {
+				case metaClear:
+					m.Note = ""
+				case len(args) == 2 && args[1] == "-":
+					data, err := io.ReadAll(os.Stdin)
+					if err != nil {
+						fatal(exitFailure, fmt.Errorf("reading note: %w", err))
+					}
+					m.Note = strings.TrimSpace(string(data))
+				case len(args) > 1:
+					m.Note = strings.Join(args[1:], " ")
+				default:
+					if m.Note != "" {
+						fmt.Println(m.Note)
+					}
+					return false
+				}
+				return true
+			})
+		},
+	}
+	noteCmd.Flags().BoolVar(&metaClear, "clear", false, "Remove the note")
+
+	return []*cobra.Command{aliasCmd, tagCmd, noteCmd}
+}
+
+var sessionRenameCmd = &cobra.Command{
+	Use:   "rename <session> [name]",
+	Short: "Show or set the name of a session",
+	Long: `Show or set the name of a session, shown instead of the title derived from
+its first prompt. With --clear, the derived title is shown again.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runMeta(args[0], sessionMetaTarget, func(m *cache.Meta) bool {
+			switch {
+			case metaClear:
+				m.Name = ""
+			case len(args) == 2:
+				m.Name = strings.TrimSpace(args[1])
+			default:
+				fmt.Println(m.Name)
+				return false
+			}
+			return true
+		})
+	},
+}
+
+var projectPinCmd = &cobra.Command{
+	Use:   "pin <project>",
+	Short: "Pin a project to the top of the list",
+	Long: `Pin a project, so that 'geminictl list' and the TUI show it before the
+projects that are not pinned.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runMeta(args[0], projectMetaTarget, func(m *cache.Meta) bool {
+			m.Pinned = true
+			return true
+		})
+	},
+}
+
+var projectUnpinCmd = &cobra.Command{
+	Use:   "unpin <project>",
+	Short: "Unpin a project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runMeta(args[0], projectMetaTarget, func(m *cache.Meta) bool {
+			m.Pinned = false
+			return true
+		})
+	},
+}
+
+// runMeta looks up a project or session and lets edit change its metadata,
+// saving it if edit reports a change.
+func runMeta(ref string, lookup func(*state, string) metaTarget, edit func(*cache.Meta) bool) {
+	st, err := loadState()
+	if err != nil {
+		fatal(exitFailure, err)
+	}
+	t := lookup(st, ref)
+	m := t.get()
+	if !edit(&m) {
+		return
+	}
+	if err := t.set(m); err != nil {
+		fatal(exitUsage, err)
+	}
+	if err := st.cache.Save(); err != nil {
+		fatal(exitFailure, fmt.Errorf("saving cache: %w", err))
+	}
+	fmt.Printf("Updated %s\n", t.label)
+}
+
+func init() {
+	projectCmd.AddCommand(newMetaCmds("project", projectMetaTarget)...)
+	projectCmd.AddCommand(projectPinCmd, projectUnpinCmd)
+	sessionMetas := append(newMetaCmds("session", sessionMetaTarget), sessionRenameCmd)
+	sessionRenameCmd.Flags().BoolVar(&metaClear, "clear", false, "Remove the name")
+	for _, c := range sessionMetas {
+		c.Flags().StringVarP(&sessionProject, "project", "p", "", "Only look for the session in this project (hash prefix or path)")
+	}
+	sessionCmd.AddCommand(sessionMetas...)
+}
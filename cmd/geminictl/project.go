@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/relocate"
+	"geminictl/internal/scanner"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRun    bool
+	assumeYes bool
+	permanent bool
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage Gemini CLI projects",
+	Long: `Manage Gemini CLI projects without the TUI.
+
+Projects are referenced by their alias, a unique prefix of their hash or
+their directory path.`,
+}
+
+var projectDeleteCmd = &cobra.Command{
+	Use:   "delete <project>",
+	Short: "Move a project and all its sessions to the trash",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		path, _ := st.cache.Resolve(p.ID)
+		summary := fmt.Sprintf("project [%s] %s and its %d sessions (%d messages)", gemini.ShortID(p.ID), path, len(p.Sessions), countMessages(p))
+		if dryRun {
+			fmt.Printf("Would delete %s\n", summary)
+			return
+		}
+		if permanent {
+			if !confirm(fmt.Sprintf("Permanently delete %s?", summary), assumeYes) {
+				fatal(exitAborted, fmt.Errorf("aborted"))
+			}
+			if err := gemini.DeleteProject(st.scanner.RootDir, p.ID); err != nil {
+				fatal(exitFailure, err)
+			}
+			for _, s := range p.Sessions {
+				st.cache.ForgetSession(s.ID)
+			}
+			_ = st.cache.Delete(p.ID)
+			fmt.Printf("Deleted %s\n", summary)
+			return
+		}
+
+		if !confirm(fmt.Sprintf("Move %s to the trash?", summary), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+		e, err := st.trash.DeleteProject(st.scanner.RootDir, p.ID, cachedPath(st, p.ID),
+			st.cache.ProjectMeta(p.ID), st.cache.SessionsMeta(p.SessionIDs()...))
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Moved %s to the trash (entry %s)\n", summary, e.ID)
+	},
+}
+
+var projectMoveCmd = &cobra.Command{
+	Use:   "move <project> <new-path>",
+	Short: "Migrate a project to a new directory path",
+	Long: `Migrate a project to a new directory path. The project is re-hashed and
+the projectHash of all its sessions is updated, so that Gemini CLI picks up
+the history when run in the new directory.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		newPath, err := absPath(args[1])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		moveProject(st, p, newPath, assumeYes)
+	},
+}
+
+var relocateLimit int
+
+var projectRelocateCmd = &cobra.Command{
+	Use:   "relocate <project>",
+	Short: "Find where the directory of an orphaned project went and move it there",
+	Long: `Search for the directory an orphaned project was moved or renamed to and
+migrate the project there.
+
+Directories are ranked by how much they resemble the project: the same name,
+the same git remote and the same top-level files as recorded while the
+project's directory existed (or as mentioned in its sessions), and being next
+to the former location. The surroundings of the former location are searched
+first, then the roots in the config file.
+
+The candidates are listed and the chosen one is moved to; with --yes the best
+candidate is moved to without asking.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, err := findProject(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		oldPath, status := st.cache.Resolve(p.ID)
+		if status != cache.StatusOrphaned {
+			fatal(exitUsage, fmt.Errorf("project [%s] is not orphaned (status %s)", gemini.ShortID(p.ID), status))
+		}
+
+		fmt.Fprintf(os.Stderr, "Searching for the new location of %s...\n", oldPath)
+		res := relocate.Find(context.Background(), oldPath, projectFingerprint(st, p.ID, oldPath), relocate.Options{
+			Resolve: st.config.Resolve,
+			Limit:   relocateLimit,
+		})
+		if len(res.Candidates) == 0 {
+			fatal(exitFailure, fmt.Errorf("no directory resembling %s found (%s directories searched)", oldPath, humanize.Comma(int64(res.Dirs))))
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tSCORE\tPATH\tREASONS")
+		for i, c := range res.Candidates {
+			fmt.Fprintf(tw, "%d\t%.0f%%\t%s\t%s\n", i+1, c.Score*100, c.Path, strings.Join(c.Reasons, ", "))
+		}
+		_ = tw.Flush()
+		if dryRun {
+			return
+		}
+
+		choice := 1
+		if !assumeYes {
+			fmt.Fprintf(os.Stderr, "Move the project to which candidate? [1-%d, empty to cancel] ", len(res.Candidates))
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			choice, err = strconv.Atoi(strings.TrimSpace(answer))
+			if err != nil || choice < 1 || choice > len(res.Candidates) {
+				fatal(exitAborted, fmt.Errorf("aborted"))
+			}
+		}
+		moveProject(st, p, res.Candidates[choice-1].Path, true)
+	},
+}
+
+// projectFingerprint returns the recorded fingerprint of a project, falling
+// back to the top-level names mentioned in its sessions.
+func projectFingerprint(st *state, id, oldPath string) relocate.Fingerprint {
+	if fp, ok := st.fingerprints.Get(id); ok {
+		return fp
+	}
+	return relocate.Fingerprint{Names: relocate.NamesFromSessions(st.scanner.RootDir, id, oldPath)}
+}
+
+// moveProject migrates a project to newPath after asking for confirmation,
+// unless yes is set, and updates the cache.
+func moveProject(st *state, p scanner.ProjectData, newPath string, yes bool) {
+	newID, err := gemini.HashProjectID(newPath)
+	if err != nil {
+		fatal(exitUsage, err)
+	}
+	if newID == p.ID {
+		fmt.Println("Project is already located at", newPath)
+		return
+	}
+	for _, other := range st.projects {
+		if other.ID == newID {
+			fatal(exitUsage, fmt.Errorf("a project for %s already exists [%s]", newPath, gemini.ShortID(newID)))
+		}
+	}
+	if _, err := os.Stat(newPath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not exist, the project will be orphaned\n", newPath)
+	}
+
+	oldPath, _ := st.cache.Resolve(p.ID)
+	summary := fmt.Sprintf("project [%s] %s to %s [%s] (%d sessions)", gemini.ShortID(p.ID), oldPath, newPath, gemini.ShortID(newID), len(p.Sessions))
+	if dryRun {
+		fmt.Printf("Would move %s\n", summary)
+		return
+	}
+	if !confirm(fmt.Sprintf("Move %s?", summary), yes) {
+		fatal(exitAborted, fmt.Errorf("aborted"))
+	}
+
+	if _, err := gemini.MoveProject(st.scanner.RootDir, p.ID, newPath); err != nil {
+		fatal(exitFailure, err)
+	}
+	st.cache.Move(p.ID, newID, newPath)
+	if err := st.cache.Save(); err != nil {
+		fatal(exitFailure, fmt.Errorf("project moved but cache could not be saved: %w", err))
+	}
+	fmt.Printf("Moved %s\n", summary)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{projectDeleteCmd, projectMoveCmd, projectRelocateCmd} {
+		c.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without changing anything")
+		c.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	}
+	projectDeleteCmd.Flags().BoolVar(&permanent, "permanent", false, "Delete permanently instead of moving to the trash")
+	projectRelocateCmd.Flags().IntVarP(&relocateLimit, "limit", "l", relocate.DefaultLimit, "Maximum number of candidates to list")
+	projectCmd.AddCommand(projectDeleteCmd, projectMoveCmd, projectRelocateCmd)
+	rootCmd.AddCommand(projectCmd)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/scanner"
+)
+
+// findProject resolves a project reference given on the command line. The
+// reference is either the alias of a project, a prefix of the project hash or
+// a directory path.
+func findProject(st *state, ref string) (scanner.ProjectData, error) {
+	if id, ok := st.cache.ProjectByAlias(ref); ok {
+		for _, p := range st.projects {
+			if p.ID == id {
+				return p, nil
+			}
+		}
+	}
+	if isHex(ref) {
+		var matches []scanner.ProjectData
+		for _, p := range st.projects {
+			if strings.HasPrefix(p.ID, strings.ToLower(ref)) {
+				matches = append(matches, p)
+			}
+		}
+		switch len(matches) {
+		case 1:
+			return matches[0], nil
+		case 0:
+			// Fall through and try the reference as a path.
+		default:
+			var lines []string
+			for _, p := range matches {
+				path, status := st.cache.Resolve(p.ID)
+				lines = append(lines, fmt.Sprintf("  %s  %s (%s)", p.ID, path, status))
+			}
+			return scanner.ProjectData{}, fmt.Errorf("project prefix %q is ambiguous, candidates:\n%s", ref, strings.Join(lines, "\n"))
+		}
+	}
+
+	abs, err := absPath(ref)
+	if err != nil {
+		return scanner.ProjectData{}, err
+	}
+	id, err := gemini.HashProjectID(abs)
+	if err != nil {
+		return scanner.ProjectData{}, err
+	}
+	for _, p := range st.projects {
+		if p.ID == id {
+			return p, nil
+		}
+		if path, ok := st.cache.Get(p.ID); ok && path == abs {
+			return p, nil
+		}
+		if a, ok := st.cache.Match(p.ID); ok && filepath.Clean(a.Path) == abs {
+			return p, nil
+		}
+	}
+	return scanner.ProjectData{}, fmt.Errorf("no project matches %q", ref)
+}
+
+// findSession resolves a session alias or ID prefix. If project is non-nil,
+// the search is restricted to that project.
+func findSession(st *state, ref string, project *scanner.ProjectData) (scanner.ProjectData, scanner.Session, error) {
+	type match struct {
+		project scanner.ProjectData
+		session scanner.Session
+	}
+
+	if ref == "" {
+		return scanner.ProjectData{}, scanner.Session{}, fmt.Errorf("empty session reference")
+	}
+
+	projects := st.projects
+	if project != nil {
+		projects = []scanner.ProjectData{*project}
+	}
+
+	var matches []match
+	for _, p := range projects {
+		for _, s := range p.Sessions {
+			if st.cache.SessionMeta(s.ID).Alias == ref {
+				return p, s, nil
+			}
+			if strings.HasPrefix(s.ID, ref) {
+				matches = append(matches, match{p, s})
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return scanner.ProjectData{}, scanner.Session{}, fmt.Errorf("no session matches %q", ref)
+	case 1:
+		return matches[0].project, matches[0].session, nil
+	}
+
+	var lines []string
+	for _, m := range matches {
+		path, _ := st.cache.Resolve(m.project.ID)
+		lines = append(lines, fmt.Sprintf("  %s  in %s", m.session.ID, path))
+	}
+	return scanner.ProjectData{}, scanner.Session{}, fmt.Errorf("session prefix %q is ambiguous, candidates:\n%s", ref, strings.Join(lines, "\n"))
+}
+
+// absPath expands a leading ~ and returns the cleaned absolute path.
+func absPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	return filepath.Abs(path)
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range strings.ToLower(s) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// confirm asks the user a yes/no question on stdin. It returns true without
+// prompting if assumeYes is set.
+func confirm(prompt string, assumeYes bool) bool {
+	if assumeYes {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// cachedPath returns the known directory of a project, or "" if it is unresolved.
+func cachedPath(st *state, id string) string {
+	path, _ := st.cache.Get(id)
+	return path
+}
+
+func countMessages(p scanner.ProjectData) int {
+	total := 0
+	for _, s := range p.Sessions {
+		total += s.MessageCount
+	}
+	return total
+}
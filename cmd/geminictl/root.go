@@ -7,6 +7,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Exit codes used by geminictl commands.
+const (
+	exitFailure = 1 // The operation failed.
+	exitUsage   = 2 // Arguments were invalid, ambiguous or did not match anything.
+	exitAborted = 3 // The user declined a confirmation prompt.
+)
+
 var testbedDir string
 
 var rootCmd = &cobra.Command{
@@ -22,10 +29,16 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 }
 
+// fatal prints err to stderr and terminates the process with the given code.
+func fatal(code int, err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(code)
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&testbedDir, "testbed", "", "Path to a testbed directory (overrides default storage)")
-}
\ No newline at end of file
+}
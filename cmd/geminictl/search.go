@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchFormat  string
+	searchProject string
+	searchRole    string
+	searchModel   string
+	searchAfter   string
+	searchBefore  string
+	searchLimit   int
+	searchRebuild bool
+)
+
+// searchHit is the serialized form of a search hit in the JSON output.
+type searchHit struct {
+	ProjectID   string    `json:"projectId"`
+	ProjectPath string    `json:"projectPath,omitempty"`
+	SessionID   string    `json:"sessionId"`
+	MessageID   string    `json:"messageId"`
+	Index       int       `json:"index"`
+	Role        string    `json:"role"`
+	Model       string    `json:"model,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Snippet     string    `json:"snippet"`
+	File        string    `json:"file"`
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>...",
+	Short: "Search the content of all sessions",
+	Long: `Search the messages of all sessions using a full-text index that is kept
+next to geminictl's cache and updated incrementally on every search.
+
+Query syntax:
+  foo bar          messages containing both words
+  "foo bar"        the phrase
+  foo*             words starting with foo
+  foo OR bar       either word
+  -foo, NOT foo    messages without foo
+  project:<hash>   project hash prefix
+  session:<id>     session ID prefix
+  role:user        message type (user or gemini)
+  model:<name>     model name substring
+  after:<date>     messages at or after the date (YYYY-MM-DD)
+  before:<date>    messages before the date`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchFormat != "table" && searchFormat != "json" {
+			fatal(exitUsage, fmt.Errorf("unknown format %q (expected table or json)", searchFormat))
+		}
+
+		q, err := search.Parse(strings.Join(args, " "))
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		if searchRole != "" {
+			q.Role = strings.ToLower(searchRole)
+		}
+		if searchModel != "" {
+			q.Model = strings.ToLower(searchModel)
+		}
+		if searchAfter != "" {
+			if err := q.SetAfter(searchAfter); err != nil {
+				fatal(exitUsage, err)
+			}
+		}
+		if searchBefore != "" {
+			if err := q.SetBefore(searchBefore); err != nil {
+				fatal(exitUsage, err)
+			}
+		}
+		if q.Empty() {
+			fatal(exitUsage, fmt.Errorf("empty query"))
+		}
+
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		if searchProject != "" {
+			p, err := findProject(st, searchProject)
+			if err != nil {
+				fatal(exitFailure, err)
+			}
+			q.Project = p.ID
+		}
+
+		idx, err := openIndex(st, searchRebuild)
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		hits := idx.Search(q, searchLimit)
+
+		if searchFormat == "json" {
+			out := make([]searchHit, 0, len(hits))
+			for _, h := range hits {
+				out = append(out, searchHit{
+					ProjectID:   h.ProjectID,
+					ProjectPath: cachedPath(st, h.ProjectID),
+					SessionID:   h.SessionID,
+					MessageID:   h.MessageID,
+					Index:       h.Index,
+					Role:        h.Role,
+					Model:       h.Model,
+					Timestamp:   h.Timestamp,
+					Snippet:     h.Snippet,
+					File:        h.FilePath,
+				})
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				fatal(exitFailure, err)
+			}
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROJECT\tSESSION\t#\tROLE\tTIME\tSNIPPET")
+		for _, h := range hits {
+			project := cachedPath(st, h.ProjectID)
+			if project == "" {
+				project = gemini.ShortID(h.ProjectID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n",
+				project, gemini.ShortID(h.SessionID), h.Index+1, h.Role, h.Timestamp.Local().Format("2006-01-02 15:04"), h.Snippet)
+		}
+		_ = tw.Flush()
+		if len(hits) == 0 {
+			fmt.Fprintln(os.Stderr, "No matches.")
+		}
+	},
+}
+
+// openIndex loads the search index and brings it up to date with the storage.
+func openIndex(st *state, rebuild bool) (*search.Index, error) {
+	idx, err := search.Open(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening search index: %w", err)
+	}
+	if rebuild {
+		idx.Clear()
+	}
+	if _, err := idx.Update(st.scanner.RootDir); err != nil {
+		return nil, fmt.Errorf("updating search index: %w", err)
+	}
+	if err := idx.Save(); err != nil {
+		return nil, fmt.Errorf("saving search index: %w", err)
+	}
+	return idx, nil
+}
+
+func init() {
+	searchCmd.Flags().StringVarP(&searchFormat, "output", "o", "table", "Output format: table or json")
+	searchCmd.Flags().StringVarP(&searchProject, "project", "p", "", "Only search this project (hash prefix or path)")
+	searchCmd.Flags().StringVar(&searchRole, "role", "", "Only search messages of this type (user or gemini)")
+	searchCmd.Flags().StringVar(&searchModel, "model", "", "Only search messages of models containing this name")
+	searchCmd.Flags().StringVar(&searchAfter, "after", "", "Only search messages at or after this date (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&searchBefore, "before", "", "Only search messages before this date (YYYY-MM-DD)")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 50, "Maximum number of hits (0 for all)")
+	searchCmd.Flags().BoolVar(&searchRebuild, "rebuild", false, "Rebuild the index from scratch")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var sessionProject string
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage Gemini CLI sessions",
+	Long: `Manage Gemini CLI sessions without the TUI.
+
+Sessions are referenced by their alias or a unique prefix of their session
+ID. Use --project to restrict the lookup to a single project.`,
+}
+
+var sessionDeleteCmd = &cobra.Command{
+	Use:   "delete <session>",
+	Short: "Move a session to the trash",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, s, err := findSessionArg(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		path, _ := st.cache.Resolve(p.ID)
+		summary := fmt.Sprintf("session [%s] (%d messages) in %s", gemini.ShortID(s.ID), s.MessageCount, path)
+		if dryRun {
+			fmt.Printf("Would delete %s\n", summary)
+			return
+		}
+		if permanent {
+			if !confirm(fmt.Sprintf("Permanently delete %s?", summary), assumeYes) {
+				fatal(exitAborted, fmt.Errorf("aborted"))
+			}
+			if err := gemini.DeleteSession(st.scanner.RootDir, p.ID, s.ID); err != nil {
+				fatal(exitFailure, err)
+			}
+			st.cache.ForgetSession(s.ID)
+			_ = st.cache.Save()
+			fmt.Printf("Deleted %s\n", summary)
+			return
+		}
+
+		if !confirm(fmt.Sprintf("Move %s to the trash?", summary), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+		e, err := st.trash.DeleteSession(st.scanner.RootDir, p.ID, cachedPath(st, p.ID), s.ID, st.cache.SessionMeta(s.ID))
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Moved %s to the trash (entry %s)\n", summary, e.ID)
+	},
+}
+
+var sessionMoveCmd = &cobra.Command{
+	Use:   "move <session> <target-project>",
+	Short: "Move a session to another project",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		p, s, err := findSessionArg(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		target, err := findProject(st, args[1])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		if target.ID == p.ID {
+			fatal(exitUsage, fmt.Errorf("session [%s] already belongs to project [%s]", gemini.ShortID(s.ID), gemini.ShortID(p.ID)))
+		}
+
+		from, _ := st.cache.Resolve(p.ID)
+		to, _ := st.cache.Resolve(target.ID)
+		summary := fmt.Sprintf("session [%s] from %s to %s", gemini.ShortID(s.ID), from, to)
+		if dryRun {
+			fmt.Printf("Would move %s\n", summary)
+			return
+		}
+		if !confirm(fmt.Sprintf("Move %s?", summary), assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+
+		if err := gemini.MoveSession(st.scanner.RootDir, p.ID, target.ID, s.ID); err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Moved %s\n", summary)
+	},
+}
+
+// findSessionArg resolves a session reference, honouring the --project flag.
+func findSessionArg(st *state, ref string) (scanner.ProjectData, scanner.Session, error) {
+	if sessionProject == "" {
+		return findSession(st, ref, nil)
+	}
+	p, err := findProject(st, sessionProject)
+	if err != nil {
+		return scanner.ProjectData{}, scanner.Session{}, err
+	}
+	return findSession(st, ref, &p)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{sessionDeleteCmd, sessionMoveCmd} {
+		c.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without changing anything")
+		c.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+		c.Flags().StringVarP(&sessionProject, "project", "p", "", "Only look for the session in this project (hash prefix or path)")
+	}
+	sessionDeleteCmd.Flags().BoolVar(&permanent, "permanent", false, "Delete permanently instead of moving to the trash")
+	sessionCmd.AddCommand(sessionDeleteCmd, sessionMoveCmd)
+	rootCmd.AddCommand(sessionCmd)
+}
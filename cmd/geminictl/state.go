@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/config"
+	"geminictl/internal/gemini"
+	"geminictl/internal/relocate"
+	"geminictl/internal/scanner"
+	"geminictl/internal/trash"
+)
+
+// state bundles the config, cache, scanner, trash, fingerprints and scan
+// results shared by all commands.
+type state struct {
+	config       config.Config
+	cache        *cache.Cache
+	fingerprints *relocate.Store
+	scanner      *scanner.Scanner
+	trash        *trash.Trash
+	projects     []scanner.ProjectData
+}
+
+// loadState initializes the cache, scanner and trash, recovers interrupted
+// operations, scans the Gemini storage, drops cache entries for projects that
+// no longer exist in storage, purges expired trash entries and refreshes the
+// fingerprints of project directories.
+func loadState() (*state, error) {
+	cfg, err := config.Load(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	c, err := cache.NewCache(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("initializing cache: %w", err)
+	}
+
+	if err := c.Load(); err != nil {
+		return nil, fmt.Errorf("loading cache: %w", err)
+	}
+	c.Mounts = cfg.Resolve.Mounts
+
+	scan, err := scanner.NewScanner(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("initializing scanner: %w", err)
+	}
+
+	tr, err := trash.New(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("initializing trash: %w", err)
+	}
+
+	// Finish or revert operations interrupted by a crash before looking at
+	// the storage, so that the scan sees a consistent state.
+	if op, err := gemini.Recover(scan.RootDir); err != nil {
+		return nil, fmt.Errorf("recovering interrupted operation: %w", err)
+	} else if op != "" {
+		fmt.Fprintf(os.Stderr, "Recovered interrupted operation: %s\n", op)
+	}
+	if op, err := tr.Recover(); err != nil {
+		return nil, fmt.Errorf("recovering interrupted trash operation: %w", err)
+	} else if op != "" {
+		fmt.Fprintf(os.Stderr, "Recovered interrupted operation: %s\n", op)
+	}
+
+	purged, err := tr.Purge(trash.DefaultMaxAge, trash.DefaultMaxSize)
+	for _, e := range purged {
+		e.ForgetCache(c, scan.RootDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("purging trash: %w", err)
+	}
+
+	projects, err := scan.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("scanning sessions: %w", err)
+	}
+
+	// --- Integrity Check: Garbage Collection ---
+	// Only records of projects whose storage directory is gone are dropped,
+	// not those of projects the scan merely skipped, and never what the user
+	// recorded: that goes when the project or session is purged from the
+	// trash or deleted permanently.
+	activeIDs := make(map[string]bool)
+	for _, p := range projects {
+		activeIDs[p.ID] = true
+	}
+
+	changed := len(purged) > 0
+	for _, hash := range c.IDs() {
+		if activeIDs[hash] || !c.ProjectMeta(hash).IsZero() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(scan.RootDir, hash)); os.IsNotExist(err) {
+			c.Forget(hash)
+			changed = true
+		}
+	}
+	if changed {
+		_ = c.Save()
+	}
+
+	// Fingerprint the directories of valid projects, so that they can be
+	// found again should they be moved or renamed.
+	fps, err := relocate.OpenStore(testbedDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening fingerprints: %w", err)
+	}
+	for _, p := range projects {
+		if path, status := c.Resolve(p.ID); status == cache.StatusValid {
+			fps.Refresh(p.ID, path)
+		}
+	}
+	fps.Prune(activeIDs)
+	_ = fps.Save()
+
+	return &state{config: cfg, cache: c, fingerprints: fps, scanner: scan, trash: tr, projects: projects}, nil
+}
@@ -2,69 +2,72 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/spf13/cobra"
-	"geminictl/internal/cache"
 	"geminictl/internal/scanner"
+	"geminictl/internal/search"
 	"geminictl/internal/tui"
+	"geminictl/internal/watch"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusPoll   bool
+	statusBudget time.Duration
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show the status of Gemini CLI projects and sessions",
-	Run: func(cmd *cobra.Command, args []string) {
-		c, err := cache.NewCache(testbedDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing cache: %v\n", err)
-			os.Exit(1)
-		}
+	Long: `Show the status of Gemini CLI projects and sessions in an interactive TUI.
 
-		if err := c.Load(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading cache: %v\n", err)
-			os.Exit(1)
-		}
+The TUI watches the Gemini storage and picks up sessions created or updated
+by Gemini CLI in other terminals. Where filesystem notifications are not
+available it polls for changes instead; --poll forces polling, e.g. on
+network filesystems that accept notification watches but never deliver events.
 
-		scan, err := scanner.NewScanner(testbedDir)
+Paths of projects not in the cache are resolved in the background by hashing
+directories: first those found in shell histories, the zoxide and autojump
+databases and git worktrees, then those below the roots in the config file
+(see 'geminictl config'). --resolve-budget overrides the configured limit on
+how long this may take; projects not found in time are shown as unlocated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing scanner: %v\n", err)
-			os.Exit(1)
+			fatal(exitFailure, err)
 		}
 
-		projects, err := scan.Scan()
+		idx, err := search.Open(testbedDir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error scanning sessions: %v\n", err)
-			os.Exit(1)
+			fatal(exitFailure, fmt.Errorf("opening search index: %w", err))
 		}
 
-		// --- Integrity Check: Garbage Collection ---
-		activeIDs := make(map[string]bool)
-		for _, p := range projects {
-			activeIDs[p.ID] = true
+		var w *watch.Watcher
+		if statusPoll {
+			w = watch.NewPolling(st.scanner.RootDir, watch.DefaultPollInterval)
+		} else {
+			w = watch.New(st.scanner.RootDir, watch.DefaultDebounce)
 		}
+		defer w.Close()
 
-		changed := false
-		for hash := range c.Data {
-			if !activeIDs[hash] {
-				c.Delete(hash)
-				changed = true
-			}
+		m := tui.NewModel(st.projects, st.cache, st.scanner, st.trash, idx, w)
+		m.ResolveOptions = scanner.ResolveOptions{Resolve: st.config.Resolve}
+		if cmd.Flags().Changed("resolve-budget") {
+			m.ResolveOptions.Budget = statusBudget
 		}
-		if changed {
-			_ = c.Save()
-		}
-
-		m := tui.NewModel(projects, c, scan)
+		m.Fingerprints = st.fingerprints
+		defer m.Close()
 		p := tea.NewProgram(m, tea.WithAltScreen())
 
 		if _, err := p.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-			os.Exit(1)
+			fatal(exitFailure, fmt.Errorf("running TUI: %w", err))
 		}
 	},
 }
 
 func init() {
+	statusCmd.Flags().DurationVar(&statusBudget, "resolve-budget", 0, "Maximum time to spend resolving project paths, e.g. 30s (0 for no limit; default from the config file)")
+	statusCmd.Flags().BoolVar(&statusPoll, "poll", false, "Poll for storage changes instead of using filesystem notifications")
 	rootCmd.AddCommand(statusCmd)
-}
\ No newline at end of file
+}
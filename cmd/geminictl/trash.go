@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/trash"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashFormat    string
+	trashOlderThan string
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Inspect, restore and empty deleted projects and sessions",
+	Long: fmt.Sprintf(`Deleted projects and sessions are moved into a trash directory managed by
+geminictl and can be restored from there.
+
+Entries older than %d days are purged automatically, as are the oldest entries
+once the trash grows beyond %s.`, int(trash.DefaultMaxAge.Hours()/24), humanize.IBytes(trash.DefaultMaxSize)),
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trash entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		entries, err := st.trash.List()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		switch trashFormat {
+		case "json":
+			if entries == nil {
+				entries = []trash.Entry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				fatal(exitFailure, err)
+			}
+		case "table":
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ENTRY\tKIND\tPROJECT\tSESSION\tPATH\tSIZE\tDELETED")
+			for _, e := range entries {
+				session, path := "-", e.ProjectPath
+				if e.SessionID != "" {
+					session = gemini.ShortID(e.SessionID)
+				}
+				if path == "" {
+					path = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					e.ID, e.Kind, gemini.ShortID(e.ProjectID), session, path, humanize.IBytes(uint64(e.Size)), humanize.Time(e.DeletedAt))
+			}
+			_ = tw.Flush()
+		default:
+			fatal(exitUsage, fmt.Errorf("unknown format %q (expected table or json)", trashFormat))
+		}
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <entry>",
+	Short: "Restore a trash entry into Gemini storage",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		e, err := findTrashEntry(st, args[0])
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+
+		if _, err := st.trash.Restore(st.scanner.RootDir, e.ID); err != nil {
+			fatal(exitFailure, err)
+		}
+		if err := e.RestoreCache(st.cache); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, the alias is not restored\n", err)
+		}
+		_ = st.cache.Save()
+
+		if e.Kind == trash.KindSession {
+			fmt.Printf("Restored session [%s] into project [%s]\n", gemini.ShortID(e.SessionID), gemini.ShortID(e.ProjectID))
+		} else {
+			fmt.Printf("Restored project [%s] %s\n", gemini.ShortID(e.ProjectID), e.ProjectPath)
+		}
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trash entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := loadState()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+
+		var before time.Time
+		prompt := "Permanently delete all trash entries?"
+		if trashOlderThan != "" {
+			age, err := parseAge(trashOlderThan)
+			if err != nil {
+				fatal(exitUsage, err)
+			}
+			before = time.Now().Add(-age)
+			prompt = fmt.Sprintf("Permanently delete trash entries older than %s?", trashOlderThan)
+		}
+		if !confirm(prompt, assumeYes) {
+			fatal(exitAborted, fmt.Errorf("aborted"))
+		}
+
+		removed, err := st.trash.Empty(before)
+		for _, e := range removed {
+			e.ForgetCache(st.cache, st.scanner.RootDir)
+		}
+		_ = st.cache.Save()
+		if err != nil {
+			fatal(exitFailure, err)
+		}
+		fmt.Printf("Removed %d trash entries\n", len(removed))
+	},
+}
+
+// findTrashEntry resolves a unique prefix of a trash entry ID.
+func findTrashEntry(st *state, ref string) (trash.Entry, error) {
+	entries, err := st.trash.List()
+	if err != nil {
+		return trash.Entry{}, err
+	}
+
+	var matches []trash.Entry
+	for _, e := range entries {
+		if ref != "" && strings.HasPrefix(e.ID, ref) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return trash.Entry{}, fmt.Errorf("no trash entry matches %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+
+	var lines []string
+	for _, e := range matches {
+		lines = append(lines, fmt.Sprintf("  %s  %s [%s] deleted %s", e.ID, e.Kind, gemini.ShortID(e.ProjectID), humanize.Time(e.DeletedAt)))
+	}
+	return trash.Entry{}, fmt.Errorf("trash entry prefix %q is ambiguous, candidates:\n%s", ref, strings.Join(lines, "\n"))
+}
+
+// parseAge parses a duration that may additionally use a 'd' (days) suffix.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func init() {
+	trashListCmd.Flags().StringVarP(&trashFormat, "output", "o", "table", "Output format: table or json")
+	trashEmptyCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only delete entries older than this age (e.g. 7d, 12h)")
+	trashEmptyCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
+}
@@ -10,28 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
-)
-
-var (
-	ignoreDirs = map[string]bool{
-		"node_modules": true,
-		".git":         true,
-		".npm":         true,
-		".cache":       true,
-		".gemini":      true,
-		".vscode":      true,
-		".idea":        true,
-		"go":           true, // ~/go/pkg can be huge
-		"Library":      true, // macOS specific system dir
-	}
 
-	// Common external dev paths (outside home)
-	externalCommonDirs = []string{
-		"/opt",
-		"/var/www",
-		"/usr/local/src",
-		"/srv",
-	}
+	"geminictl/internal/config"
 )
 
 type Session struct {
@@ -43,7 +23,7 @@ type ProjectData struct {
 }
 
 func main() {
-	deepScan := flag.Bool("deep", false, "Enable deep scan of root filesystem (Tier 4)")
+	deepScan := flag.Bool("deep", false, "Enable deep scan of the root filesystem")
 	flag.Parse()
 
 	// 1. Get unknown project IDs
@@ -58,54 +38,32 @@ func main() {
 	}
 	fmt.Printf("Found %d projects to resolve: %v\n", len(unknownIDs), unknownIDs)
 
-	resolved := make(map[string]string)
-	visited := make(map[string]bool)
-	home, _ := os.UserHomeDir()
-
-	// Tier 1: Desktop Scan
-	fmt.Println("\n--- Tier 1: Desktop Scan ---")
-	t1Start := time.Now()
-	desktopPath := filepath.Join(home, "Desktop")
-	if _, err := os.Stat(desktopPath); err == nil {
-		scan(desktopPath, unknownIDs, resolved, visited, true)
-	}
-	fmt.Printf("Tier 1 took: %v\n", time.Since(t1Start))
-	report(resolved, unknownIDs)
-	if len(resolved) == len(unknownIDs) {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
 		return
 	}
+	skip := cfg.Resolve.Matcher()
 
-	// Tier 2: Home Directory Scan
-	fmt.Println("\n--- Tier 2: Home Directory Scan ---")
-	t2Start := time.Now()
-	scan(home, unknownIDs, resolved, visited, true)
-	fmt.Printf("Tier 2 took: %v\n", time.Since(t2Start))
-	report(resolved, unknownIDs)
-	if len(resolved) == len(unknownIDs) {
-		return
-	}
+	resolved := make(map[string]string)
+	visited := make(map[string]bool)
 
-	// Tier 3: External Common Paths
-	fmt.Println("\n--- Tier 3: External Common Paths ---")
-	t3Start := time.Now()
-	for _, path := range externalCommonDirs {
-		if _, err := os.Stat(path); err == nil {
-			scan(path, unknownIDs, resolved, visited, true)
+	// Walk the configured roots in order. The root filesystem is only
+	// walked with -deep.
+	for i, root := range cfg.Resolve.Roots {
+		if root.Path == "/" && !*deepScan {
+			continue
 		}
-	}
-	fmt.Printf("Tier 3 took: %v\n", time.Since(t3Start))
-	report(resolved, unknownIDs)
-	if len(resolved) == len(unknownIDs) {
-		return
-	}
-
-	// Tier 4: Root Filesystem
-	if *deepScan {
-		fmt.Println("\n--- Tier 4: Root Filesystem ---")
-		t4Start := time.Now()
-		scan("/", unknownIDs, resolved, visited, true)
-		fmt.Printf("Tier 4 took: %v\n", time.Since(t4Start))
+		fmt.Printf("\n--- Tier %d: %s ---\n", i+1, root.Path)
+		start := time.Now()
+		if _, err := os.Stat(root.Path); err == nil {
+			scan(root, skip, unknownIDs, resolved, visited)
+		}
+		fmt.Printf("Tier %d took: %v\n", i+1, time.Since(start))
 		report(resolved, unknownIDs)
+		if len(resolved) == len(unknownIDs) {
+			return
+		}
 	}
 }
 
@@ -126,14 +84,14 @@ func getUnknownProjectIDs() ([]string, error) {
 	return ids, nil
 }
 
-func scan(root string, targets []string, resolved map[string]string, visited map[string]bool, recursive bool) {
+func scan(root config.Root, skip *config.Matcher, targets []string, resolved map[string]string, visited map[string]bool) {
 	// Avoid re-scanning if we've already fully visited this root or its parent
 	// Ideally we check visited inside walk, but here we just prevent top-level redundancy
-	if visited[root] {
+	if visited[root.Path] {
 		return
 	}
 
-	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return filepath.SkipDir // Skip permission denied, etc.
 		}
@@ -144,11 +102,13 @@ func scan(root string, targets []string, resolved map[string]string, visited map
 		}
 
 		if d.IsDir() {
-			name := d.Name()
-			if ignoreDirs[name] || (strings.HasPrefix(name, ".") && name != ".") {
+			if path != root.Path && skip.Skip(path) {
 				visited[path] = true // Mark ignored as visited so we don't try again
 				return filepath.SkipDir
 			}
+			if root.MaxDepth > 0 && depth(root.Path, path) > root.MaxDepth {
+				return filepath.SkipDir
+			}
 
 			// Check hash
 			h := hashPath(path)
@@ -169,6 +129,15 @@ func scan(root string, targets []string, resolved map[string]string, visited map
 	})
 }
 
+// depth returns how many directories path is below root.
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
 func hashPath(path string) string {
 	// Standardize path: absolute, no trailing slash
 	abs, err := filepath.Abs(path)
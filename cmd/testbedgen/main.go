@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"geminictl/internal/cache"
 	"geminictl/internal/gemini"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -18,8 +19,9 @@ type Config struct {
 }
 
 type ProjectConfig struct {
-	Path     string   `json:"path"`
-	Sessions []string `json:"sessions"`
+	Path        string   `json:"path"`
+	Sessions    []string `json:"sessions"`
+	Checkpoints []string `json:"checkpoints"`
 }
 
 var (
@@ -81,7 +83,11 @@ func runGenerator(cmd *cobra.Command, args []string) {
 
 	// Format: 2026-01-20T08:12:58.167Z
 	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
-	cacheData := make(map[string]string)
+	cacheData, err := cache.NewCache(testbedDir)
+	if err != nil {
+		fmt.Printf("Error initializing cache: %v\n", err)
+		os.Exit(1)
+	}
 
 	// 4. Process Projects
 	for i, p := range config.Projects {
@@ -103,9 +109,9 @@ func runGenerator(cmd *cobra.Command, args []string) {
 		projectHash, _ = gemini.HashProjectID(finalPath)
 
 		if !isUnlocated {
-			cacheData[projectHash] = finalPath
+			cacheData.Set(projectHash, finalPath)
 		} else {
-			cacheData[projectHash] = ""
+			cacheData.Set(projectHash, "")
 		}
 
 		if verbose {
@@ -117,6 +123,7 @@ func runGenerator(cmd *cobra.Command, args []string) {
 		}
 
 		// 5. Create Sessions
+		var logs []gemini.LogEntry
 		for _, sID := range p.Sessions {
 			content := string(sessionTemplateData)
 			content = strings.ReplaceAll(content, "{{PROJECT_HASH}}", projectHash)
@@ -134,12 +141,31 @@ func runGenerator(cmd *cobra.Command, args []string) {
 			if err := gemini.WriteSession(geminiRoot, projectHash, s); err != nil {
 				fmt.Printf("Error writing session %s: %v\n", sID, err)
 			}
+
+			for i, msg := range s.Messages {
+				if msg.Type == "user" {
+					logs = append(logs, gemini.LogEntry{SessionID: sID, MessageID: i, Type: msg.Type, Message: msg.Content, Timestamp: msg.Timestamp})
+				}
+			}
+		}
+
+		// 6. Create logs.json and checkpoints
+		if len(logs) > 0 {
+			logsJSON, _ := json.MarshalIndent(logs, "", "  ")
+			_ = os.WriteFile(gemini.LogsPath(geminiRoot, projectHash), logsJSON, 0644)
+		}
+		for _, name := range p.Checkpoints {
+			history := []gemini.Content{
+				{Role: "user", Parts: []gemini.Part{{Text: "Hello from the testbed."}}},
+				{Role: "model", Parts: []gemini.Part{{Text: fmt.Sprintf("Acknowledged. This is checkpoint %q.", name)}}},
+			}
+			cpJSON, _ := json.MarshalIndent(history, "", "  ")
+			_ = os.WriteFile(gemini.CheckpointPath(geminiRoot, projectHash, name), cpJSON, 0644)
 		}
 	}
 
-	// 6. Write Cache
-	cacheJSON, _ := json.MarshalIndent(cacheData, "", "  ")
-	_ = os.WriteFile(filepath.Join(geminictlConfigRoot, "cache.json"), cacheJSON, 0644)
+	// 7. Write Cache
+	_ = cacheData.Save()
 
 	fmt.Printf("Created testbed in %s\n", testbedDir)
 }
@@ -0,0 +1,469 @@
+// Package archive writes projects to portable tar.gz archives and imports
+// them into Gemini storage, possibly under another project directory.
+//
+// An archive holds a manifest.json describing the project and the files of
+// its storage directory, verbatim, below project/.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+)
+
+// Version is the version of the archive format written by Export.
+const Version = 1
+
+const (
+	manifestFile = "manifest.json"
+	projectDir   = "project"
+)
+
+// Extension is the file extension of archives.
+const Extension = ".tar.gz"
+
+// Manifest describes the project held in an archive.
+type Manifest struct {
+	Version     int                   `json:"version"`
+	ProjectID   string                `json:"projectId"`
+	ProjectPath string                `json:"projectPath,omitempty"` // Original directory, "" if unknown
+	CreatedAt   time.Time             `json:"createdAt"`
+	Sessions    int                   `json:"sessions"`
+	Files       int                   `json:"files"`
+	Project     cache.Meta            `json:"project"`               // What the user recorded about the project
+	SessionMeta map[string]cache.Meta `json:"sessionMeta,omitempty"` // By session ID
+}
+
+// FileName returns the default name of the archive of a project, made of
+// the base name of its directory, the short project ID and the date.
+func FileName(projectID, projectPath string, now time.Time) string {
+	name := gemini.ShortID(projectID)
+	if base := filepath.Base(projectPath); projectPath != "" && base != "/" && base != "." {
+		name = base + "-" + name
+	}
+	return name + "-" + now.Format("20060102") + Extension
+}
+
+// Export writes the storage directory of a project to w as a tar.gz archive.
+// The version, ID, creation time and counts of m are filled in; the path and
+// the metadata are left to the caller. It returns the written manifest.
+func Export(w io.Writer, rootDir, projectID string, m Manifest) (Manifest, error) {
+	dir := filepath.Join(rootDir, projectID)
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == journal.DirName {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	sessions, err := gemini.ListSessionFiles(rootDir, projectID)
+	if err != nil {
+		return m, err
+	}
+	ids := make(map[string]bool)
+	for _, p := range sessions {
+		if meta, err := gemini.ReadSessionMeta(p); err == nil {
+			ids[meta.ID] = true
+		}
+	}
+
+	m.Version = Version
+	m.ProjectID = projectID
+	m.CreatedAt = time.Now().UTC()
+	m.Sessions = len(ids)
+	m.Files = len(files)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	hdr := &tar.Header{Name: manifestFile, Mode: 0644, Size: int64(len(data)), ModTime: m.CreatedAt}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return m, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return m, err
+	}
+	for _, p := range files {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return m, err
+		}
+		if err := addFile(tw, p, path.Join(projectDir, filepath.ToSlash(rel))); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+func addFile(tw *tar.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Conflict is something of an archive that already exists in Gemini storage:
+// a session, in any project, or another file of the target project.
+type Conflict struct {
+	SessionID string // Empty for files other than sessions
+	ProjectID string // Project holding the existing session or file
+	File      string // Path relative to the project directory, for files
+}
+
+func (c Conflict) String() string {
+	if c.SessionID != "" {
+		return fmt.Sprintf("session %s already exists in project [%s]", c.SessionID, gemini.ShortID(c.ProjectID))
+	}
+	return fmt.Sprintf("%s already exists in project [%s]", c.File, gemini.ShortID(c.ProjectID))
+}
+
+// ConflictError is returned by Import when the archive conflicts with Gemini
+// storage and conflicts are not skipped.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Conflicts) == 1 {
+		return e.Conflicts[0].String()
+	}
+	return fmt.Sprintf("%d sessions or files of the archive already exist", len(e.Conflicts))
+}
+
+// ImportOptions controls Import.
+type ImportOptions struct {
+	Path          string // Directory of the imported project, the original one if empty
+	SkipConflicts bool   // Leave out what already exists instead of failing
+	DryRun        bool   // Only check the archive and report what would be imported
+}
+
+// Result describes an import.
+type Result struct {
+	Manifest  Manifest
+	ProjectID string     // ID of the project imported into
+	Path      string     // Directory of the project imported into
+	Exists    bool       // Whether the project already had a storage directory
+	Sessions  []string   // Imported session IDs
+	Files     int        // Imported files, including sessions
+	Skipped   []Conflict // Conflicts left out with SkipConflicts
+}
+
+// Import restores a project archive into Gemini storage under the project ID
+// of opts.Path, rewriting the projectHash of its sessions like
+// gemini.MoveProject does. Sessions are merged into the project if it exists.
+// Either everything is imported or, on failure, nothing is.
+func Import(r io.Reader, rootDir string, opts ImportOptions) (Result, error) {
+	staging, err := os.MkdirTemp("", "geminictl-import-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(staging)
+
+	m, err := extract(r, staging)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Manifest: m, Path: opts.Path}
+	if res.Path == "" {
+		if m.ProjectPath == "" {
+			return res, fmt.Errorf("the archive does not record the directory of the project, give one to import it into")
+		}
+		res.Path = m.ProjectPath
+	}
+	if res.ProjectID, err = gemini.HashProjectID(res.Path); err != nil {
+		return res, err
+	}
+	dst := filepath.Join(rootDir, res.ProjectID)
+	if _, err := os.Stat(dst); err == nil {
+		res.Exists = true
+	}
+
+	plan, err := planImport(staging, rootDir, res.ProjectID)
+	if err != nil {
+		return res, err
+	}
+	if len(plan.conflicts) > 0 && !opts.SkipConflicts {
+		return res, &ConflictError{Conflicts: plan.conflicts}
+	}
+	res.Skipped = plan.conflicts
+	res.Sessions = plan.sessions
+	res.Files = len(plan.files)
+	if opts.DryRun || len(plan.files) == 0 {
+		return res, nil
+	}
+	return res, apply(plan, rootDir, res.ProjectID, !res.Exists)
+}
+
+// extract unpacks an archive into dir and returns its manifest.
+func extract(r io.Reader, dir string) (Manifest, error) {
+	var m Manifest
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("not a project archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Name == manifestFile {
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, fmt.Errorf("parsing %s: %w", manifestFile, err)
+			}
+			found = true
+			continue
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, projectDir+"/")
+		if !ok || hdr.Typeflag != tar.TypeReg || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return m, fmt.Errorf("unexpected entry %q in archive", hdr.Name)
+		}
+		target := filepath.Join(dir, projectDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return m, err
+		}
+		if err := writeFile(target, tr, hdr.ModTime); err != nil {
+			return m, err
+		}
+	}
+	if !found {
+		return m, fmt.Errorf("not a project archive: %s is missing", manifestFile)
+	}
+	if m.Version > Version {
+		return m, fmt.Errorf("archive version %d is newer than the supported version %d", m.Version, Version)
+	}
+	// The archive of a project without files has no project/ entries.
+	return m, os.MkdirAll(filepath.Join(dir, projectDir), 0755)
+}
+
+func writeFile(path string, r io.Reader, modTime time.Time) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
+
+// importFile is a file of the staged archive to write into the project.
+type importFile struct {
+	src, rel string
+	session  bool // Whether its projectHash must be rewritten
+}
+
+type importPlan struct {
+	files     []importFile
+	sessions  []string
+	logs      []gemini.LogEntry
+	conflicts []Conflict
+}
+
+// planImport lists the files of the staged archive to import into projectID
+// and what conflicts with Gemini storage.
+func planImport(staging, rootDir, projectID string) (importPlan, error) {
+	var plan importPlan
+	existing, err := sessionProjects(rootDir)
+	if err != nil {
+		return plan, err
+	}
+	dst := filepath.Join(rootDir, projectID)
+
+	// Sessions, which may span several files, conflict wherever they exist.
+	paths, err := gemini.ListSessionFiles(staging, projectDir)
+	if err != nil {
+		return plan, err
+	}
+	var ids []string
+	files := make(map[string][]string)
+	isSession := make(map[string]bool)
+	for _, p := range paths {
+		meta, err := gemini.ReadSessionMeta(p)
+		if err != nil {
+			continue // Imported verbatim like other files
+		}
+		if _, ok := files[meta.ID]; !ok {
+			ids = append(ids, meta.ID)
+		}
+		files[meta.ID] = append(files[meta.ID], p)
+		isSession[p] = true
+	}
+	for _, id := range ids {
+		if other, ok := existing[id]; ok {
+			plan.conflicts = append(plan.conflicts, Conflict{SessionID: id, ProjectID: other})
+			continue
+		}
+		// A session is imported with all its files or not at all.
+		conflict := false
+		for _, p := range files[id] {
+			rel := filepath.Join(gemini.SessionDir, filepath.Base(p))
+			if _, err := os.Stat(filepath.Join(dst, rel)); err == nil {
+				plan.conflicts = append(plan.conflicts, Conflict{ProjectID: projectID, File: rel})
+				conflict = true
+			}
+		}
+		if conflict {
+			continue
+		}
+		plan.sessions = append(plan.sessions, id)
+		for _, p := range files[id] {
+			rel := filepath.Join(gemini.SessionDir, filepath.Base(p))
+			plan.files = append(plan.files, importFile{src: p, rel: rel, session: true})
+		}
+	}
+
+	// The log entries of the imported sessions are merged into logs.json.
+	logs, err := gemini.ReadLogs(staging, projectDir)
+	if err != nil {
+		return plan, err
+	}
+	for _, e := range logs {
+		if slices.Contains(plan.sessions, e.SessionID) {
+			plan.logs = append(plan.logs, e)
+		}
+	}
+
+	// Other files, e.g. checkpoints, conflict if the project has them.
+	src := filepath.Join(staging, projectDir)
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isSession[p] {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || rel == gemini.LogsFile {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(dst, rel)); err == nil {
+			plan.conflicts = append(plan.conflicts, Conflict{ProjectID: projectID, File: rel})
+			return nil
+		}
+		plan.files = append(plan.files, importFile{src: p, rel: rel})
+		return nil
+	})
+	return plan, err
+}
+
+// apply writes the planned files into the project as a journaled operation.
+// created reports whether the project directory is new and must be removed
+// again if the import fails.
+func apply(plan importPlan, rootDir, projectID string, created bool) error {
+	dst := filepath.Join(rootDir, projectID)
+	j, err := journal.Begin(rootDir, "import project "+projectID)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		err = journal.Abort(j, err)
+		if created {
+			_ = os.RemoveAll(dst)
+		}
+		return err
+	}
+
+	for _, f := range plan.files {
+		data, err := os.ReadFile(f.src)
+		if err != nil {
+			return fail(err)
+		}
+		if f.session {
+			if data, err = gemini.SetProjectHash(data, projectID); err != nil {
+				return fail(fmt.Errorf("rewriting %s: %w", f.rel, err))
+			}
+		}
+		target := filepath.Join(dst, f.rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fail(err)
+		}
+		if err := j.WriteFile(target, data, 0644); err != nil {
+			return fail(err)
+		}
+		if info, err := os.Stat(f.src); err == nil {
+			_ = os.Chtimes(target, info.ModTime(), info.ModTime())
+		}
+	}
+	if err := gemini.AppendLogs(j, rootDir, projectID, plan.logs); err != nil {
+		return fail(err)
+	}
+	return j.Commit()
+}
+
+// sessionProjects returns the project of every session in Gemini storage.
+func sessionProjects(rootDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(rootDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	projects := make(map[string]string)
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		paths, err := gemini.ListSessionFiles(rootDir, e.Name())
+		if err != nil {
+			continue
+		}
+		for _, p := range paths {
+			if meta, err := gemini.ReadSessionMeta(p); err == nil {
+				projects[meta.ID] = e.Name()
+			}
+		}
+	}
+	return projects, nil
+}
@@ -0,0 +1,260 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+)
+
+// writeTestFile writes a file, creating its directory.
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// files returns the contents of the files below dir by relative path.
+func files(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	out := make(map[string]string)
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			data, _ := os.ReadFile(path)
+			out[rel] = string(data)
+		}
+		return nil
+	})
+	return out
+}
+
+// setup creates a project with two sessions, logs and a checkpoint, and
+// returns the storage root and the project ID.
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	id := gemini.HashPath("/project")
+	chats := filepath.Join(root, id, gemini.SessionDir)
+	writeTestFile(t, filepath.Join(chats, "session-2025-01-01T10-00-s1.json"), `{"sessionId":"s1","projectHash":"`+id+`","messages":[]}`)
+	writeTestFile(t, filepath.Join(chats, "session-2025-01-02T10-00-s2.json"), `{"sessionId":"s2","projectHash":"`+id+`","messages":[]}`)
+	writeTestFile(t, gemini.LogsPath(root, id),
+		`[{"sessionId":"s1","messageId":0,"type":"user","message":"a","timestamp":"2025-01-01T10:00:00Z"},`+
+			`{"sessionId":"s2","messageId":0,"type":"user","message":"b","timestamp":"2025-01-02T10:00:00Z"}]`)
+	writeTestFile(t, gemini.CheckpointPath(root, id, "cp"), `[]`)
+	return root, id
+}
+
+// export returns the archive of a project.
+func export(t *testing.T, root, id string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	m := Manifest{ProjectPath: "/project", Project: cache.Meta{Alias: "api"}}
+	if _, err := Export(&buf, root, id, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// entry is a tar entry of a handmade archive.
+type entry struct {
+	name string
+	kind byte
+	data string
+	link string
+}
+
+// archiveOf returns a tar.gz archive holding the given entries.
+func archiveOf(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.kind, Linkname: e.link, Mode: 0644, Size: int64(len(e.data)), ModTime: time.Now()}
+		if e.kind != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			tw.Write([]byte(e.data))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestFileName(t *testing.T) {
+	id := gemini.HashPath("/home/u/api")
+	now := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/u/api", "api-" + gemini.ShortID(id) + "-20250304.tar.gz"},
+		{"", gemini.ShortID(id) + "-20250304.tar.gz"},
+		{"/", gemini.ShortID(id) + "-20250304.tar.gz"},
+	}
+	for _, tt := range tests {
+		if got := FileName(id, tt.path, now); got != tt.want {
+			t.Errorf("FileName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	src, id := setup(t)
+	data := export(t, src, id)
+
+	root := t.TempDir()
+	res, err := Import(bytes.NewReader(data), root, ImportOptions{Path: "/moved"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newID := gemini.HashPath("/moved")
+	m := res.Manifest
+	if m.ProjectID != id || m.ProjectPath != "/project" || m.Sessions != 2 || m.Files != 4 || m.Project.Alias != "api" {
+		t.Errorf("manifest = %+v", m)
+	}
+	if res.ProjectID != newID || res.Exists || res.Files != 3 || !reflect.DeepEqual(res.Sessions, []string{"s1", "s2"}) {
+		t.Errorf("Import = %+v", res)
+	}
+
+	got := files(t, filepath.Join(root, newID))
+	want := files(t, filepath.Join(src, id))
+	if len(got) != len(want) {
+		t.Fatalf("imported %v, want the files of %v", got, want)
+	}
+	for rel := range want {
+		if _, ok := got[rel]; !ok {
+			t.Errorf("%s not imported", rel)
+		}
+	}
+	for _, p := range []string{"session-2025-01-01T10-00-s1.json", "session-2025-01-02T10-00-s2.json"} {
+		if s := got[filepath.Join(gemini.SessionDir, p)]; !strings.Contains(s, newID) || strings.Contains(s, id) {
+			t.Errorf("projectHash of %s not rewritten: %s", p, s)
+		}
+	}
+	if logs, _ := gemini.ReadLogs(root, newID); len(logs) != 2 {
+		t.Errorf("imported logs = %+v", logs)
+	}
+
+	// Without a path, the project is imported into its original directory.
+	res, err = Import(bytes.NewReader(data), t.TempDir(), ImportOptions{})
+	if err != nil || res.ProjectID != id || res.Path != "/project" {
+		t.Errorf("Import into the original directory = %+v, %v", res, err)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	src, id := setup(t)
+	data := export(t, src, id)
+
+	// The target project has s1 and the checkpoint already; s2 is in another
+	// project.
+	root := t.TempDir()
+	dst := gemini.HashPath("/target")
+	writeTestFile(t, filepath.Join(root, dst, gemini.SessionDir, "session-2025-01-01T10-00-s1.json"), `{"sessionId":"s1","messages":[]}`)
+	writeTestFile(t, gemini.CheckpointPath(root, dst, "cp"), `[]`)
+	writeTestFile(t, filepath.Join(root, "other", gemini.SessionDir, "session-x.json"), `{"sessionId":"s2","messages":[]}`)
+	before := files(t, root)
+
+	_, err := Import(bytes.NewReader(data), root, ImportOptions{Path: "/target"})
+	var conflicts *ConflictError
+	if !errors.As(err, &conflicts) || len(conflicts.Conflicts) != 3 {
+		t.Fatalf("Import = %v, want 3 conflicts", err)
+	}
+	want := []Conflict{
+		{SessionID: "s1", ProjectID: dst},
+		{SessionID: "s2", ProjectID: "other"},
+		{ProjectID: dst, File: "checkpoint-cp.json"},
+	}
+	if !reflect.DeepEqual(conflicts.Conflicts, want) {
+		t.Errorf("conflicts = %+v, want %+v", conflicts.Conflicts, want)
+	}
+
+	res, err := Import(bytes.NewReader(data), root, ImportOptions{Path: "/target", SkipConflicts: true, DryRun: true})
+	if err != nil || res.Files != 0 || len(res.Skipped) != 3 || !res.Exists {
+		t.Errorf("dry run = %+v, %v", res, err)
+	}
+	if got := files(t, root); !reflect.DeepEqual(got, before) {
+		t.Errorf("failed import or dry run changed storage to %v", got)
+	}
+}
+
+func TestImportSkipConflicts(t *testing.T) {
+	src, id := setup(t)
+	data := export(t, src, id)
+
+	root := t.TempDir()
+	dst := gemini.HashPath("/target")
+	writeTestFile(t, filepath.Join(root, dst, gemini.SessionDir, "session-2025-01-01T10-00-s1.json"), `{"sessionId":"s1","messages":[]}`)
+
+	res, err := Import(bytes.NewReader(data), root, ImportOptions{Path: "/target", SkipConflicts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Sessions, []string{"s2"}) || len(res.Skipped) != 1 || res.Files != 2 {
+		t.Errorf("Import = %+v", res)
+	}
+	logs, _ := gemini.ReadLogs(root, dst)
+	if len(logs) != 1 || logs[0].SessionID != "s2" {
+		t.Errorf("logs = %+v, want the entries of s2 only", logs)
+	}
+}
+
+func TestImportRejectsUnsafeEntries(t *testing.T) {
+	manifest := entry{name: manifestFile, kind: tar.TypeReg, data: `{"version":1,"projectId":"x","projectPath":"/p"}`}
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"parent directory", []entry{manifest, {name: "project/../../escaped", kind: tar.TypeReg, data: "x"}}},
+		{"parent directory first", []entry{manifest, {name: "../escaped", kind: tar.TypeReg, data: "x"}}},
+		{"absolute path", []entry{manifest, {name: "/tmp/escaped", kind: tar.TypeReg, data: "x"}}},
+		{"outside project", []entry{manifest, {name: "escaped", kind: tar.TypeReg, data: "x"}}},
+		{"symlink", []entry{manifest, {name: "project/link", kind: tar.TypeSymlink, link: "/etc"}}},
+		{"hard link", []entry{manifest, {name: "project/link", kind: tar.TypeLink, link: "manifest.json"}}},
+		{"duplicate file", []entry{manifest, {name: "project/a", kind: tar.TypeReg, data: "x"}, {name: "project/a", kind: tar.TypeReg, data: "y"}}},
+		{"missing manifest", []entry{{name: "project/a", kind: tar.TypeReg, data: "x"}}},
+		{"newer version", []entry{{name: manifestFile, kind: tar.TypeReg, data: `{"version":99}`}}},
+		{"corrupt manifest", []entry{{name: manifestFile, kind: tar.TypeReg, data: `{`}}},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		if _, err := Import(bytes.NewReader(archiveOf(t, tt.entries...)), root, ImportOptions{}); err == nil {
+			t.Errorf("%s: Import succeeded", tt.name)
+		}
+		if got := files(t, root); len(got) != 0 {
+			t.Errorf("%s: wrote %v", tt.name, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(os.TempDir(), "escaped")); err == nil {
+		t.Errorf("entry written outside the staging directory")
+	}
+
+	if _, err := Import(strings.NewReader("not gzip"), t.TempDir(), ImportOptions{}); err == nil {
+		t.Errorf("Import of a file that is not gzip succeeded")
+	}
+	// Directory entries are ignored.
+	data := archiveOf(t, manifest, entry{name: "project/", kind: tar.TypeDir})
+	if res, err := Import(bytes.NewReader(data), t.TempDir(), ImportOptions{}); err != nil || res.Files != 0 {
+		t.Errorf("Import of an empty project = %+v, %v", res, err)
+	}
+}
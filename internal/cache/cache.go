@@ -4,8 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+	"geminictl/internal/variant"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
 )
 
 // CalculateProjectID returns the SHA-256 hash of the absolute path.
@@ -13,92 +19,566 @@ func CalculateProjectID(path string) (string, error) {
 	return gemini.HashProjectID(path)
 }
 
-// Cache stores the mapping of project IDs to directory paths.
+// Status represents the resolution state of a project.
+type Status int
+
+const (
+	StatusScanning Status = iota
+	StatusValid
+	StatusUnlocated
+	StatusOrphaned
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusScanning:
+		return "Scanning"
+	case StatusValid:
+		return "Valid"
+	case StatusUnlocated:
+		return "Unlocated"
+	case StatusOrphaned:
+		return "Orphaned"
+	}
+	return "Unknown"
+}
+
+// Version is the version of the cache file format written by Save. Version 1
+// was a flat JSON object mapping project IDs to paths; it is migrated when
+// loaded.
+const Version = 2
+
+// maxPreviousPaths caps the number of former locations kept per project.
+const maxPreviousPaths = 10
+
+// Meta is what the user recorded about a project or session.
+type Meta struct {
+	Alias  string   `json:"alias,omitempty"`  // Short name
+	Name   string   `json:"name,omitempty"`   // Title of a session, replacing the one derived from its first prompt
+	Tags   []string `json:"tags,omitempty"`   // Normalised, see ParseTags
+	Note   string   `json:"note,omitempty"`   // Markdown
+	Pinned bool     `json:"pinned,omitempty"` // Listed before other projects
+}
+
+// IsZero reports whether nothing was recorded.
+func (m Meta) IsZero() bool {
+	return m.Alias == "" && m.Name == "" && len(m.Tags) == 0 && m.Note == "" && !m.Pinned
+}
+
+// HasTag reports whether the tag is among the tags.
+func (m Meta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags splits a list of tags separated by commas or whitespace and
+// normalises them: lower case, without a leading '#', sorted and without
+// duplicates.
+func ParseTags(s ...string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, arg := range s {
+		for _, t := range strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			t = strings.ToLower(strings.TrimLeft(t, "#"))
+			if t != "" && !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Record is what the cache knows about a project.
+type Record struct {
+	Path          string           `json:"path"`                    // Directory, "" if it could not be located
+	Source        string           `json:"source,omitempty"`        // How the path was found, e.g. "walk" or "zsh"
+	FirstSeen     time.Time        `json:"firstSeen"`               // When the project entered the cache
+	LastSeen      time.Time        `json:"lastSeen"`                // When the path was last set, zero if it never was
+	PreviousPaths []string         `json:"previousPaths,omitempty"` // Former locations, most recent first
+	Matched       *variant.Variant `json:"matched,omitempty"`       // Variant of Path the hash matched, if not Path itself
+	Meta
+}
+
+// file is the on-disk form of the cache.
+type file struct {
+	Version  int                `json:"version"`
+	Projects map[string]*Record `json:"projects"`
+	Sessions map[string]*Meta   `json:"sessions,omitempty"` // By session ID
+}
+
+// Cache stores what is known about projects, by project ID. Changes are kept
+// in memory until Save, which merges them into the file under an exclusive
+// lock, so that concurrent geminictl instances do not undo each other's
+// changes.
 type Cache struct {
-	Data       map[string]string `json:"data"`
+	Records    map[string]*Record
+	Sessions   map[string]*Meta
 	configPath string
+
+	// dirty and dirtySessions hold the IDs of the records and sessions
+	// changed since the last Load or Save; cleared is set by Clear.
+	dirty         map[string]bool
+	dirtySessions map[string]bool
+	cleared       bool
+
+	// Mounts are the mounts VerifyAndSet tries variants of a path under.
+	Mounts []variant.Mount
 }
 
 // NewCache creates a new Cache instance. If baseDir is provided, it uses it as the root
-// and looks for cache.json directly in that directory.
+// and looks for cache.json in its 'geminictl' subdirectory.
 func NewCache(baseDir string) (*Cache, error) {
-	var configPath string
-	if baseDir != "" {
-		configPath = filepath.Join(baseDir, "geminictl", "cache.json")
-	} else {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		configPath = filepath.Join(home, ".config", "geminictl", "cache.json")
+	dir, err := Dir(baseDir)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Cache{
-		Data:       make(map[string]string),
-		configPath: configPath,
+		Records:       make(map[string]*Record),
+		Sessions:      make(map[string]*Meta),
+		configPath:    filepath.Join(dir, "cache.json"),
+		dirty:         make(map[string]bool),
+		dirtySessions: make(map[string]bool),
 	}, nil
 }
 
-// Load reads the cache from the config file.
+// Dir returns the directory holding geminictl's own state (cache, trash, ...).
+// If baseDir is provided, it is the 'geminictl' subdirectory of that path.
+func Dir(baseDir string) (string, error) {
+	if baseDir != "" {
+		return filepath.Join(baseDir, "geminictl"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "geminictl"), nil
+}
+
+// Load reads the cache from the config file. Files in an older format are
+// migrated and rewritten; the original is kept next to it with the suffix
+// .v<version>.
 func (c *Cache) Load() error {
+	f, migrated, err := c.read()
+	if err != nil {
+		return err
+	}
+	c.Records, c.Sessions = f.Projects, f.Sessions
+	c.dirty = make(map[string]bool)
+	c.dirtySessions = make(map[string]bool)
+	c.cleared = false
+	if migrated != 0 {
+		// Mark every record dirty so that Save writes them all.
+		for id := range c.Records {
+			c.dirty[id] = true
+		}
+		if data, err := os.ReadFile(c.configPath); err == nil {
+			_ = os.WriteFile(fmt.Sprintf("%s.v%d", c.configPath, migrated), data, 0644)
+		}
+		if err := c.Save(); err != nil {
+			return fmt.Errorf("migrating %s: %w", c.configPath, err)
+		}
+	}
+	return nil
+}
+
+// read parses the config file. It returns the version migrated from, or 0 if
+// the file is current or missing.
+func (c *Cache) read() (file, int, error) {
+	f := file{
+		Version:  Version,
+		Projects: make(map[string]*Record),
+		Sessions: make(map[string]*Meta),
+	}
 	data, err := os.ReadFile(c.configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			c.Data = make(map[string]string)
-			return nil
+			return f, 0, nil
 		}
-		return err
+		return file{}, 0, err
+	}
+
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return file{}, 0, fmt.Errorf("%s: %w", c.configPath, err)
+	}
+	if probe.Version == nil {
+		records, err := migrateV1(data)
+		if err != nil {
+			return file{}, 0, fmt.Errorf("%s: %w", c.configPath, err)
+		}
+		f.Projects = records
+		return f, 1, nil
+	}
+	if *probe.Version > Version {
+		return file{}, 0, fmt.Errorf("%s: cache version %d is newer than supported version %d, upgrade geminictl", c.configPath, *probe.Version, Version)
+	}
+
+	var onDisk file
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return file{}, 0, fmt.Errorf("%s: %w", c.configPath, err)
 	}
+	for id, r := range onDisk.Projects {
+		if r != nil {
+			f.Projects[id] = r
+		}
+	}
+	for id, m := range onDisk.Sessions {
+		if m != nil && !m.IsZero() {
+			f.Sessions[id] = m
+		}
+	}
+	return f, 0, nil
+}
 
-	return json.Unmarshal(data, &c.Data)
+// migrateV1 converts the flat map of project IDs to paths of version 1 into
+// records.
+func migrateV1(data []byte) (map[string]*Record, error) {
+	var paths map[string]string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	records := make(map[string]*Record, len(paths))
+	for id, path := range paths {
+		records[id] = &Record{Path: path, FirstSeen: now, LastSeen: now}
+	}
+	return records, nil
 }
 
-// Save writes the cache to the config file.
+// Save merges the changes made since the last Load or Save into the config
+// file. The file is locked while it is read and rewritten, and written
+// atomically, so that neither a crash nor another geminictl instance saving
+// at the same time corrupts it or loses changes. Records changed by other
+// instances in the meantime are picked up.
 func (c *Cache) Save() error {
 	dir := filepath.Dir(c.configPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c.Data, "", "  ")
+	unlock, err := lockFile(c.configPath + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking cache: %w", err)
+	}
+	defer unlock()
+
+	onDisk, _, err := c.read()
+	if err != nil {
+		return err
+	}
+	if c.cleared {
+		onDisk.Projects = make(map[string]*Record)
+		onDisk.Sessions = make(map[string]*Meta)
+	}
+	for id := range c.dirty {
+		if r, ok := c.Records[id]; ok {
+			onDisk.Projects[id] = r
+		} else {
+			delete(onDisk.Projects, id)
+		}
+	}
+	for id := range c.dirtySessions {
+		if m, ok := c.Sessions[id]; ok {
+			onDisk.Sessions[id] = m
+		} else {
+			delete(onDisk.Sessions, id)
+		}
+	}
+
+	data, err := json.MarshalIndent(onDisk, "", "  ")
 	if err != nil {
 		return err
 	}
+	if err := journal.WriteFileAtomic(c.configPath, data, 0644); err != nil {
+		return err
+	}
 
-	return os.WriteFile(c.configPath, data, 0644)
+	c.Records, c.Sessions = onDisk.Projects, onDisk.Sessions
+	c.dirty = make(map[string]bool)
+	c.dirtySessions = make(map[string]bool)
+	c.cleared = false
+	return nil
 }
 
 // Get returns the path for a given ID.
 func (c *Cache) Get(id string) (string, bool) {
-	path, ok := c.Data[id]
-	return path, ok
+	r, ok := c.Records[id]
+	if !ok {
+		return "", false
+	}
+	return r.Path, true
+}
+
+// Record returns a copy of the record of a project.
+func (c *Cache) Record(id string) (Record, bool) {
+	r, ok := c.Records[id]
+	if !ok {
+		return Record{}, false
+	}
+	return *r, true
+}
+
+// IDs returns the IDs of the projects in the cache.
+func (c *Cache) IDs() []string {
+	ids := make([]string, 0, len(c.Records))
+	for id := range c.Records {
+		ids = append(ids, id)
+	}
+	return ids
 }
 
-// Set adds or updates a project in the cache.
+// Paths returns the paths of the located projects in the cache.
+func (c *Cache) Paths() []string {
+	var paths []string
+	for _, r := range c.Records {
+		if r.Path != "" {
+			paths = append(paths, r.Path)
+		}
+	}
+	return paths
+}
+
+// update returns the record of a project for modification, creating it if
+// needed.
+func (c *Cache) update(id string) *Record {
+	r, ok := c.Records[id]
+	if !ok {
+		r = &Record{FirstSeen: time.Now()}
+		c.Records[id] = r
+	}
+	c.dirty[id] = true
+	return r
+}
+
+// Set adds or updates the path of a project in the cache. A different former
+// path is remembered, and how the path was found and matched is reset.
 func (c *Cache) Set(id, path string) {
-	c.Data[id] = path
+	r := c.update(id)
+	if r.Path != path {
+		r.PreviousPaths = pushPath(r.PreviousPaths, r.Path, path)
+		r.Path = path
+		r.Source = ""
+		r.Matched = nil
+	}
+	r.LastSeen = time.Now()
+}
+
+// pushPath prepends old to paths, dropping current and earlier occurrences
+// of old.
+func pushPath(paths []string, old, current string) []string {
+	out := make([]string, 0, len(paths)+1)
+	if old != "" {
+		out = append(out, old)
+	}
+	for _, p := range paths {
+		if p != old && p != current && len(out) < maxPreviousPaths {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// SetSource records how the path of a project was found.
+func (c *Cache) SetSource(id, source string) {
+	if _, ok := c.Records[id]; ok {
+		c.update(id).Source = source
+	}
 }
 
-// VerifyAndSet checks if the path's hash matches the projectID and updates the cache.
+// SetMatch records the variant of a project's path that hashes to its ID.
+// Exact matches need no record.
+func (c *Cache) SetMatch(id string, a variant.Variant) {
+	if _, ok := c.Records[id]; !ok {
+		return
+	}
+	r := c.update(id)
+	if a.Kind == variant.KindExact {
+		r.Matched = nil
+	} else {
+		r.Matched = &a
+	}
+}
+
+// Match returns the variant of a project's path that hashes to its ID, if it
+// is not the path itself.
+func (c *Cache) Match(id string) (variant.Variant, bool) {
+	r, ok := c.Records[id]
+	if !ok || r.Matched == nil {
+		return variant.Variant{}, false
+	}
+	return *r.Matched, true
+}
+
+// VerifyAndSet checks if the hash of the path, or of one of its variants
+// (see variant.Of), matches the projectID and updates the cache,
+// recording which variant matched.
 func (c *Cache) VerifyAndSet(projectID, path string) error {
-	hash, err := CalculateProjectID(path)
+	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
-	if hash != projectID {
+	a, ok := variant.Match(projectID, abs, c.Mounts)
+	if !ok {
+		hash, _ := CalculateProjectID(abs)
 		return fmt.Errorf("path hash mismatch: expected %s, got %s", projectID, hash)
 	}
-	c.Set(projectID, path)
+	c.Set(projectID, abs)
+	c.SetMatch(projectID, a)
 	return c.Save()
 }
 
+// Move transfers the record of a project whose directory moved to its new
+// ID, keeping what the user recorded about it.
+func (c *Cache) Move(oldID, newID, newPath string) {
+	r, ok := c.Records[oldID]
+	if !ok {
+		c.Set(newID, newPath)
+		return
+	}
+	c.Forget(oldID)
+	c.Records[newID] = r
+	c.Set(newID, newPath)
+}
+
+// ProjectMeta returns what the user recorded about a project.
+func (c *Cache) ProjectMeta(id string) Meta {
+	if r, ok := c.Records[id]; ok {
+		return r.Meta
+	}
+	return Meta{}
+}
+
+// SetProjectMeta replaces what the user recorded about a project. Aliases
+// identify projects and must be unique.
+func (c *Cache) SetProjectMeta(id string, m Meta) error {
+	if m.Alias != "" {
+		if other, ok := c.ProjectByAlias(m.Alias); ok && other != id {
+			return fmt.Errorf("alias %q is already used by project [%s]", m.Alias, gemini.ShortID(other))
+		}
+	}
+	if _, ok := c.Records[id]; !ok && m.IsZero() {
+		return nil
+	}
+	c.update(id).Meta = m
+	return nil
+}
+
+// ProjectByAlias returns the ID of the project with the given alias.
+func (c *Cache) ProjectByAlias(name string) (string, bool) {
+	for id, r := range c.Records {
+		if r.Alias != "" && r.Alias == name {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// SessionMeta returns what the user recorded about a session.
+func (c *Cache) SessionMeta(id string) Meta {
+	if m, ok := c.Sessions[id]; ok {
+		return *m
+	}
+	return Meta{}
+}
+
+// SessionsMeta returns what the user recorded about the given sessions, by
+// session ID. Sessions nothing was recorded about are left out.
+func (c *Cache) SessionsMeta(ids ...string) map[string]Meta {
+	metas := make(map[string]Meta)
+	for _, id := range ids {
+		if m, ok := c.Sessions[id]; ok {
+			metas[id] = *m
+		}
+	}
+	return metas
+}
+
+// SessionTitle returns the name the user gave a session, or else the title
+// derived from its first prompt.
+func (c *Cache) SessionTitle(id, derived string) string {
+	if m, ok := c.Sessions[id]; ok && m.Name != "" {
+		return m.Name
+	}
+	return derived
+}
+
+// SetSessionMeta replaces what the user recorded about a session.
+func (c *Cache) SetSessionMeta(id string, m Meta) {
+	if m.IsZero() {
+		c.ForgetSession(id)
+		return
+	}
+	c.Sessions[id] = &m
+	c.dirtySessions[id] = true
+}
+
+// ForgetSession removes what the user recorded about a session without
+// saving the cache.
+func (c *Cache) ForgetSession(id string) {
+	if _, ok := c.Sessions[id]; ok {
+		delete(c.Sessions, id)
+		c.dirtySessions[id] = true
+	}
+}
+
+// Tags returns the tags of all projects and sessions, sorted.
+func (c *Cache) Tags() []string {
+	var all []string
+	for _, r := range c.Records {
+		all = append(all, r.Tags...)
+	}
+	for _, m := range c.Sessions {
+		all = append(all, m.Tags...)
+	}
+	return ParseTags(all...)
+}
+
+// Forget removes a project from the cache without saving it.
+func (c *Cache) Forget(id string) {
+	delete(c.Records, id)
+	c.dirty[id] = true
+}
+
 // Delete removes a project from the cache.
 func (c *Cache) Delete(id string) error {
-	delete(c.Data, id)
+	c.Forget(id)
 	return c.Save()
 }
 
+// Resolve returns the display path and resolution status of a project.
+// Projects missing from the cache, or only holding what the user recorded
+// about them, are reported as scanning and displayed by their hash, as are
+// projects whose location could not be determined.
+func (c *Cache) Resolve(id string) (string, Status) {
+	r, inCache := c.Records[id]
+	if !inCache || (r.Path == "" && r.LastSeen.IsZero()) {
+		return id, StatusScanning
+	}
+	path := r.Path
+	if path == "" {
+		return id, StatusUnlocated
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path, StatusOrphaned
+	}
+	return path, StatusValid
+}
+
 // Clear removes all projects from the cache.
 func (c *Cache) Clear() {
-	c.Data = make(map[string]string)
+	c.Records = make(map[string]*Record)
+	c.Sessions = make(map[string]*Meta)
+	c.dirty = make(map[string]bool)
+	c.dirtySessions = make(map[string]bool)
+	c.cleared = true
 }
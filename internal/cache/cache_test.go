@@ -0,0 +1,303 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// load returns the cache stored in baseDir.
+func load(t *testing.T, baseDir string) *Cache {
+	t.Helper()
+	c, err := NewCache(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSaveMerges(t *testing.T) {
+	base := t.TempDir()
+	seed := load(t, base)
+	seed.Set("kept", "/kept")
+	seed.Set("forgotten", "/forgotten")
+	seed.SetSessionMeta("s1", Meta{Name: "first"})
+	if err := seed.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two instances load the same file and change different records.
+	a, b := load(t, base), load(t, base)
+	a.Set("a", "/a")
+	a.Forget("forgotten")
+	b.Set("b", "/b")
+	b.SetSessionMeta("s2", Meta{Tags: []string{"x"}})
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]*Cache{"reloaded": load(t, base), "saved last": b} {
+		for id, want := range map[string]string{"kept": "/kept", "a": "/a", "b": "/b"} {
+			if got, ok := c.Get(id); !ok || got != want {
+				t.Errorf("%s: Get(%s) = %q, %v; want %q", name, id, got, ok, want)
+			}
+		}
+		if _, ok := c.Get("forgotten"); ok {
+			t.Errorf("%s: forgotten record came back", name)
+		}
+		if c.SessionMeta("s1").Name != "first" || !c.SessionMeta("s2").HasTag("x") {
+			t.Errorf("%s: sessions = %v", name, c.Sessions)
+		}
+	}
+}
+
+func TestSaveClear(t *testing.T) {
+	base := t.TempDir()
+	c := load(t, base)
+	c.Set("old", "/old")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Clear()
+	c.Set("new", "/new")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(t, base).IDs(); !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("IDs after Clear = %v, want [new]", got)
+	}
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	base := t.TempDir()
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := NewCache(base)
+			if err == nil {
+				err = c.Load()
+			}
+			if err == nil {
+				c.Set(fmt.Sprint(i), fmt.Sprintf("/p%d", i))
+				err = c.Save()
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(load(t, base).IDs()); got != n {
+		t.Errorf("%d records after %d concurrent saves, want %d", got, n, n)
+	}
+}
+
+func TestLoadMigratesV1(t *testing.T) {
+	base := t.TempDir()
+	c, err := NewCache(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := `{"abc":"/abc","def":""}`
+	if err := os.MkdirAll(filepath.Dir(c.configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.configPath, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, ok := c.Record("abc"); !ok || r.Path != "/abc" || r.FirstSeen.IsZero() {
+		t.Errorf("Record(abc) = %+v, %v", r, ok)
+	}
+	if _, status := c.Resolve("def"); status != StatusUnlocated {
+		t.Errorf("Resolve(def) = %v, want unlocated", status)
+	}
+	if data, _ := os.ReadFile(c.configPath + ".v1"); string(data) != v1 {
+		t.Errorf("backup of the version 1 file = %q", data)
+	}
+	var probe struct{ Version int }
+	data, _ := os.ReadFile(c.configPath)
+	if err := json.Unmarshal(data, &probe); err != nil || probe.Version != Version {
+		t.Errorf("migrated file has version %d, %v", probe.Version, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, data := range []string{`{`, `{"version":99,"projects":{}}`} {
+		c, err := NewCache(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Dir(c.configPath), 0755)
+		os.WriteFile(c.configPath, []byte(data), 0644)
+		if err := c.Load(); err == nil {
+			t.Errorf("Load(%s) succeeded", data)
+		}
+	}
+}
+
+func TestSetRemembersPreviousPaths(t *testing.T) {
+	c := load(t, t.TempDir())
+	for _, p := range []string{"/a", "/b", "/a", "/c", "/c"} {
+		c.Set("id", p)
+	}
+	r, _ := c.Record("id")
+	if want := []string{"/a", "/b"}; r.Path != "/c" || !reflect.DeepEqual(r.PreviousPaths, want) {
+		t.Errorf("after moves: path %q, previous %v; want /c, %v", r.Path, r.PreviousPaths, want)
+	}
+
+	for i := 0; i < 2*maxPreviousPaths; i++ {
+		c.Set("id", fmt.Sprintf("/p%d", i))
+	}
+	if r, _ := c.Record("id"); len(r.PreviousPaths) != maxPreviousPaths {
+		t.Errorf("%d previous paths kept, want %d", len(r.PreviousPaths), maxPreviousPaths)
+	}
+}
+
+func TestMoveKeepsRecord(t *testing.T) {
+	c := load(t, t.TempDir())
+	c.Set("old", "/old")
+	c.SetSource("old", "zsh")
+	if err := c.SetProjectMeta("old", Meta{Alias: "x", Pinned: true}); err != nil {
+		t.Fatal(err)
+	}
+	c.Move("old", "new", "/new")
+
+	if _, ok := c.Record("old"); ok {
+		t.Errorf("old record kept")
+	}
+	r, ok := c.Record("new")
+	if !ok || r.Path != "/new" || r.Source != "" || r.Alias != "x" || !r.Pinned || !reflect.DeepEqual(r.PreviousPaths, []string{"/old"}) {
+		t.Errorf("Record(new) = %+v, %v", r, ok)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{"b a"}, []string{"a", "b"}},
+		{[]string{"#Work, urgent", "work\tlater"}, []string{"later", "urgent", "work"}},
+		{[]string{"##x,,#"}, []string{"x"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.in...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestProjectMeta(t *testing.T) {
+	base := t.TempDir()
+	c := load(t, base)
+	c.Set("p1", "/p1")
+	c.Set("p2", "/p2")
+	if err := c.SetProjectMeta("p1", Meta{Alias: "api", Tags: []string{"work"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetProjectMeta("p2", Meta{Alias: "api"}); err == nil {
+		t.Errorf("SetProjectMeta reused the alias of another project")
+	}
+	if err := c.SetProjectMeta("p1", Meta{Alias: "api", Note: "renamed"}); err != nil {
+		t.Errorf("SetProjectMeta with the project's own alias: %v", err)
+	}
+	// Nothing is recorded for unknown projects unless there is metadata.
+	if err := c.SetProjectMeta("unknown", Meta{}); err != nil || len(c.IDs()) != 2 {
+		t.Errorf("SetProjectMeta of an empty Meta added a record: %v", c.IDs())
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = load(t, base)
+	if id, ok := c.ProjectByAlias("api"); !ok || id != "p1" {
+		t.Errorf("ProjectByAlias(api) = %q, %v", id, ok)
+	}
+	if m := c.ProjectMeta("p1"); m.Note != "renamed" || len(m.Tags) != 0 {
+		t.Errorf("ProjectMeta(p1) = %+v", m)
+	}
+}
+
+func TestSessionMeta(t *testing.T) {
+	base := t.TempDir()
+	c := load(t, base)
+	c.SetSessionMeta("s1", Meta{Name: "named", Tags: []string{"b"}})
+	c.SetSessionMeta("s2", Meta{Tags: []string{"a"}})
+	c.SetProjectMeta("p", Meta{Tags: []string{"b", "c"}})
+	if got, want := c.Tags(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %q, want %q", got, want)
+	}
+	if got := c.SessionTitle("s1", "derived"); got != "named" {
+		t.Errorf("SessionTitle(s1) = %q", got)
+	}
+	if got := c.SessionTitle("s2", "derived"); got != "derived" {
+		t.Errorf("SessionTitle(s2) = %q", got)
+	}
+	if got := c.SessionsMeta("s1", "s3"); len(got) != 1 || got["s1"].Name != "named" {
+		t.Errorf("SessionsMeta(s1, s3) = %v", got)
+	}
+
+	// An empty Meta forgets the session.
+	c.SetSessionMeta("s2", Meta{})
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	c = load(t, base)
+	if _, ok := c.Sessions["s2"]; ok {
+		t.Errorf("session with empty metadata kept")
+	}
+	if !c.SessionMeta("s1").HasTag("b") {
+		t.Errorf("SessionMeta(s1) = %+v", c.SessionMeta("s1"))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	existing := t.TempDir()
+	c := load(t, t.TempDir())
+	c.Set("valid", existing)
+	c.Set("orphaned", filepath.Join(existing, "gone"))
+	c.Set("unlocated", "")
+	tests := []struct {
+		id     string
+		path   string
+		status Status
+	}{
+		{"valid", existing, StatusValid},
+		{"orphaned", filepath.Join(existing, "gone"), StatusOrphaned},
+		{"unlocated", "unlocated", StatusUnlocated},
+		{"unknown", "unknown", StatusScanning},
+	}
+	for _, tt := range tests {
+		if path, status := c.Resolve(tt.id); path != tt.path || status != tt.status {
+			t.Errorf("Resolve(%s) = %q, %v; want %q, %v", tt.id, path, status, tt.path, tt.status)
+		}
+	}
+	if got := Status(99).String(); got != "Unknown" {
+		t.Errorf("String of an invalid status = %q", got)
+	}
+}
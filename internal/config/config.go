@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/variant"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file in geminictl's state directory.
+const FileName = "config.yaml"
+
+// DefaultYAML is the configuration used when there is no config file. It is
+// also what 'geminictl config init' writes.
+const DefaultYAML = `# geminictl configuration
+
+resolve:
+  # Directories searched for the paths of projects, in order. Directories
+  # searched by an earlier root are skipped by later ones. maxDepth limits
+  # how deep below the root the search goes (0 for no limit).
+  roots:
+    - path: ~/Desktop
+    - path: "~"
+    - path: /opt
+    - path: /var/www
+    - path: /usr/local/src
+    - path: /srv
+    - path: /
+
+  # Places harvested for directories that are hashed before the roots are
+  # searched: the paths mentioned in the projects' sessions and logs
+  # (sessions), shell histories (bash, zsh, fish), the zoxide and autojump
+  # databases and the worktrees of known git repositories (git).
+  sources:
+    - sessions
+    - bash
+    - zsh
+    - fish
+    - zoxide
+    - autojump
+    - git
+
+  # Directories not searched, using gitignore-style patterns. Patterns
+  # without a slash match directory names anywhere, patterns with a slash
+  # match paths ('**' matches any number of directories, a leading '/' or
+  # '~' anchors the pattern) and a leading '!' re-includes a directory.
+  exclude:
+    - node_modules
+    - .git
+    - .npm
+    - .cache
+    - .gemini
+    - .vscode
+    - .idea
+    - go
+    - Library
+    - /proc
+    - /sys
+    - /dev
+
+  # Path prefixes under which the same directories are reachable, e.g. a
+  # container bind mount or a directory reached through a symlink. Projects
+  # are matched under both prefixes, for example:
+  #   mounts:
+  #     - from: /mnt/data/work
+  #       to: ~/work
+  mounts: []
+
+  # Skip directories whose name starts with a dot.
+  skipHidden: true
+
+  # Descend into directories on other filesystems than the root's.
+  crossFilesystems: true
+
+  # Give up after this long, e.g. 30s or 2m (0 for no limit).
+  budget: 0s
+
+  # Number of directories read in parallel.
+  workers: 8
+`
+
+// Root is a directory the resolver searches.
+type Root struct {
+	Path     string `yaml:"path"`
+	MaxDepth int    `yaml:"maxDepth"`
+}
+
+// Resolve configures the resolution of project paths.
+type Resolve struct {
+	Roots            []Root          `yaml:"roots"`
+	Sources          []string        `yaml:"sources"`
+	Exclude          []string        `yaml:"exclude"`
+	Mounts           []variant.Mount `yaml:"mounts"`
+	SkipHidden       bool            `yaml:"skipHidden"`
+	CrossFilesystems bool            `yaml:"crossFilesystems"`
+	Budget           time.Duration   `yaml:"budget"`
+	Workers          int             `yaml:"workers"`
+}
+
+// Sources lists the names of the available candidate sources.
+var Sources = []string{"sessions", "bash", "zsh", "fish", "zoxide", "autojump", "git"}
+
+// Config is the content of the config file.
+type Config struct {
+	Resolve Resolve `yaml:"resolve"`
+
+	// Path is the file the config was loaded from, empty for the defaults.
+	Path string `yaml:"-"`
+}
+
+// Default returns the default configuration.
+func Default() Config {
+	var c Config
+	if err := yaml.Unmarshal([]byte(DefaultYAML), &c); err != nil {
+		panic(fmt.Sprintf("invalid default config: %v", err))
+	}
+	c.expand()
+	return c
+}
+
+// FilePath returns the path of the config file in geminictl's state
+// directory (see cache.Dir).
+func FilePath(baseDir string) (string, error) {
+	dir, err := cache.Dir(baseDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the config file. Settings missing from the file keep their
+// default values; a missing file yields the defaults.
+func Load(baseDir string) (Config, error) {
+	path, err := FilePath(baseDir)
+	if err != nil {
+		return Config{}, err
+	}
+
+	c := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return Config{}, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	c.Path = path
+	c.expand()
+	return c, c.validate()
+}
+
+// expand replaces a leading ~ in root and mount paths with the home
+// directory. Exclude patterns are expanded when they are compiled.
+func (c *Config) expand() {
+	for i, r := range c.Resolve.Roots {
+		c.Resolve.Roots[i].Path = filepath.Clean(expandHome(r.Path))
+	}
+	for i, m := range c.Resolve.Mounts {
+		c.Resolve.Mounts[i].From = filepath.Clean(expandHome(m.From))
+		c.Resolve.Mounts[i].To = filepath.Clean(expandHome(m.To))
+	}
+}
+
+func (c Config) validate() error {
+	for _, r := range c.Resolve.Roots {
+		if !filepath.IsAbs(r.Path) {
+			return fmt.Errorf("%s: root %q is not an absolute path", c.Path, r.Path)
+		}
+		if r.MaxDepth < 0 {
+			return fmt.Errorf("%s: root %q has a negative maxDepth", c.Path, r.Path)
+		}
+	}
+	for _, m := range c.Resolve.Mounts {
+		if !filepath.IsAbs(m.From) || !filepath.IsAbs(m.To) {
+			return fmt.Errorf("%s: mount %q -> %q must map absolute paths", c.Path, m.From, m.To)
+		}
+	}
+	for _, s := range c.Resolve.Sources {
+		if !slices.Contains(Sources, s) {
+			return fmt.Errorf("%s: unknown source %q (expected one of %s)", c.Path, s, strings.Join(Sources, ", "))
+		}
+	}
+	for _, p := range c.Resolve.Exclude {
+		if _, err := compile(p); err != nil {
+			return fmt.Errorf("%s: %w", c.Path, err)
+		}
+	}
+	if c.Resolve.Budget < 0 || c.Resolve.Workers < 0 {
+		return fmt.Errorf("%s: budget and workers must not be negative", c.Path)
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatcher(t *testing.T) {
+	m := Resolve{
+		SkipHidden: true,
+		Exclude: []string{
+			"node_modules",
+			"build*",
+			"/proc",
+			"vendor/cache/",
+			"/srv/**/tmp",
+			"!.config",
+			"!/srv/keep/build",
+			"[", // Invalid, ignored
+		},
+	}.Matcher()
+
+	tests := []struct {
+		dir  string
+		skip bool
+	}{
+		{"/", false},
+		{"/home/u/app", false},
+		{"/home/u/app/node_modules", true},
+		{"/home/u/node_modules/pkg", false}, // Only the excluded directory itself is reported
+		{"/home/u/build-output", true},
+		{"/home/u/rebuild", false},
+		{"/proc", true},
+		{"/home/proc", false},
+		{"/a/vendor/cache", true},
+		{"/a/cache", false},
+		{"/srv/tmp", true},
+		{"/srv/a/b/tmp", true},
+		{"/var/tmp", false},
+		{"/home/u/.cache", true},
+		{"/home/u/.config", false},
+		{"/srv/keep/build", false},
+		{"/srv/other/build", true},
+		{"/home/u/app/", false},
+	}
+	for _, tt := range tests {
+		if got := m.Skip(tt.dir); got != tt.skip {
+			t.Errorf("Skip(%q) = %v, want %v", tt.dir, got, tt.skip)
+		}
+	}
+
+	if (Resolve{}).Matcher().Skip("/home/.hidden") {
+		t.Errorf("hidden directory skipped without skipHidden")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	if len(c.Resolve.Roots) == 0 || len(c.Resolve.Sources) != len(Sources) || !c.Resolve.SkipHidden {
+		t.Errorf("Default() = %+v", c.Resolve)
+	}
+	if err := c.validate(); err != nil {
+		t.Errorf("default config is invalid: %v", err)
+	}
+	for _, r := range c.Resolve.Roots {
+		if strings.HasPrefix(r.Path, "~") {
+			t.Errorf("root %q not expanded", r.Path)
+		}
+	}
+}
+
+// writeConfig writes a config file into the state directory below base.
+func writeConfig(t *testing.T, base, data string) {
+	t.Helper()
+	path, err := FilePath(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	base := t.TempDir()
+	c, err := Load(base)
+	if err != nil || c.Path != "" || len(c.Resolve.Roots) != len(Default().Resolve.Roots) {
+		t.Fatalf("Load without a file = %+v, %v; want the defaults", c, err)
+	}
+
+	home, _ := os.UserHomeDir()
+	writeConfig(t, base, `
+resolve:
+  roots:
+    - path: ~/code
+      maxDepth: 3
+  sources: [zsh]
+  mounts:
+    - from: /mnt/work/
+      to: ~/work
+  budget: 30s
+`)
+	c, err = Load(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.Resolve
+	if len(r.Roots) != 1 || r.Roots[0].Path != filepath.Join(home, "code") || r.Roots[0].MaxDepth != 3 {
+		t.Errorf("roots = %+v", r.Roots)
+	}
+	if len(r.Mounts) != 1 || r.Mounts[0].From != "/mnt/work" || r.Mounts[0].To != filepath.Join(home, "work") {
+		t.Errorf("mounts = %+v", r.Mounts)
+	}
+	if len(r.Sources) != 1 || r.Budget != 30*time.Second {
+		t.Errorf("sources %v, budget %v", r.Sources, r.Budget)
+	}
+	// Settings missing from the file keep their defaults.
+	if !r.SkipHidden || len(r.Exclude) == 0 || r.Workers != Default().Resolve.Workers {
+		t.Errorf("defaults lost: %+v", r)
+	}
+
+	writeConfig(t, base, "")
+	if _, err := Load(base); err != nil {
+		t.Errorf("Load of an empty file: %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []string{
+		"resolve: [",
+		"resolve:\n  unknown: 1",
+		"resolve:\n  roots:\n    - path: relative",
+		"resolve:\n  roots:\n    - path: /x\n      maxDepth: -1",
+		"resolve:\n  sources: [nope]",
+		"resolve:\n  exclude: ['[']",
+		"resolve:\n  mounts:\n    - from: a\n      to: /b",
+		"resolve:\n  workers: -1",
+	}
+	for _, data := range tests {
+		base := t.TempDir()
+		writeConfig(t, base, data)
+		if _, err := Load(base); err == nil {
+			t.Errorf("Load(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// pattern is a compiled exclude pattern.
+type pattern struct {
+	negate bool
+	name   string   // Set for patterns matching directory names
+	segs   []string // Set for patterns matching paths
+}
+
+// compile parses a gitignore-style pattern.
+func compile(p string) (pattern, error) {
+	var pat pattern
+	p = strings.TrimSpace(p)
+	if strings.HasPrefix(p, "!") {
+		pat.negate = true
+		p = p[1:]
+	}
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	p = expandHome(p)
+	if p == "" {
+		return pat, fmt.Errorf("empty exclude pattern")
+	}
+
+	if !strings.Contains(p, "/") {
+		pat.name = p
+		_, err := path.Match(p, "")
+		return pat, err
+	}
+
+	anchored := strings.HasPrefix(p, "/")
+	pat.segs = strings.Split(strings.Trim(p, "/"), "/")
+	if !anchored && pat.segs[0] != "**" {
+		pat.segs = append([]string{"**"}, pat.segs...)
+	}
+	for _, s := range pat.segs {
+		if _, err := path.Match(s, ""); err != nil {
+			return pat, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+	}
+	return pat, nil
+}
+
+func (p pattern) match(segs []string) bool {
+	if p.name != "" {
+		ok, _ := path.Match(p.name, segs[len(segs)-1])
+		return ok
+	}
+	return matchSegs(p.segs, segs)
+}
+
+// matchSegs matches path segments against pattern segments, where "**"
+// matches any number of segments.
+func matchSegs(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegs(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], segs[0])
+	return ok && matchSegs(pat[1:], segs[1:])
+}
+
+// Matcher decides which directories the resolver skips.
+type Matcher struct {
+	patterns   []pattern
+	skipHidden bool
+}
+
+// Matcher compiles the exclude rules. Invalid patterns are ignored; Load
+// reports them.
+func (r Resolve) Matcher() *Matcher {
+	m := &Matcher{skipHidden: r.SkipHidden}
+	for _, p := range r.Exclude {
+		if pat, err := compile(p); err == nil {
+			m.patterns = append(m.patterns, pat)
+		}
+	}
+	return m
+}
+
+// Skip reports whether the directory at the absolute path dir is excluded.
+// As with gitignore, the last matching pattern decides.
+func (m *Matcher) Skip(dir string) bool {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "/" {
+		return false
+	}
+	segs := strings.Split(strings.TrimPrefix(dir, "/"), "/")
+
+	skip := m.skipHidden && strings.HasPrefix(segs[len(segs)-1], ".")
+	for _, p := range m.patterns {
+		if p.match(segs) {
+			skip = !p.negate
+		}
+	}
+	return skip
+}
@@ -0,0 +1,258 @@
+// Package export renders sessions as transcripts that can be read or shared
+// outside of Gemini CLI.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"geminictl/internal/gemini"
+)
+
+// Formats of the transcripts, which are also their file extensions.
+const (
+	Markdown = "md"
+	HTML     = "html"
+	Text     = "txt"
+	JSONL    = "jsonl"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{Markdown, HTML, Text, JSONL}
+
+// FormatNames are the names of the formats to show to users.
+var FormatNames = map[string]string{
+	Markdown: "Markdown",
+	HTML:     "HTML",
+	Text:     "Plain text",
+	JSONL:    "JSON Lines",
+}
+
+// Options selects what transcripts include besides the messages.
+type Options struct {
+	Title      string // Heading of the transcript, the session ID if empty
+	Thoughts   bool   // Chain-of-thought of the model
+	Tokens     bool   // Models and token usage
+	Timestamps bool   // Time of the session and of each message
+}
+
+// FormatOf returns the format a file name stands for by its extension.
+func FormatOf(name string) (string, bool) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch ext {
+	case "markdown":
+		return Markdown, true
+	case "htm":
+		return HTML, true
+	case "text":
+		return Text, true
+	}
+	for _, f := range Formats {
+		if ext == f {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Write renders s as a transcript in format to w.
+func Write(w io.Writer, s gemini.Session, format string, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = "Session " + s.ID
+	}
+	switch format {
+	case Markdown:
+		return writeMarkdown(w, s, opts)
+	case HTML:
+		return writeHTML(w, s, opts)
+	case Text:
+		return writeText(w, s, opts)
+	case JSONL:
+		return writeJSONL(w, s, opts)
+	}
+	return fmt.Errorf("unknown export format %q (want one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteFile renders s as a transcript in format to the file at path,
+// creating its directory if needed.
+func WriteFile(path string, s gemini.Session, format string, opts Options) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, s, format, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// FileName returns the name of the transcript of s in format, made of the
+// start date, the short session ID and the title, e.g.
+// 2026-10-16-0900-rich0001-refactor-the-parser.md.
+func FileName(s gemini.Session, title, format string) string {
+	var parts []string
+	if start := s.Started(); !start.IsZero() {
+		parts = append(parts, start.Local().Format("2006-01-02-1504"))
+	}
+	parts = append(parts, gemini.ShortID(s.ID))
+	if slug := slugify(title, 40); slug != "" {
+		parts = append(parts, slug)
+	}
+	return strings.Join(parts, "-") + "." + format
+}
+
+// slugify lowercases s and joins its runs of letters and digits with dashes,
+// cutting it at a dash before max bytes.
+func slugify(s string, max int) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	slug := ""
+	for _, w := range words {
+		if slug != "" && len(slug)+1+len(w) > max {
+			break
+		}
+		if slug != "" {
+			slug += "-"
+		}
+		slug += w
+	}
+	if len(slug) > max {
+		return ""
+	}
+	return slug
+}
+
+// field is a line of the summary at the top of the transcripts.
+type field struct {
+	Label, Value string
+}
+
+// summary returns the fields describing s selected by opts.
+func summary(s gemini.Session, opts Options) []field {
+	meta := gemini.SummarizeSession(s)
+	fields := []field{{"Session", s.ID}}
+	if opts.Timestamps {
+		start := s.Started()
+		if !start.IsZero() {
+			fields = append(fields, field{"Started", start.Local().Format("2006-01-02 15:04:05 MST")})
+			if end := s.GetLastUpdate(); end.After(start) {
+				fields = append(fields, field{"Duration", end.Sub(start).Round(time.Second).String()})
+			}
+		}
+	}
+	fields = append(fields, field{"Messages", fmt.Sprintf("%d", len(s.Messages))})
+	if opts.Tokens {
+		if len(meta.Models) > 0 {
+			fields = append(fields, field{"Models", strings.Join(meta.Models, ", ")})
+		}
+		if meta.Tokens.Total > 0 {
+			fields = append(fields, field{"Tokens", meta.Tokens.String()})
+		}
+	}
+	return fields
+}
+
+// messageTime renders the time of msg in local time, without the date when it
+// is the day the session started. It is empty without a timestamp.
+func messageTime(msg gemini.Message, start time.Time) string {
+	ts := msg.Time()
+	if ts.IsZero() {
+		return ""
+	}
+	ts, start = ts.Local(), start.Local()
+	if ts.YearDay() == start.YearDay() && ts.Year() == start.Year() {
+		return ts.Format("15:04:05")
+	}
+	return ts.Format("2006-01-02 15:04:05")
+}
+
+// footer returns the model and the token usage of msg.
+func footer(msg gemini.Message) string {
+	var parts []string
+	if msg.Model != "" {
+		parts = append(parts, msg.Model)
+	}
+	if msg.Tokens != nil && msg.Tokens.Total > 0 {
+		parts = append(parts, msg.Tokens.String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+// toolName returns the name of a tool call as shown to users.
+func toolName(tc gemini.ToolCall) string {
+	if tc.DisplayName != "" {
+		return tc.DisplayName
+	}
+	return tc.Name
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// jsonlMessage is a line of the JSONL transcripts.
+type jsonlMessage struct {
+	SessionID string             `json:"sessionId"`
+	Index     int                `json:"index"`
+	ID        string             `json:"id,omitempty"`
+	Timestamp string             `json:"timestamp,omitempty"`
+	Type      string             `json:"type"`
+	Content   string             `json:"content"`
+	Thoughts  []gemini.Thought   `json:"thoughts,omitempty"`
+	ToolCalls []gemini.ToolCall  `json:"toolCalls,omitempty"`
+	Model     string             `json:"model,omitempty"`
+	Tokens    *gemini.TokenStats `json:"tokens,omitempty"`
+}
+
+// writeJSONL writes a JSON object per message, so that the transcripts of
+// several sessions can be concatenated.
+func writeJSONL(w io.Writer, s gemini.Session, opts Options) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for i, msg := range s.Messages {
+		line := jsonlMessage{
+			SessionID: s.ID,
+			Index:     i,
+			ID:        msg.ID,
+			Type:      msg.Type,
+			Content:   msg.Content,
+			ToolCalls: msg.ToolCalls,
+		}
+		if opts.Timestamps {
+			line.Timestamp = msg.Timestamp
+		} else {
+			line.ToolCalls = make([]gemini.ToolCall, len(msg.ToolCalls))
+			for j, tc := range msg.ToolCalls {
+				tc.Timestamp = ""
+				line.ToolCalls[j] = tc
+			}
+		}
+		if opts.Thoughts {
+			for _, t := range msg.Thoughts {
+				if !opts.Timestamps {
+					t.Timestamp = ""
+				}
+				line.Thoughts = append(line.Thoughts, t)
+			}
+		}
+		if opts.Tokens {
+			line.Model = msg.Model
+			line.Tokens = msg.Tokens
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
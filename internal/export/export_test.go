@@ -0,0 +1,183 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/gemini"
+)
+
+// session is a session with a thought, a tool call and token usage.
+var session = gemini.Session{
+	ID:          "3f2a0000-1111-2222-3333-444455556666",
+	StartTime:   "2025-01-01T10:00:00Z",
+	LastUpdated: "2025-01-01T10:05:00Z",
+	Messages: []gemini.Message{
+		{ID: "m1", Timestamp: "2025-01-01T10:00:00Z", Type: gemini.MessageUser, Content: "Fix <script>alert(1)</script> the *parser*"},
+		{
+			ID: "m2", Timestamp: "2025-01-01T10:01:00Z", Type: gemini.MessageGemini, Content: "Done.\n\n```go\nfunc f() {}\n```",
+			Thoughts:  []gemini.Thought{{Subject: "Planning", Description: "read the parser first", Timestamp: "2025-01-01T10:00:30Z"}},
+			ToolCalls: []gemini.ToolCall{{ID: "t1", Name: "read_file", DisplayName: "ReadFile", Status: "success", Timestamp: "2025-01-01T10:00:40Z"}},
+			Model:     "gemini-2.5-pro",
+			Tokens:    &gemini.TokenStats{Input: 100, Output: 20, Total: 120},
+		},
+		{Timestamp: "2025-01-02T09:00:00Z", Type: gemini.MessageError, Content: "quota\nexceeded"},
+	},
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"out.md", Markdown, true},
+		{"out.MARKDOWN", Markdown, true},
+		{"a/b.html", HTML, true},
+		{"b.htm", HTML, true},
+		{"c.txt", Text, true},
+		{"c.text", Text, true},
+		{"d.jsonl", JSONL, true},
+		{"e.json", "", false},
+		{"noext", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := FormatOf(tt.name); got != tt.want || ok != tt.ok {
+			t.Errorf("FormatOf(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"Refactor the Parser!", 40, "refactor-the-parser"},
+		{"  --  ", 40, ""},
+		{"Ünïcode wörds, 2 of them", 40, "ünïcode-wörds-2-of-them"},
+		{"one two three", 8, "one-two"},
+		{"supercalifragilistic", 8, ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.in, tt.max); got != tt.want {
+			t.Errorf("slugify(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	date := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC).Local().Format("2006-01-02-1504")
+	tests := []struct {
+		s      gemini.Session
+		title  string
+		format string
+		want   string
+	}{
+		{session, "Refactor the parser", Markdown, date + "-3f2a0000-refactor-the-parser.md"},
+		{session, "", JSONL, date + "-3f2a0000.jsonl"},
+		{gemini.Session{ID: "abcdef123456"}, "x", HTML, "abcdef12-x.html"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.s, tt.title, tt.format); got != tt.want {
+			t.Errorf("FileName(%s, %q) = %q, want %q", tt.s.ID, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	all := Options{Title: "My *session*", Thoughts: true, Tokens: true, Timestamps: true}
+	tests := []struct {
+		format  string
+		opts    Options
+		want    []string
+		notWant []string
+	}{
+		{Markdown, Options{}, []string{"# Session 3f2a0000", "## User", "the *parser*", "- ⚙ `ReadFile` (success)", "> quota\n> exceeded"},
+			[]string{"Planning", "gemini-2.5-pro", "Started", "10:01"}},
+		{Markdown, all, []string{`# My \*session\*`, "<summary>1 thought</summary>", "**Planning:** read the parser first", "*gemini-2.5-pro · ", "- **Duration:** 5m0s"}, nil},
+		{HTML, Options{}, []string{"<title>Session 3f2a0000", "<em>parser</em>", `<span class="name">ReadFile</span>`, "quota\nexceeded"},
+			[]string{"<script>", "Planning", "<footer>"}},
+		{HTML, all, []string{"<title>My *session*</title>", "<summary>1 thought</summary>", "<strong>Planning:</strong>", `<time datetime="2025-01-01T10:01:00Z">`, "<footer>gemini-2.5-pro"}, nil},
+		{Text, Options{}, []string{"Session 3f2a0000-1111-2222-3333-444455556666\n=====", "User:\n    Fix", "    [tool] ReadFile (success)", "    quota\n    exceeded"},
+			[]string{"[thought]", "gemini-2.5-pro"}},
+		{Text, all, []string{"My *session*\n============", "    [thought] Planning: read the parser first", "    [gemini-2.5-pro · "}, nil},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, session, tt.format, tt.opts); err != nil {
+			t.Fatalf("Write(%s): %v", tt.format, err)
+		}
+		out := buf.String()
+		for _, s := range tt.want {
+			if !strings.Contains(out, s) {
+				t.Errorf("Write(%s, %+v) lacks %q:\n%s", tt.format, tt.opts, s, out)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(out, s) {
+				t.Errorf("Write(%s, %+v) has %q:\n%s", tt.format, tt.opts, s, out)
+			}
+		}
+	}
+
+	if err := Write(&bytes.Buffer{}, session, "pdf", Options{}); err == nil {
+		t.Errorf("Write in an unknown format succeeded")
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	for _, opts := range []Options{{}, {Thoughts: true, Tokens: true, Timestamps: true}} {
+		var buf bytes.Buffer
+		if err := Write(&buf, session, JSONL, opts); err != nil {
+			t.Fatal(err)
+		}
+		var lines []jsonlMessage
+		sc := bufio.NewScanner(&buf)
+		for sc.Scan() {
+			var m jsonlMessage
+			if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+				t.Fatalf("line %q: %v", sc.Text(), err)
+			}
+			lines = append(lines, m)
+		}
+		if len(lines) != len(session.Messages) {
+			t.Fatalf("%d lines, want %d", len(lines), len(session.Messages))
+		}
+		m := lines[1]
+		if m.SessionID != session.ID || m.Index != 1 || m.ID != "m2" || m.Content != session.Messages[1].Content || len(m.ToolCalls) != 1 {
+			t.Errorf("line = %+v", m)
+		}
+		stamped := m.Timestamp != "" || m.ToolCalls[0].Timestamp != ""
+		if stamped != opts.Timestamps {
+			t.Errorf("with %+v: timestamps %q and %q", opts, m.Timestamp, m.ToolCalls[0].Timestamp)
+		}
+		if (len(m.Thoughts) == 1) != opts.Thoughts || (m.Tokens != nil) != opts.Tokens {
+			t.Errorf("with %+v: thoughts %+v, tokens %+v", opts, m.Thoughts, m.Tokens)
+		}
+	}
+	// Leaving out timestamps does not change the session.
+	if session.Messages[1].ToolCalls[0].Timestamp == "" {
+		t.Errorf("writeJSONL modified the session")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new", "dir", "out.md")
+	if err := WriteFile(path, session, Markdown, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "# Session") {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	if err := WriteFile(filepath.Join(t.TempDir(), "x.pdf"), session, "pdf", Options{}); err == nil {
+		t.Errorf("WriteFile in an unknown format succeeded")
+	}
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+
+	"geminictl/internal/gemini"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+)
+
+// htmlMarkdown converts the content of the messages. Raw HTML in the content
+// is left out, and code is highlighted with inline styles so that the page
+// needs no external stylesheet.
+var htmlMarkdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle("github"),
+			highlighting.WithFormatOptions(chromahtml.WithClasses(false)),
+		),
+	),
+)
+
+type htmlThought struct {
+	Subject, Description string
+}
+
+type htmlToolCall struct {
+	Name, Status, Description string
+}
+
+type htmlMessage struct {
+	Number    int
+	Type      string
+	Label     string
+	Time      string
+	Timestamp string
+	Thoughts  []htmlThought
+	Content   template.HTML
+	ToolCalls []htmlToolCall
+	Footer    string
+}
+
+type htmlPage struct {
+	Title    string
+	Summary  []field
+	Messages []htmlMessage
+}
+
+// writeHTML writes s as a single HTML page with inline styles. Thoughts are
+// collapsed in <details> elements.
+func writeHTML(w io.Writer, s gemini.Session, opts Options) error {
+	page := htmlPage{Title: opts.Title, Summary: summary(s, opts)}
+	start := s.Started()
+	for i, msg := range s.Messages {
+		m := htmlMessage{Number: i + 1, Type: msg.Type, Label: gemini.TypeLabel(msg.Type)}
+		if opts.Timestamps {
+			m.Time, m.Timestamp = messageTime(msg, start), msg.Timestamp
+		}
+		if opts.Thoughts {
+			for _, t := range msg.Thoughts {
+				m.Thoughts = append(m.Thoughts, htmlThought{t.Subject, strings.TrimSpace(t.Description)})
+			}
+		}
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			if msg.Type == gemini.MessageUser || msg.Type == gemini.MessageGemini {
+				var buf bytes.Buffer
+				if err := htmlMarkdown.Convert([]byte(content), &buf); err != nil {
+					return err
+				}
+				m.Content = template.HTML(buf.String())
+			} else {
+				m.Content = template.HTML("<p class=\"plain\">" + template.HTMLEscapeString(content) + "</p>")
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, htmlToolCall{toolName(tc), tc.Status, tc.Description})
+		}
+		if opts.Tokens {
+			m.Footer = footer(msg)
+		}
+		page.Messages = append(page.Messages, m)
+	}
+	return htmlTemplate.Execute(w, page)
+}
+
+var htmlTemplate = template.Must(template.New("session").Funcs(template.FuncMap{
+	"plural": plural,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="geminictl">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; background: #f6f8fa; color: #1f2328; font: 15px/1.55 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 860px; margin: 0 auto; padding: 32px 20px 64px; }
+h1 { font-size: 26px; margin: 0 0 12px; }
+dl.summary { display: grid; grid-template-columns: max-content 1fr; gap: 2px 16px; margin: 0 0 24px; color: #59636e; }
+dl.summary dt { font-weight: 600; }
+dl.summary dd { margin: 0; }
+section.message { background: #fff; border: 1px solid #d1d9e0; border-left: 4px solid #8c959f; border-radius: 6px; padding: 12px 18px; margin: 0 0 14px; }
+section.user { border-left-color: #1a7f37; }
+section.gemini { border-left-color: #8250df; }
+section.info { border-left-color: #1f6feb; }
+section.warning { border-left-color: #b08800; }
+section.error { border-left-color: #cf222e; }
+section.message > header { display: flex; gap: 12px; align-items: baseline; color: #59636e; font-size: 13px; }
+section.message > header .label { font-weight: 600; font-size: 14px; color: #1f2328; }
+section.message > header .number { margin-left: auto; }
+.content { overflow-wrap: anywhere; }
+.content pre { padding: 12px; border-radius: 6px; overflow-x: auto; border: 1px solid #d1d9e0; font-size: 13px; }
+.content code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 90%; }
+.content :not(pre) > code { background: #eff1f3; padding: 1px 5px; border-radius: 4px; }
+.content table { border-collapse: collapse; }
+.content th, .content td { border: 1px solid #d1d9e0; padding: 4px 10px; }
+.content blockquote { margin: 0; padding-left: 12px; border-left: 3px solid #d1d9e0; color: #59636e; }
+p.plain { white-space: pre-wrap; }
+details.thoughts { margin: 8px 0; color: #59636e; font-style: italic; }
+details.thoughts summary { cursor: pointer; }
+ul.tools { list-style: none; padding: 0; margin: 8px 0; font-size: 14px; }
+ul.tools .name { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-weight: 600; color: #bc4c00; }
+ul.tools .status { color: #59636e; }
+footer { color: #59636e; font-size: 13px; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<dl class="summary">
+{{- range .Summary}}
+<dt>{{.Label}}</dt><dd>{{.Value}}</dd>
+{{- end}}
+</dl>
+{{- range .Messages}}
+<section class="message {{.Type}}" id="message-{{.Number}}">
+<header><span class="label">{{.Label}}</span>{{if .Time}}<time datetime="{{.Timestamp}}">{{.Time}}</time>{{end}}<a class="number" href="#message-{{.Number}}">#{{.Number}}</a></header>
+{{- if .Thoughts}}
+<details class="thoughts">
+<summary>{{plural (len .Thoughts) "thought"}}</summary>
+{{- range .Thoughts}}
+<p>{{if .Subject}}<strong>{{.Subject}}:</strong> {{end}}{{.Description}}</p>
+{{- end}}
+</details>
+{{- end}}
+{{- if .Content}}
+<div class="content">
+{{.Content}}</div>
+{{- end}}
+{{- if .ToolCalls}}
+<ul class="tools">
+{{- range .ToolCalls}}
+<li>⚙ <span class="name">{{.Name}}</span>{{if .Status}} <span class="status">({{.Status}})</span>{{end}}{{if .Description}} {{.Description}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Footer}}
+<footer>{{.Footer}}</footer>
+{{- end}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"geminictl/internal/gemini"
+)
+
+// writeMarkdown writes s as a Markdown document with a section per message.
+// Thoughts are collapsed in <details> blocks, which most renderers support.
+func writeMarkdown(w io.Writer, s gemini.Session, opts Options) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", escapeMarkdownLine(opts.Title))
+	for _, f := range summary(s, opts) {
+		fmt.Fprintf(bw, "- **%s:** %s\n", f.Label, f.Value)
+	}
+
+	start := s.Started()
+	for _, msg := range s.Messages {
+		heading := "## " + gemini.TypeLabel(msg.Type)
+		if opts.Timestamps {
+			if t := messageTime(msg, start); t != "" {
+				heading += " · " + t
+			}
+		}
+		blocks := []string{"---", heading}
+
+		if opts.Thoughts && len(msg.Thoughts) > 0 {
+			blocks = append(blocks, fmt.Sprintf("<details>\n<summary>%s</summary>", plural(len(msg.Thoughts), "thought")))
+			for _, t := range msg.Thoughts {
+				text := strings.TrimSpace(t.Description)
+				if t.Subject != "" {
+					text = fmt.Sprintf("**%s:** %s", escapeMarkdownLine(t.Subject), text)
+				}
+				blocks = append(blocks, text)
+			}
+			blocks = append(blocks, "</details>")
+		}
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			if msg.Type != gemini.MessageUser && msg.Type != gemini.MessageGemini {
+				content = "> " + strings.ReplaceAll(content, "\n", "\n> ")
+			}
+			blocks = append(blocks, content)
+		}
+		if len(msg.ToolCalls) > 0 {
+			var tools []string
+			for _, tc := range msg.ToolCalls {
+				line := fmt.Sprintf("- ⚙ `%s`", toolName(tc))
+				if tc.Status != "" {
+					line += " (" + tc.Status + ")"
+				}
+				if tc.Description != "" {
+					line += " " + escapeMarkdownLine(tc.Description)
+				}
+				tools = append(tools, line)
+			}
+			blocks = append(blocks, strings.Join(tools, "\n"))
+		}
+		if opts.Tokens {
+			if f := footer(msg); f != "" {
+				blocks = append(blocks, "*"+f+"*")
+			}
+		}
+		bw.WriteString("\n" + strings.Join(blocks, "\n\n") + "\n")
+	}
+	return bw.Flush()
+}
+
+// escapeMarkdownLine puts text on a single line and escapes the characters
+// that would start inline markup in it.
+func escapeMarkdownLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `#`, `\#`,
+)
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"geminictl/internal/gemini"
+)
+
+// writeText writes s as plain text, indenting the messages under their labels.
+func writeText(w io.Writer, s gemini.Session, opts Options) error {
+	bw := bufio.NewWriter(w)
+	title := strings.Join(strings.Fields(opts.Title), " ")
+	bw.WriteString(title + "\n" + strings.Repeat("=", len([]rune(title))) + "\n\n")
+	for _, f := range summary(s, opts) {
+		bw.WriteString(f.Label + ": " + f.Value + "\n")
+	}
+
+	start := s.Started()
+	for _, msg := range s.Messages {
+		label := gemini.TypeLabel(msg.Type)
+		if opts.Timestamps {
+			if t := messageTime(msg, start); t != "" {
+				label += " (" + t + ")"
+			}
+		}
+		bw.WriteString("\n" + label + ":\n")
+
+		if opts.Thoughts {
+			for _, t := range msg.Thoughts {
+				text := strings.TrimSpace(t.Description)
+				if t.Subject != "" {
+					text = t.Subject + ": " + text
+				}
+				bw.WriteString(indent("[thought] "+text, "    "))
+			}
+		}
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			bw.WriteString(indent(content, "    "))
+		}
+		for _, tc := range msg.ToolCalls {
+			line := "[tool] " + toolName(tc)
+			if tc.Status != "" {
+				line += " (" + tc.Status + ")"
+			}
+			if tc.Description != "" {
+				line += " " + tc.Description
+			}
+			bw.WriteString(indent(line, "    "))
+		}
+		if opts.Tokens {
+			if f := footer(msg); f != "" {
+				bw.WriteString(indent("["+f+"]", "    "))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// indent prefixes the non-empty lines of s and ends it with a newline.
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			lines[i] = prefix + l
+		} else {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,355 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"geminictl/internal/journal"
+	"github.com/google/uuid"
+)
+
+// Constants for the project-level artifacts next to the chats directory.
+const (
+	LogsFile         = "logs.json"
+	CheckpointPrefix = "checkpoint-"
+	CheckpointSuffix = ".json"
+)
+
+// LogEntry is a single record of a project's append-only logs.json, which
+// holds the user prompts of all sessions of the project.
+type LogEntry struct {
+	SessionID string `json:"sessionId"`
+	MessageID int    `json:"messageId"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+
+	// raw preserves fields we don't model when entries are written back.
+	raw json.RawMessage
+}
+
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	type plain LogEntry
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	e.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (e LogEntry) MarshalJSON() ([]byte, error) {
+	if e.raw != nil {
+		return e.raw, nil
+	}
+	type plain LogEntry
+	return json.Marshal(plain(e))
+}
+
+// Part is a piece of a checkpointed message. Only text parts are modelled;
+// function calls and responses are kept as raw JSON.
+type Part struct {
+	Text             string          `json:"text,omitempty"`
+	Thought          bool            `json:"thought,omitempty"`
+	FunctionCall     json.RawMessage `json:"functionCall,omitempty"`
+	FunctionResponse json.RawMessage `json:"functionResponse,omitempty"`
+}
+
+// Content is a message of a checkpointed conversation in the Gemini API
+// format. Role is either "user" or "model".
+type Content struct {
+	Role  string `json:"role"`
+	Parts []Part `json:"parts"`
+}
+
+// Text returns the concatenated text parts of the message.
+func (c Content) Text() string {
+	var texts []string
+	for _, p := range c.Parts {
+		if p.Text != "" && !p.Thought {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Checkpoint is a conversation saved with '/chat save <name>'.
+type Checkpoint struct {
+	Name    string
+	History []Content
+
+	// Metadata extracted from the file system
+	FilePath string
+	Size     int64
+	ModTime  time.Time
+}
+
+// LogsPath returns the path of a project's logs.json.
+func LogsPath(rootDir, projectID string) string {
+	return filepath.Join(rootDir, projectID, LogsFile)
+}
+
+// ReadLogs parses the logs.json of a project. A missing file yields no entries.
+func ReadLogs(rootDir, projectID string) ([]LogEntry, error) {
+	data, err := os.ReadFile(LogsPath(rootDir, projectID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []LogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", LogsFile, err)
+	}
+	return entries, nil
+}
+
+// CheckpointPath returns the file path of a named checkpoint. Gemini CLI
+// URL-encodes checkpoint names in file names.
+func CheckpointPath(rootDir, projectID, name string) string {
+	return filepath.Join(rootDir, projectID, CheckpointPrefix+url.PathEscape(name)+CheckpointSuffix)
+}
+
+// ListCheckpoints parses all checkpoints of a project, sorted by name.
+func ListCheckpoints(rootDir, projectID string) ([]Checkpoint, error) {
+	entries, err := os.ReadDir(filepath.Join(rootDir, projectID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var checkpoints []Checkpoint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, CheckpointPrefix) || !strings.HasSuffix(name, CheckpointSuffix) {
+			continue
+		}
+		cp, err := parseCheckpointFile(filepath.Join(rootDir, projectID, name))
+		if err != nil {
+			continue
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Name < checkpoints[j].Name
+	})
+	return checkpoints, nil
+}
+
+// ReadCheckpoint parses a single named checkpoint of a project.
+func ReadCheckpoint(rootDir, projectID, name string) (Checkpoint, error) {
+	cp, err := parseCheckpointFile(CheckpointPath(rootDir, projectID, name))
+	if os.IsNotExist(err) {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q not found in project %s", name, projectID)
+	}
+	return cp, err
+}
+
+func parseCheckpointFile(path string) (Checkpoint, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	// Checkpoints are either a bare history array or, in newer Gemini CLI
+	// versions, an object wrapping the history.
+	var history []Content
+	if err := json.Unmarshal(data, &history); err != nil {
+		var wrapped struct {
+			History []Content `json:"history"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return Checkpoint{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		history = wrapped.History
+	}
+
+	base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), CheckpointPrefix), CheckpointSuffix)
+	name, err := url.PathUnescape(base)
+	if err != nil {
+		name = base
+	}
+
+	return Checkpoint{
+		Name:     name,
+		History:  history,
+		FilePath: path,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}, nil
+}
+
+// ExtractSessionLogs removes the log entries of a session from a project's
+// logs.json as part of a journaled operation and returns them.
+func ExtractSessionLogs(j *journal.Journal, rootDir, projectID, sessionID string) ([]LogEntry, error) {
+	entries, err := ReadLogs(rootDir, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept, removed []LogEntry
+	for _, e := range entries {
+		if e.SessionID == sessionID {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	if kept == nil {
+		kept = []LogEntry{}
+	}
+	return removed, writeLogs(j, LogsPath(rootDir, projectID), kept)
+}
+
+// AppendLogs adds entries to a project's logs.json as part of a journaled
+// operation, keeping the file ordered by timestamp.
+func AppendLogs(j *journal.Journal, rootDir, projectID string, added []LogEntry) error {
+	if len(added) == 0 {
+		return nil
+	}
+	entries, err := ReadLogs(rootDir, projectID)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, added...)
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Timestamp < entries[b].Timestamp
+	})
+	return writeLogs(j, LogsPath(rootDir, projectID), entries)
+}
+
+func writeLogs(j *journal.Journal, path string, entries []LogEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return j.WriteFile(path, data, 0644)
+}
+
+// CheckpointSession converts a checkpoint into a session of the given project.
+// The session gets a fresh ID; messages without text (e.g. pure tool calls)
+// are dropped and all timestamps are set to the checkpoint's save time.
+func CheckpointSession(cp Checkpoint, projectID string) Session {
+	ts := cp.ModTime.UTC().Format("2006-01-02T15:04:05.000Z")
+	s := Session{
+		ID:             uuid.NewString(),
+		ProjectHash:    projectID,
+		StartTime:      ts,
+		LastUpdated:    ts,
+		Messages:       []Message{},
+		FileLastUpdate: cp.ModTime,
+	}
+	for _, c := range cp.History {
+		text := c.Text()
+		if text == "" {
+			continue
+		}
+		msgType := "user"
+		if c.Role == "model" {
+			msgType = "gemini"
+		}
+		s.Messages = append(s.Messages, Message{
+			ID:        uuid.NewString(),
+			Timestamp: ts,
+			Type:      msgType,
+			Content:   text,
+		})
+	}
+	return s
+}
+
+// PromoteCheckpoint turns a checkpoint into a standalone session file that
+// can be resumed with 'gemini --resume'. The checkpoint itself is kept.
+func PromoteCheckpoint(rootDir, projectID, name string) (Session, error) {
+	cp, err := ReadCheckpoint(rootDir, projectID, name)
+	if err != nil {
+		return Session{}, err
+	}
+	s := CheckpointSession(cp, projectID)
+	if len(s.Messages) == 0 {
+		return Session{}, fmt.Errorf("checkpoint %q has no messages", name)
+	}
+	if err := WriteSession(rootDir, projectID, s); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+// DeleteCheckpoint removes a named checkpoint from a project.
+func DeleteCheckpoint(rootDir, projectID, name string) error {
+	path := CheckpointPath(rootDir, projectID, name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("checkpoint %q not found in project %s", name, projectID)
+	}
+
+	j, err := journal.Begin(rootDir, "delete checkpoint "+name)
+	if err != nil {
+		return err
+	}
+	if err := j.Remove(path); err != nil {
+		return journal.Abort(j, err)
+	}
+	return j.Commit()
+}
+
+// RenameCheckpoint renames a checkpoint within a project.
+func RenameCheckpoint(rootDir, projectID, oldName, newName string) error {
+	oldPath := CheckpointPath(rootDir, projectID, oldName)
+	if _, err := os.Stat(oldPath); err != nil {
+		return fmt.Errorf("checkpoint %q not found in project %s", oldName, projectID)
+	}
+
+	j, err := journal.Begin(rootDir, "rename checkpoint "+oldName)
+	if err != nil {
+		return err
+	}
+	if err := j.Move(oldPath, CheckpointPath(rootDir, projectID, newName)); err != nil {
+		return journal.Abort(j, fmt.Errorf("checkpoint %q: %w", newName, err))
+	}
+	return j.Commit()
+}
+
+// CopyCheckpoint copies a checkpoint into another project, optionally under a
+// new name. An existing checkpoint of that name in the target is not replaced.
+func CopyCheckpoint(rootDir, srcProjectID, name, dstProjectID, newName string) error {
+	if newName == "" {
+		newName = name
+	}
+	if srcProjectID == dstProjectID && newName == name {
+		return fmt.Errorf("cannot copy checkpoint %q onto itself", name)
+	}
+
+	data, err := os.ReadFile(CheckpointPath(rootDir, srcProjectID, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("checkpoint %q not found in project %s", name, srcProjectID)
+		}
+		return err
+	}
+	dst := CheckpointPath(rootDir, dstProjectID, newName)
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("checkpoint %q already exists in project %s", newName, dstProjectID)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return journal.WriteFileAtomic(dst, data, 0644)
+}
@@ -0,0 +1,226 @@
+package gemini
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"geminictl/internal/journal"
+)
+
+func TestLogEntryKeepsUnknownFields(t *testing.T) {
+	in := `{"sessionId":"s1","messageId":3,"type":"user","message":"hi","timestamp":"t","extra":{"a":[1]}}`
+	var e LogEntry
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.SessionID != "s1" || e.MessageID != 3 || e.Message != "hi" {
+		t.Errorf("parsed %+v", e)
+	}
+	out, err := json.Marshal(e)
+	if err != nil || string(out) != in {
+		t.Errorf("Marshal = %s, %v; want %s", out, err, in)
+	}
+
+	// Entries created by geminictl have no raw form.
+	out, _ = json.Marshal(LogEntry{SessionID: "s2", Type: "user"})
+	if !strings.Contains(string(out), `"sessionId":"s2"`) {
+		t.Errorf("Marshal of a new entry = %s", out)
+	}
+}
+
+func TestContentText(t *testing.T) {
+	tests := []struct {
+		parts []Part
+		want  string
+	}{
+		{nil, ""},
+		{[]Part{{Text: "a"}, {Text: "b"}}, "a\nb"},
+		{[]Part{{Text: "thinking", Thought: true}, {Text: "answer"}}, "answer"},
+		{[]Part{{FunctionCall: json.RawMessage(`{"name":"ls"}`)}}, ""},
+	}
+	for _, tt := range tests {
+		if got := (Content{Parts: tt.parts}).Text(); got != tt.want {
+			t.Errorf("Text(%+v) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestParseCheckpointFile(t *testing.T) {
+	history := `[{"role":"user","parts":[{"text":"hi"}]},{"role":"model","parts":[{"text":"hello"}]}]`
+	tests := []struct {
+		file, data string
+		name       string
+		messages   int
+		ok         bool
+	}{
+		{"checkpoint-plain.json", history, "plain", 2, true},
+		{"checkpoint-wrapped.json", `{"history":` + history + `}`, "wrapped", 2, true},
+		{"checkpoint-my%20work.json", `[]`, "my work", 0, true},
+		{"checkpoint-broken.json", `{`, "", 0, false},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		writeFile(t, path, tt.data)
+		cp, err := parseCheckpointFile(path)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseCheckpointFile(%s): %v", tt.file, err)
+			continue
+		}
+		if tt.ok && (cp.Name != tt.name || len(cp.History) != tt.messages) {
+			t.Errorf("parseCheckpointFile(%s) = %q with %d messages, want %q with %d", tt.file, cp.Name, len(cp.History), tt.name, tt.messages)
+		}
+	}
+}
+
+func TestExtractAndAppendLogs(t *testing.T) {
+	root := t.TempDir()
+	id := HashPath("/project")
+	writeFile(t, LogsPath(root, id),
+		`[{"sessionId":"a","message":"1","timestamp":"2025-01-01T10:00:00Z","x":1},`+
+			`{"sessionId":"b","message":"2","timestamp":"2025-01-02T10:00:00Z"},`+
+			`{"sessionId":"a","message":"3","timestamp":"2025-01-03T10:00:00Z"}]`)
+	messages := func() string {
+		logs, err := ReadLogs(root, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range logs {
+			out = append(out, e.Message)
+		}
+		return strings.Join(out, " ")
+	}
+
+	j, err := journal.Begin(root, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := ExtractSessionLogs(j, root, id, "a")
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("ExtractSessionLogs = %+v, %v", removed, err)
+	}
+	if none, err := ExtractSessionLogs(j, root, id, "missing"); none != nil || err != nil {
+		t.Errorf("ExtractSessionLogs of a session without entries = %+v, %v", none, err)
+	}
+	if got := messages(); got != "2" {
+		t.Errorf("after extracting: %q", got)
+	}
+	if err := AppendLogs(j, root, id, removed); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(); got != "1 2 3" {
+		t.Errorf("after appending: %q, want the entries ordered by time", got)
+	}
+	if data, _ := os.ReadFile(LogsPath(root, id)); !strings.Contains(string(data), `"x": 1`) {
+		t.Errorf("unknown field lost:\n%s", data)
+	}
+
+	if logs, err := ReadLogs(root, HashPath("/none")); logs != nil || err != nil {
+		t.Errorf("ReadLogs without logs.json = %+v, %v", logs, err)
+	}
+}
+
+// checkpointNames returns the names of the checkpoints of a project.
+func checkpointNames(t *testing.T, root, id string) []string {
+	t.Helper()
+	cps, err := ListCheckpoints(root, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, cp := range cps {
+		names = append(names, cp.Name)
+	}
+	return names
+}
+
+func TestListCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	id := HashPath("/project")
+	writeFile(t, CheckpointPath(root, id, "b"), `[]`)
+	writeFile(t, CheckpointPath(root, id, "a/b"), `[]`)
+	writeFile(t, CheckpointPath(root, id, "broken"), `{`)
+	writeFile(t, filepath.Join(root, id, "logs.json"), `[]`)
+
+	if got, want := checkpointNames(t, root, id), []string{"a/b", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListCheckpoints = %q, want %q", got, want)
+	}
+	if _, err := ReadCheckpoint(root, id, "missing"); err == nil {
+		t.Errorf("ReadCheckpoint of a missing checkpoint succeeded")
+	}
+}
+
+func TestCheckpointSession(t *testing.T) {
+	cp := Checkpoint{History: []Content{
+		{Role: "user", Parts: []Part{{Text: "hi"}}},
+		{Role: "model", Parts: []Part{{FunctionCall: json.RawMessage(`{}`)}}},
+		{Role: "model", Parts: []Part{{Text: "hello"}}},
+	}}
+	s := CheckpointSession(cp, "pid")
+	if s.ID == "" || s.ProjectHash != "pid" || len(s.Messages) != 2 {
+		t.Fatalf("CheckpointSession = %+v", s)
+	}
+	if s.Messages[0].Type != MessageUser || s.Messages[1].Type != MessageGemini || s.Messages[1].Content != "hello" {
+		t.Errorf("messages = %+v", s.Messages)
+	}
+}
+
+func TestCheckpointOperations(t *testing.T) {
+	root := t.TempDir()
+	p1, p2 := HashPath("/p1"), HashPath("/p2")
+	history := `[{"role":"user","parts":[{"text":"hi"}]}]`
+	writeFile(t, CheckpointPath(root, p1, "a"), history)
+	writeFile(t, CheckpointPath(root, p1, "b"), history)
+
+	steps := []struct {
+		name string
+		op   func() error
+		ok   bool
+		p1   []string
+		p2   []string
+	}{
+		{"rename", func() error { return RenameCheckpoint(root, p1, "a", "c") }, true, []string{"b", "c"}, nil},
+		{"rename onto existing", func() error { return RenameCheckpoint(root, p1, "b", "c") }, false, []string{"b", "c"}, nil},
+		{"rename missing", func() error { return RenameCheckpoint(root, p1, "a", "d") }, false, []string{"b", "c"}, nil},
+		{"copy", func() error { return CopyCheckpoint(root, p1, "b", p2, "") }, true, []string{"b", "c"}, []string{"b"}},
+		{"copy renamed", func() error { return CopyCheckpoint(root, p1, "b", p2, "x y") }, true, []string{"b", "c"}, []string{"b", "x y"}},
+		{"copy onto existing", func() error { return CopyCheckpoint(root, p1, "c", p2, "b") }, false, []string{"b", "c"}, []string{"b", "x y"}},
+		{"copy onto itself", func() error { return CopyCheckpoint(root, p1, "b", p1, "") }, false, []string{"b", "c"}, []string{"b", "x y"}},
+		{"delete", func() error { return DeleteCheckpoint(root, p2, "b") }, true, []string{"b", "c"}, []string{"x y"}},
+		{"delete missing", func() error { return DeleteCheckpoint(root, p2, "b") }, false, []string{"b", "c"}, []string{"x y"}},
+	}
+	for _, s := range steps {
+		if err := s.op(); (err == nil) != s.ok {
+			t.Errorf("%s: error %v", s.name, err)
+		}
+		if got := checkpointNames(t, root, p1); !reflect.DeepEqual(got, s.p1) {
+			t.Errorf("%s: checkpoints of p1 = %q, want %q", s.name, got, s.p1)
+		}
+		if got := checkpointNames(t, root, p2); !reflect.DeepEqual(got, s.p2) {
+			t.Errorf("%s: checkpoints of p2 = %q, want %q", s.name, got, s.p2)
+		}
+		if _, err := os.Stat(filepath.Join(root, journal.DirName)); !os.IsNotExist(err) {
+			t.Errorf("%s: journal left behind", s.name)
+		}
+	}
+
+	s, err := PromoteCheckpoint(root, p1, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	promoted, err := GetSession(root, p1, s.ID)
+	if err != nil || len(promoted.Messages) != 1 || promoted.Messages[0].Content != "hi" {
+		t.Errorf("promoted session = %+v, %v", promoted, err)
+	}
+	if got := checkpointNames(t, root, p1); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("checkpoints after promoting = %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// UnmodelledFields returns the JSON paths of the fields of a session file
+// that Session does not model, and that are therefore dropped when geminictl
+// rewrites the file. Paths look like messages[2].toolCalls[0].kind.
+func UnmodelledFields(data []byte) (map[string]bool, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]bool)
+	unmodelled(v, reflect.TypeOf(Session{}), "", fields)
+	return fields, nil
+}
+
+// unmodelled walks v along type t, adding the paths of the object keys t has
+// no field for to fields.
+func unmodelled(v any, t reflect.Type, path string, fields map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		return // Modelled as opaque JSON
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			for key, child := range v {
+				childPath := joinPath(path, key)
+				if f, ok := jsonField(t, key); ok {
+					unmodelled(child, f.Type, childPath, fields)
+				} else {
+					fields[childPath] = true
+				}
+			}
+		case reflect.Map:
+			for key, child := range v {
+				unmodelled(child, t.Elem(), joinPath(path, key), fields)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, child := range v {
+				unmodelled(child, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fields)
+			}
+		}
+	}
+}
+
+// jsonField returns the field of struct type t that encoding/json decodes
+// the key into. Like encoding/json, keys match names case-insensitively.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
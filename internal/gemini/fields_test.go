@@ -0,0 +1,47 @@
+package gemini
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestUnmodelledFields(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{`{"sessionId":"s","messages":[]}`, nil},
+		{sessionJSON, []string{"kind", "messages[0].attachments", "summary"}},
+		// Keys match case-insensitively, like encoding/json.
+		{`{"SESSIONID":"s","ProjectHash":"p"}`, nil},
+		// Fields geminictl keeps out of the JSON are not modelled.
+		{`{"filePath":"x","files":[]}`, []string{"filePath", "files"}},
+		{`{"messages":[{"tokens":{"total":1,"extra":2},"thoughts":[{"subject":"s","kind":"k"}]}]}`,
+			[]string{"messages[0].thoughts[0].kind", "messages[0].tokens.extra"}},
+		// Tool call arguments and results are kept as they are.
+		{`{"messages":[{},{"toolCalls":[{"args":{"any":1},"result":[{"x":1}],"kind":"edit"}]}]}`,
+			[]string{"messages[1].toolCalls[0].kind"}},
+		// Values of an unexpected type are not descended into.
+		{`{"messages":{"a":{"b":1}},"startTime":{"c":1}}`, nil},
+	}
+	for _, tt := range tests {
+		fields, err := UnmodelledFields([]byte(tt.data))
+		if err != nil {
+			t.Errorf("UnmodelledFields(%s): %v", tt.data, err)
+			continue
+		}
+		var got []string
+		for path := range fields {
+			got = append(got, path)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("UnmodelledFields(%.60s) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+
+	if _, err := UnmodelledFields([]byte(`{`)); err == nil {
+		t.Errorf("UnmodelledFields of invalid JSON succeeded")
+	}
+}
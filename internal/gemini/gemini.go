@@ -1,14 +1,18 @@
 package gemini
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"geminictl/internal/journal"
 )
 
 // Constants for Gemini CLI storage structure.
@@ -20,9 +24,9 @@ const (
 
 // Thought represents a chain-of-thought step.
 type Thought struct {
-	Subject     string    `json:"subject"`
-	Description string    `json:"description"`
-	Timestamp   string    `json:"timestamp"`
+	Subject     string `json:"subject"`
+	Description string `json:"description"`
+	Timestamp   string `json:"timestamp"`
 }
 
 // TokenStats represents usage statistics.
@@ -35,15 +39,61 @@ type TokenStats struct {
 	Total    int `json:"total"`
 }
 
+// Message types written by Gemini CLI.
+const (
+	MessageUser    = "user"
+	MessageGemini  = "gemini"
+	MessageInfo    = "info"
+	MessageWarning = "warning"
+	MessageError   = "error"
+)
+
+// TypeLabel returns the name of a message type to show to users. Types
+// unknown to geminictl are labelled with their capitalized name.
+func TypeLabel(typ string) string {
+	switch typ {
+	case MessageUser:
+		return "User"
+	case MessageGemini:
+		return "Gemini"
+	case "":
+		return "Unknown"
+	}
+	return strings.ToUpper(typ[:1]) + typ[1:]
+}
+
 // Message represents a single interaction in a session.
 type Message struct {
-	ID        string     `json:"id"`
-	Timestamp string     `json:"timestamp"`
-	Type      string     `json:"type"` // "user" or "gemini"
-	Content   string     `json:"content"`
-	Thoughts  []Thought  `json:"thoughts,omitempty"`
+	ID        string      `json:"id"`
+	Timestamp string      `json:"timestamp"`
+	Type      string      `json:"type"` // One of the Message* types
+	Content   string      `json:"content"`
+	Thoughts  []Thought   `json:"thoughts,omitempty"`
 	Tokens    *TokenStats `json:"tokens,omitempty"`
-	Model     string     `json:"model,omitempty"`
+	Model     string      `json:"model,omitempty"`
+	ToolCalls []ToolCall  `json:"toolCalls,omitempty"`
+
+	// Source is the path of the file a merged message was read from.
+	Source string `json:"-"`
+}
+
+// Time returns the parsed Timestamp, or the zero time.
+func (m Message) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339, m.Timestamp)
+	return t
+}
+
+// ToolCall is a tool invoked by the model while producing a message.
+type ToolCall struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	DisplayName   string          `json:"displayName,omitempty"`
+	Description   string          `json:"description,omitempty"`
+	Status        string          `json:"status,omitempty"` // e.g. "success", "error", "cancelled"
+	Timestamp     string          `json:"timestamp,omitempty"`
+	Args          json.RawMessage `json:"args,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	ResultDisplay json.RawMessage `json:"resultDisplay,omitempty"`
 }
 
 // Session represents the content of a Gemini CLI session file.
@@ -53,13 +103,39 @@ type Session struct {
 	StartTime   string    `json:"startTime"`
 	LastUpdated string    `json:"lastUpdated"`
 	Messages    []Message `json:"messages"`
-	
+
 	// Metadata not in JSON but extracted from file system
 	FileLastUpdate time.Time `json:"-"`
 	FilePath       string    `json:"-"`
+	FileSize       int64     `json:"-"`
+
+	// Files lists the files a session returned by MergeSessions was merged
+	// from.
+	Files []SessionFile `json:"-"`
 }
 
-// GetLastUpdate returns the parsed LastUpdated timestamp from the JSON, 
+// SessionFile is one of the files backing a session.
+type SessionFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+
+	// Count messages of the merged session come from the file, the first
+	// at index First and the last at index Last (both -1 without messages).
+	// Duplicates counts the messages of the file superseded by a copy in a
+	// more recently updated file.
+	First, Last int
+	Count       int
+	Duplicates  int
+}
+
+// Started returns the parsed StartTime, or the zero time.
+func (s Session) Started() time.Time {
+	t, _ := time.Parse(time.RFC3339, s.StartTime)
+	return t
+}
+
+// GetLastUpdate returns the parsed LastUpdated timestamp from the JSON,
 // falling back to the file system modification time if parsing fails.
 func (s Session) GetLastUpdate() time.Time {
 	t, err := time.Parse(time.RFC3339, s.LastUpdated)
@@ -75,8 +151,23 @@ func HashProjectID(path string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	hash := sha256.Sum256([]byte(abs))
-	return hex.EncodeToString(hash[:]), nil
+	return HashPath(abs), nil
+}
+
+// HashPath returns the SHA-256 hash of a path exactly as given, without
+// making it absolute or cleaning it.
+func HashPath(path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(hash[:])
+}
+
+// ShortID returns the first 8 characters of a project or session ID, which is
+// how IDs are shown to users.
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
 }
 
 // ListProjectIDs discovers all project hash directories in the root.
@@ -99,8 +190,8 @@ func ListProjectIDs(rootDir string) ([]string, error) {
 	return ids, nil
 }
 
-// ReadSessions parses all session files for a specific project.
-func ReadSessions(rootDir, projectID string) ([]Session, error) {
+// ListSessionFiles returns the paths of all session files of a project.
+func ListSessionFiles(rootDir, projectID string) ([]string, error) {
 	sessionPath := filepath.Join(rootDir, projectID, SessionDir)
 	entries, err := os.ReadDir(sessionPath)
 	if err != nil {
@@ -110,54 +201,79 @@ func ReadSessions(rootDir, projectID string) ([]Session, error) {
 		return nil, err
 	}
 
-	var sessions []Session
+	var paths []string
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasPrefix(entry.Name(), SessionPrefix) || !strings.HasSuffix(entry.Name(), SessionSuffix) {
 			continue
 		}
+		paths = append(paths, filepath.Join(sessionPath, entry.Name()))
+	}
+	return paths, nil
+}
+
+// ReadSessions parses all session files for a specific project.
+func ReadSessions(rootDir, projectID string) ([]Session, error) {
+	paths, err := ListSessionFiles(rootDir, projectID)
+	if err != nil {
+		return nil, err
+	}
 
-		path := filepath.Join(sessionPath, entry.Name())
-		s, err := parseSessionFile(path)
+	var sessions []Session
+	for _, path := range paths {
+		s, err := ReadSessionFile(path)
 		if err != nil {
 			continue
 		}
-		s.FilePath = path
 		sessions = append(sessions, s)
 	}
 	return sessions, nil
 }
 
 // GetSession aggregates all messages for a specific session ID in a project.
-// Gemini CLI may split a single logical session across multiple files if it spans long periods.
+// Gemini CLI may split a single logical session across multiple files; they
+// are merged with MergeSessions.
 func GetSession(rootDir, projectID, sessionID string) (Session, error) {
 	allSessions, err := ReadSessions(rootDir, projectID)
 	if err != nil {
 		return Session{}, err
 	}
 
-	var result Session
-	found := false
-
+	var files []Session
 	for _, s := range allSessions {
 		if s.ID == sessionID {
-			if !found {
-				result = s
-				found = true
-			} else {
-				// Aggregate messages
-				result.Messages = append(result.Messages, s.Messages...)
-				// Update timestamps if necessary (assuming they are already sorted or we sort later)
-			}
+			files = append(files, s)
 		}
 	}
-
-	if !found {
+	if len(files) == 0 {
 		return Session{}, fmt.Errorf("session %s not found in project %s", sessionID, projectID)
 	}
+	return MergeSessions(files), nil
+}
 
-	// Ensure messages are sorted by timestamp if they came from multiple files
-	// (Gemini CLI session files are usually chronological, but aggregation might need care)
-	return result, nil
+// Sessions returns all sessions of a project, each merged from its files
+// like GetSession does, ordered by start time.
+func Sessions(rootDir, projectID string) ([]Session, error) {
+	allSessions, err := ReadSessions(rootDir, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string][]Session)
+	var ids []string
+	for _, s := range allSessions {
+		if _, ok := byID[s.ID]; !ok {
+			ids = append(ids, s.ID)
+		}
+		byID[s.ID] = append(byID[s.ID], s)
+	}
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		sessions = append(sessions, MergeSessions(byID[id]))
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Started().Before(sessions[j].Started())
+	})
+	return sessions, nil
 }
 
 // WriteSession marshals and writes a session to the Gemini storage structure.
@@ -173,43 +289,71 @@ func WriteSession(rootDir, projectID string, s Session) error {
 		return err
 	}
 
+	return journal.WriteFileAtomic(sessionFilePath(rootDir, projectID, s), data, 0644)
+}
+
+// sessionFilePath returns the path WriteSession uses for a session.
+func sessionFilePath(rootDir, projectID string, s Session) string {
 	// Format timestamp: 2026-02-02T12:55 -> 2026-02-02T12-55
-	ts := strings.ReplaceAll(s.StartTime[:16], ":", "-")
-	
-	// Short ID: first 8 chars of sessionId
-	shortID := s.ID
-	if len(shortID) > 8 {
-		shortID = shortID[:8]
+	ts := s.StartTime
+	if len(ts) > 16 {
+		ts = ts[:16]
 	}
+	ts = strings.ReplaceAll(ts, ":", "-")
+
+	filename := fmt.Sprintf("%s%s-%s%s", SessionPrefix, ts, ShortID(s.ID), SessionSuffix)
+	return filepath.Join(rootDir, projectID, SessionDir, filename)
+}
 
-	filename := fmt.Sprintf("%s%s-%s%s", SessionPrefix, ts, shortID, SessionSuffix)
-	return os.WriteFile(filepath.Join(sessionPath, filename), data, 0644)
+// Recover completes or rolls back an operation that was interrupted in the
+// storage root, e.g. by a crash. It returns the name of the recovered
+// operation, or "" if there was nothing to recover.
+func Recover(rootDir string) (string, error) {
+	return journal.Recover(rootDir)
 }
 
 // DeleteProject removes the entire project directory from Gemini storage.
 func DeleteProject(rootDir, projectID string) error {
-	path := filepath.Join(rootDir, projectID)
-	return os.RemoveAll(path)
+	j, err := journal.Begin(rootDir, "delete project "+projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := j.Remove(filepath.Join(rootDir, projectID)); err != nil {
+		return journal.Abort(j, err)
+	}
+	return j.Commit()
 }
 
-// DeleteSession removes all files associated with a specific session ID.
+// DeleteSession removes all files associated with a specific session ID and
+// prunes its entries from the project's logs.json.
 func DeleteSession(rootDir, projectID, sessionID string) error {
 	allSessions, err := ReadSessions(rootDir, projectID)
 	if err != nil {
 		return err
 	}
 
+	j, err := journal.Begin(rootDir, "delete session "+sessionID)
+	if err != nil {
+		return err
+	}
+
 	for _, s := range allSessions {
 		if s.ID == sessionID {
-			if err := os.Remove(s.FilePath); err != nil {
-				return err
+			if err := j.Remove(s.FilePath); err != nil {
+				return journal.Abort(j, err)
 			}
 		}
 	}
-	return nil
+	if _, err := ExtractSessionLogs(j, rootDir, projectID, sessionID); err != nil {
+		return journal.Abort(j, err)
+	}
+	return j.Commit()
 }
 
-// MoveSession relocates a session to a different project.
+// MoveSession relocates a session to a different project, including its
+// entries in the project's logs.json. The files of the session keep their
+// names and the fields geminictl does not model.
 func MoveSession(rootDir, oldProjectID, newProjectID, sessionID string) error {
 	if oldProjectID == newProjectID {
 		return nil
@@ -220,71 +364,165 @@ func MoveSession(rootDir, oldProjectID, newProjectID, sessionID string) error {
 		return err
 	}
 
+	if err := os.MkdirAll(filepath.Join(rootDir, newProjectID, SessionDir), 0755); err != nil {
+		return err
+	}
+
+	j, err := journal.Begin(rootDir, "move session "+sessionID)
+	if err != nil {
+		return err
+	}
+
 	for _, s := range allSessions {
-		if s.ID == sessionID {
-			oldPath := s.FilePath
-			s.ProjectHash = newProjectID
-			
-			if err := WriteSession(rootDir, newProjectID, s); err != nil {
-				return err
-			}
-			
-			if err := os.Remove(oldPath); err != nil {
-				// We could try to roll back but it's complex. 
-				// At least the new file is written.
-				return fmt.Errorf("failed to remove old session file: %w", err)
-			}
+		if s.ID != sessionID {
+			continue
+		}
+		data, err := os.ReadFile(s.FilePath)
+		if err != nil {
+			return journal.Abort(j, err)
+		}
+		if data, err = SetProjectHash(data, newProjectID); err != nil {
+			return journal.Abort(j, fmt.Errorf("%s: %w", s.FilePath, err))
+		}
+		newPath := filepath.Join(rootDir, newProjectID, SessionDir, filepath.Base(s.FilePath))
+		if _, err := os.Stat(newPath); err == nil {
+			return journal.Abort(j, fmt.Errorf("%s already exists", newPath))
+		}
+		if err := j.WriteFile(newPath, data, 0644); err != nil {
+			return journal.Abort(j, err)
+		}
+		if err := j.Remove(s.FilePath); err != nil {
+			return journal.Abort(j, fmt.Errorf("failed to remove old session file: %w", err))
 		}
 	}
-	return nil
+
+	logs, err := ExtractSessionLogs(j, rootDir, oldProjectID, sessionID)
+	if err != nil {
+		return journal.Abort(j, err)
+	}
+	if err := AppendLogs(j, rootDir, newProjectID, logs); err != nil {
+		return journal.Abort(j, err)
+	}
+	return j.Commit()
 }
 
 // MoveProject migrates a project to a new directory path.
-// It renames the storage directory and updates the projectHash in all sessions.
+// It renames the storage directory and updates the projectHash in all sessions,
+// keeping the fields geminictl does not model (see SetProjectHash).
+// Either all of this happens or, on failure, nothing does.
 func MoveProject(rootDir, oldID, newPath string) (string, error) {
 	newID, err := HashProjectID(newPath)
 	if err != nil {
 		return "", err
 	}
+	if err := MoveProjectToID(rootDir, oldID, newID); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
 
+// MoveProjectToID migrates a project to another project ID, like MoveProject
+// does. It is used where the ID is not the hash of a clean absolute path,
+// e.g. to move a project back to the spelling of its directory it was
+// hashed under.
+func MoveProjectToID(rootDir, oldID, newID string) error {
 	if oldID == newID {
-		return newID, nil // No change needed
+		return nil // No change needed
 	}
 
 	oldPath := filepath.Join(rootDir, oldID)
 	newStoragePath := filepath.Join(rootDir, newID)
 
+	j, err := journal.Begin(rootDir, "move project "+oldID)
+	if err != nil {
+		return err
+	}
+
 	// 1. Rename the project directory
-	if err := os.Rename(oldPath, newStoragePath); err != nil {
-		return "", err
+	if err := j.Move(oldPath, newStoragePath); err != nil {
+		return journal.Abort(j, err)
 	}
 
-	// 2. Update all sessions in the new directory
-	sessions, err := ReadSessions(rootDir, newID)
+	// 2. Update the projectHash of all sessions in the new directory. Files
+	// that are not sessions Gemini CLI can read are left alone.
+	paths, err := ListSessionFiles(rootDir, newID)
 	if err != nil {
-		return newID, fmt.Errorf("failed to read sessions after move: %w", err)
+		return journal.Abort(j, fmt.Errorf("failed to read sessions after move: %w", err))
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return journal.Abort(j, err)
+		}
+		data, err = SetProjectHash(data, newID)
+		if err != nil {
+			continue
+		}
+		if err := j.WriteFile(path, data, 0644); err != nil {
+			return journal.Abort(j, fmt.Errorf("failed to update %s: %w", filepath.Base(path), err))
+		}
 	}
 
-	for _, s := range sessions {
-		// Store the old file path before we potentially rename/overwrite
-		oldFilePath := s.FilePath
+	return j.Commit()
+}
 
-		s.ProjectHash = newID
-		if err := WriteSession(rootDir, newID, s); err != nil {
-			return newID, fmt.Errorf("failed to update session %s: %w", s.ID, err)
-		}
+// SetProjectHash returns the contents of a session file with its projectHash
+// set to projectID. Unlike marshalling a Session, the order of the fields and
+// the fields geminictl does not model are kept.
+func SetProjectHash(data []byte, projectID string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	hash, _ := json.Marshal(projectID)
 
-		// If the filename changed (because the short ID changed), remove the old one
-		newShortID := newID[:8]
-		if !strings.Contains(filepath.Base(oldFilePath), newShortID) {
-			_ = os.Remove(oldFilePath)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	found := false
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if key == "projectHash" {
+			value, found = hash, true
 		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
 	}
+	if !found {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"projectHash":`)
+		buf.Write(hash)
+	}
+	buf.WriteByte('}')
 
-	return newID, nil
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
 }
 
-func parseSessionFile(path string) (Session, error) {
+// ReadSessionFile parses a single session file.
+func ReadSessionFile(path string) (Session, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return Session{}, err
@@ -304,5 +542,7 @@ func parseSessionFile(path string) (Session, error) {
 		return Session{}, err
 	}
 	s.FileLastUpdate = info.ModTime()
+	s.FilePath = path
+	s.FileSize = info.Size()
 	return s, nil
-}
\ No newline at end of file
+}
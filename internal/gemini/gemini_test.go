@@ -0,0 +1,236 @@
+package gemini
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"geminictl/internal/journal"
+)
+
+// sessionJSON is a session file with fields geminictl does not model, at the
+// top level and within messages.
+const sessionJSON = `{
+  "sessionId": "3f2a0000-1111-2222-3333-444455556666",
+  "projectHash": "old",
+  "startTime": "2025-01-01T10:00:00Z",
+  "lastUpdated": "2025-01-01T10:05:00Z",
+  "kind": "main",
+  "messages": [
+    {
+      "id": "m1",
+      "timestamp": "2025-01-01T10:00:00Z",
+      "type": "user",
+      "content": "hello",
+      "attachments": [{"name": "a.txt"}]
+    }
+  ],
+  "summary": {"text": "greeting", "score": 0.5}
+}`
+
+func TestSetProjectHash(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"a":1,"projectHash":"old","b":[1,2]}`, `{"a":1,"projectHash":"new","b":[1,2]}`},
+		{`{"a":{"projectHash":"nested"}}`, `{"a":{"projectHash":"nested"},"projectHash":"new"}`},
+		{`{}`, `{"projectHash":"new"}`},
+		{`{"s":"é\n"}`, `{"s":"é\n","projectHash":"new"}`},
+	}
+	for _, tt := range tests {
+		out, err := SetProjectHash([]byte(tt.in), "new")
+		if err != nil {
+			t.Errorf("SetProjectHash(%s): %v", tt.in, err)
+			continue
+		}
+		var got, want any
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("SetProjectHash(%s) = invalid JSON %s", tt.in, out)
+		}
+		_ = json.Unmarshal([]byte(tt.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SetProjectHash(%s) = %s, want %s", tt.in, out, tt.want)
+		}
+		// Keys keep their order.
+		if i, j := strings.Index(string(out), `"a"`), strings.Index(string(out), `"projectHash"`); i > j && strings.Contains(tt.in, `"a"`) {
+			t.Errorf("SetProjectHash(%s) = %s: keys reordered", tt.in, out)
+		}
+	}
+
+	for _, in := range []string{``, `[]`, `{"a":`, `"x"`} {
+		if _, err := SetProjectHash([]byte(in), "new"); err == nil {
+			t.Errorf("SetProjectHash(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+// writeFile writes a file, creating its directory.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkRewritten checks that the session file at path equals sessionJSON
+// with projectHash set to id.
+func checkRewritten(t *testing.T, path, id string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	_ = json.Unmarshal([]byte(sessionJSON), &want)
+	want["projectHash"] = id
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s =\n%s\nwant %v", filepath.Base(path), data, want)
+	}
+}
+
+func TestMoveProjectKeepsUnknownFields(t *testing.T) {
+	root := t.TempDir()
+	oldID := HashPath("/old")
+	name := "session-2025-01-01T10-00-3f2a0000.json"
+	writeFile(t, filepath.Join(root, oldID, SessionDir, name), sessionJSON)
+	writeFile(t, filepath.Join(root, oldID, SessionDir, "session-broken.json"), "{")
+	writeFile(t, filepath.Join(root, oldID, "logs.json"), "[]")
+
+	newID, err := MoveProject(root, oldID, "/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newID != HashPath("/new") {
+		t.Errorf("MoveProject returned %s, want the hash of /new", newID)
+	}
+	if _, err := os.Stat(filepath.Join(root, oldID)); !os.IsNotExist(err) {
+		t.Errorf("old project directory still exists")
+	}
+	checkRewritten(t, filepath.Join(root, newID, SessionDir, name), newID)
+	if data, _ := os.ReadFile(filepath.Join(root, newID, SessionDir, "session-broken.json")); string(data) != "{" {
+		t.Errorf("unreadable session file changed to %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, journal.DirName)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind")
+	}
+
+	// And back again, to an ID that is not the hash of a clean path.
+	variantID := HashPath("/old/")
+	if err := MoveProjectToID(root, newID, variantID); err != nil {
+		t.Fatal(err)
+	}
+	checkRewritten(t, filepath.Join(root, variantID, SessionDir, name), variantID)
+}
+
+func TestMoveSessionKeepsUnknownFields(t *testing.T) {
+	root := t.TempDir()
+	from, to := HashPath("/from"), HashPath("/to")
+	sessionID := "3f2a0000-1111-2222-3333-444455556666"
+	// A session split across two files, and another session.
+	names := []string{"session-2025-01-01T10-00-3f2a0000.json", "session-2025-01-01T10-00-3f2a0000-2.json"}
+	for _, name := range names {
+		writeFile(t, filepath.Join(root, from, SessionDir, name), sessionJSON)
+	}
+	other := filepath.Join(root, from, SessionDir, "session-2025-01-02T10-00-aaaaaaaa.json")
+	writeFile(t, other, `{"sessionId":"aaaaaaaa","projectHash":"old","messages":[]}`)
+	writeFile(t, filepath.Join(root, from, "logs.json"),
+		`[{"sessionId":"`+sessionID+`","messageId":0,"type":"user","message":"hello","timestamp":"2025-01-01T10:00:00Z"},`+
+			`{"sessionId":"aaaaaaaa","messageId":0,"type":"user","message":"other","timestamp":"2025-01-02T10:00:00Z"}]`)
+
+	if err := MoveSession(root, from, to, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		checkRewritten(t, filepath.Join(root, to, SessionDir, name), to)
+		if _, err := os.Stat(filepath.Join(root, from, SessionDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left in the old project", name)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+
+	logs, err := ReadLogs(root, to)
+	if err != nil || len(logs) != 1 || logs[0].SessionID != sessionID {
+		t.Errorf("logs of the new project = %+v, %v; want the entry of the session", logs, err)
+	}
+	if logs, _ := ReadLogs(root, from); len(logs) != 1 || logs[0].SessionID != "aaaaaaaa" {
+		t.Errorf("logs of the old project = %+v, want the entry of the other session", logs)
+	}
+}
+
+func TestMoveSessionConflict(t *testing.T) {
+	root := t.TempDir()
+	from, to := HashPath("/from"), HashPath("/to")
+	name := "session-2025-01-01T10-00-3f2a0000.json"
+	writeFile(t, filepath.Join(root, from, SessionDir, name), sessionJSON)
+	writeFile(t, filepath.Join(root, to, SessionDir, name), "taken")
+
+	if err := MoveSession(root, from, to, "3f2a0000-1111-2222-3333-444455556666"); err == nil {
+		t.Fatal("MoveSession overwrote an existing file")
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, to, SessionDir, name)); string(data) != "taken" {
+		t.Errorf("existing file changed to %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, from, SessionDir, name)); string(data) != sessionJSON {
+		t.Errorf("session file not rolled back")
+	}
+}
+
+func TestSessionFilePath(t *testing.T) {
+	tests := []struct {
+		s    Session
+		want string
+	}{
+		{Session{ID: "3f2a0000-1111", StartTime: "2025-01-01T10:00:00.000Z"}, "session-2025-01-01T10-00-3f2a0000.json"},
+		{Session{ID: "abc", StartTime: "2025-01-01"}, "session-2025-01-01-abc.json"},
+		{Session{ID: "abc"}, "session--abc.json"},
+	}
+	for _, tt := range tests {
+		want := filepath.Join("root", "p", SessionDir, tt.want)
+		if got := sessionFilePath("root", "p", tt.s); got != want {
+			t.Errorf("sessionFilePath(%+v) = %q, want %q", tt.s, got, want)
+		}
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	root := t.TempDir()
+	id := HashPath("/project")
+	chats := filepath.Join(root, id, SessionDir)
+	writeFile(t, filepath.Join(chats, "session-2025-01-01T10-00-s1.json"), `{"sessionId":"s1","messages":[]}`)
+	writeFile(t, filepath.Join(chats, "session-2025-01-01T11-00-s1.json"), `{"sessionId":"s1","messages":[]}`)
+	writeFile(t, filepath.Join(chats, "session-2025-01-02T10-00-s2.json"), `{"sessionId":"s2","messages":[]}`)
+	writeFile(t, LogsPath(root, id), `[{"sessionId":"s1","message":"a"},{"sessionId":"s2","message":"b"}]`)
+
+	if err := DeleteSession(root, id, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := ReadSessions(root, id)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "s2" {
+		t.Errorf("sessions after delete = %+v, %v", sessions, err)
+	}
+	if logs, _ := ReadLogs(root, id); len(logs) != 1 || logs[0].SessionID != "s2" {
+		t.Errorf("logs after delete = %+v", logs)
+	}
+
+	if err := DeleteProject(root, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, id)); !os.IsNotExist(err) {
+		t.Errorf("project directory kept")
+	}
+	if err := DeleteProject(root, id); err == nil {
+		t.Errorf("DeleteProject of a missing project succeeded")
+	}
+}
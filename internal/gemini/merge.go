@@ -0,0 +1,114 @@
+package gemini
+
+import (
+	"sort"
+	"time"
+)
+
+// MergeSessions merges the files of a session into a single session. Gemini
+// CLI splits sessions across files, e.g. for sub-agents or when resuming,
+// and a message may be present in several of them.
+//
+// The messages are ordered by timestamp and de-duplicated by ID, keeping the
+// copy of the most recently updated file; each records its file in Source.
+// The session spans from the earliest start time to the latest update of
+// the files, which are listed in Files in order of start time.
+func MergeSessions(files []Session) Session {
+	files = append([]Session(nil), files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		ti, tj := files[i].Started(), files[j].Started()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i].FilePath < files[j].FilePath
+	})
+
+	merged := files[0]
+	merged.Messages = nil
+	merged.Files = make([]SessionFile, len(files))
+
+	// A message without a valid timestamp is ordered after the message
+	// preceding it in its file.
+	type entry struct {
+		msg  Message
+		file int
+		at   time.Time
+	}
+	var entries []entry
+	byID := make(map[string]int) // Index in entries
+	for fi, f := range files {
+		merged.Files[fi] = SessionFile{Path: f.FilePath, Size: f.FileSize, ModTime: f.FileLastUpdate, First: -1, Last: -1}
+
+		if t := f.Started(); !t.IsZero() && (merged.Started().IsZero() || t.Before(merged.Started())) {
+			merged.StartTime = f.StartTime
+		}
+		if f.GetLastUpdate().After(merged.GetLastUpdate()) {
+			merged.LastUpdated = f.LastUpdated
+			merged.FileLastUpdate = f.FileLastUpdate
+		}
+
+		at := f.Started()
+		for _, msg := range f.Messages {
+			if t := msg.Time(); !t.IsZero() {
+				at = t
+			}
+			msg.Source = f.FilePath
+			e := entry{msg: msg, file: fi, at: at}
+
+			i, dup := byID[msg.ID]
+			if !dup || msg.ID == "" {
+				byID[msg.ID] = len(entries)
+				entries = append(entries, e)
+				continue
+			}
+			prev := entries[i].file
+			if f.GetLastUpdate().Before(files[prev].GetLastUpdate()) {
+				merged.Files[fi].Duplicates++
+				continue
+			}
+			merged.Files[prev].Duplicates++
+			entries[i] = e
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].at.Before(entries[j].at)
+	})
+	merged.Messages = make([]Message, len(entries))
+	for i, e := range entries {
+		merged.Messages[i] = e.msg
+		sf := &merged.Files[e.file]
+		if sf.First < 0 {
+			sf.First = i
+		}
+		sf.Last = i
+		sf.Count++
+	}
+	return merged
+}
+
+// SummarizeSession returns the summary of a session, as ReadSessionMeta
+// returns for a session file.
+func SummarizeSession(s Session) SessionMeta {
+	meta := SessionMeta{
+		ID:           s.ID,
+		ProjectHash:  s.ProjectHash,
+		StartTime:    s.StartTime,
+		LastUpdated:  s.LastUpdated,
+		MessageCount: len(s.Messages),
+	}
+	models := make(map[string]bool)
+	for _, m := range s.Messages {
+		if m.Type == "user" && meta.Title == "" {
+			meta.Title = PromptTitle(m.Content)
+		}
+		if m.Model != "" && !models[m.Model] {
+			models[m.Model] = true
+			meta.Models = append(meta.Models, m.Model)
+		}
+		if m.Tokens != nil {
+			meta.Tokens.Add(*m.Tokens)
+		}
+	}
+	return meta
+}
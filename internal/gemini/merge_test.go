@@ -0,0 +1,113 @@
+package gemini
+
+import (
+	"reflect"
+	"testing"
+)
+
+// msg returns a user message with the given ID and time of day.
+func msg(id, at string) Message {
+	m := Message{ID: id, Type: MessageUser, Content: id}
+	if at != "" {
+		m.Timestamp = "2025-01-01T" + at + "Z"
+	}
+	return m
+}
+
+// file returns a session file started and last updated at the given times.
+func file(path, start, updated string, msgs ...Message) Session {
+	return Session{
+		ID:          "s",
+		StartTime:   "2025-01-01T" + start + "Z",
+		LastUpdated: "2025-01-01T" + updated + "Z",
+		FilePath:    path,
+		Messages:    msgs,
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []Session
+		ids     []string // Message IDs in order
+		sources []string // File of each message
+		start   string
+		updated string
+		counts  []int // Count of each file, by start time
+		dups    []int // Duplicates of each file, by start time
+	}{
+		{
+			name:  "single file",
+			files: []Session{file("a", "10:00:00", "10:05:00", msg("1", "10:00:00"), msg("2", "10:01:00"))},
+			ids:   []string{"1", "2"}, sources: []string{"a", "a"},
+			start: "10:00:00", updated: "10:05:00",
+			counts: []int{2}, dups: []int{0},
+		},
+		{
+			name: "interleaved files",
+			files: []Session{
+				file("b", "10:02:00", "10:06:00", msg("3", "10:02:00"), msg("5", "10:04:00")),
+				file("a", "10:00:00", "10:05:00", msg("1", "10:00:00"), msg("4", "10:03:00")),
+			},
+			ids: []string{"1", "3", "4", "5"}, sources: []string{"a", "b", "a", "b"},
+			start: "10:00:00", updated: "10:06:00",
+			counts: []int{2, 2}, dups: []int{0, 0},
+		},
+		{
+			name: "duplicates keep the most recently updated copy",
+			files: []Session{
+				file("a", "10:00:00", "10:05:00", msg("1", "10:00:00"), msg("2", "10:01:00")),
+				file("b", "10:00:30", "10:09:00", msg("2", "10:01:00"), msg("3", "10:02:00")),
+			},
+			ids: []string{"1", "2", "3"}, sources: []string{"a", "b", "b"},
+			start: "10:00:00", updated: "10:09:00",
+			counts: []int{1, 2}, dups: []int{1, 0},
+		},
+		{
+			name: "messages without timestamp follow their predecessor",
+			files: []Session{
+				file("a", "10:00:00", "10:05:00", msg("1", "10:00:00"), msg("2", ""), msg("4", "10:03:00")),
+				file("b", "10:00:00", "10:05:00", msg("3", "10:02:00"), msg("", ""), msg("", "")),
+			},
+			ids: []string{"1", "2", "3", "", "", "4"}, sources: []string{"a", "a", "b", "b", "b", "a"},
+			start: "10:00:00", updated: "10:05:00",
+			counts: []int{3, 3}, dups: []int{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		s := MergeSessions(tt.files)
+		var ids, sources []string
+		for _, m := range s.Messages {
+			ids = append(ids, m.ID)
+			sources = append(sources, m.Source)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(sources, tt.sources) {
+			t.Errorf("%s: messages %q from %q, want %q from %q", tt.name, ids, sources, tt.ids, tt.sources)
+		}
+		if s.StartTime != "2025-01-01T"+tt.start+"Z" || s.LastUpdated != "2025-01-01T"+tt.updated+"Z" {
+			t.Errorf("%s: spans %s to %s, want %s to %s", tt.name, s.StartTime, s.LastUpdated, tt.start, tt.updated)
+		}
+		var counts, dups []int
+		for _, f := range s.Files {
+			counts = append(counts, f.Count)
+			dups = append(dups, f.Duplicates)
+			if f.Count > 0 && (s.Messages[f.First].Source != f.Path || s.Messages[f.Last].Source != f.Path) {
+				t.Errorf("%s: First and Last of %s point at messages of other files", tt.name, f.Path)
+			}
+		}
+		if !reflect.DeepEqual(counts, tt.counts) || !reflect.DeepEqual(dups, tt.dups) {
+			t.Errorf("%s: counts %v and duplicates %v, want %v and %v", tt.name, counts, dups, tt.counts, tt.dups)
+		}
+	}
+}
+
+func TestMergeSessionsKeepsInput(t *testing.T) {
+	files := []Session{
+		file("b", "10:02:00", "10:06:00", msg("2", "10:02:00")),
+		file("a", "10:00:00", "10:05:00", msg("1", "10:00:00")),
+	}
+	MergeSessions(files)
+	if files[0].FilePath != "b" || files[0].Messages[0].Source != "" {
+		t.Errorf("MergeSessions modified its input: %+v", files)
+	}
+}
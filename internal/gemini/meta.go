@@ -0,0 +1,237 @@
+package gemini
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+)
+
+// SessionMeta is the summary of a session file that can be read without
+// materialising message content.
+type SessionMeta struct {
+	ID           string     `json:"sessionId"`
+	ProjectHash  string     `json:"projectHash"`
+	StartTime    string     `json:"startTime"`
+	LastUpdated  string     `json:"lastUpdated"`
+	MessageCount int        `json:"messageCount"`
+	Models       []string   `json:"models,omitempty"`
+	Tokens       TokenStats `json:"tokens"`
+	Title        string     `json:"title,omitempty"` // See PromptTitle
+}
+
+// Started returns the parsed StartTime, or the zero time.
+func (m SessionMeta) Started() time.Time {
+	t, _ := time.Parse(time.RFC3339, m.StartTime)
+	return t
+}
+
+// LastUpdate returns the parsed LastUpdated, falling back to the given
+// file modification time if parsing fails.
+func (m SessionMeta) LastUpdate(modTime time.Time) time.Time {
+	t, err := time.Parse(time.RFC3339, m.LastUpdated)
+	if err != nil {
+		return modTime
+	}
+	return t
+}
+
+// messageMeta is the part of a message ReadSessionMeta decodes. Fields not
+// listed here, most importantly the content and thoughts, are skipped by the
+// decoder without being allocated.
+type messageMeta struct {
+	Type   string      `json:"type"`
+	Model  string      `json:"model"`
+	Tokens *TokenStats `json:"tokens"`
+}
+
+// promptMeta is a messageMeta with the content, decoded until the first user
+// message was found.
+type promptMeta struct {
+	messageMeta
+	Content string `json:"content"`
+}
+
+// ReadSessionMeta reads the summary of a session file with a streaming
+// decoder, so that large sessions can be counted without holding them in
+// memory.
+func ReadSessionMeta(path string) (SessionMeta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SessionMeta{}, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var meta SessionMeta
+	if err := expectDelim(dec, '{'); err != nil {
+		return SessionMeta{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	models := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return SessionMeta{}, fmt.Errorf("%s: %w", path, err)
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "sessionId":
+			err = dec.Decode(&meta.ID)
+		case "projectHash":
+			err = dec.Decode(&meta.ProjectHash)
+		case "startTime":
+			err = dec.Decode(&meta.StartTime)
+		case "lastUpdated":
+			err = dec.Decode(&meta.LastUpdated)
+		case "messages":
+			err = decodeMessages(dec, &meta.Title, func(m messageMeta) {
+				meta.MessageCount++
+				if m.Model != "" && !models[m.Model] {
+					models[m.Model] = true
+					meta.Models = append(meta.Models, m.Model)
+				}
+				if m.Tokens != nil {
+					meta.Tokens.Add(*m.Tokens)
+				}
+			})
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return SessionMeta{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return meta, nil
+}
+
+// decodeMessages decodes the messages array one message at a time. The title
+// derived from the first non-empty user message is stored in title.
+func decodeMessages(dec *json.Decoder, title *string, fn func(messageMeta)) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil // "messages": null
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expected messages array, got %v", tok)
+	}
+	found := false
+	for dec.More() {
+		if !found {
+			var m promptMeta
+			if err := dec.Decode(&m); err != nil {
+				return err
+			}
+			if m.Type == "user" {
+				*title = PromptTitle(m.Content)
+				found = *title != ""
+			}
+			fn(m.messageMeta)
+			continue
+		}
+		var m messageMeta
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		fn(m)
+	}
+	_, err = dec.Token() // closing ']'
+	return err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// Add accumulates another set of token statistics.
+func (t *TokenStats) Add(o TokenStats) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.Cached += o.Cached
+	t.Thoughts += o.Thoughts
+	t.Tool += o.Tool
+	t.Total += o.Total
+}
+
+// String renders the total tokens followed by the non-zero details.
+func (t TokenStats) String() string {
+	var details []string
+	for _, d := range []struct {
+		name string
+		n    int
+	}{
+		{"in", t.Input}, {"out", t.Output}, {"cached", t.Cached},
+		{"thoughts", t.Thoughts}, {"tool", t.Tool},
+	} {
+		if d.n > 0 {
+			details = append(details, fmt.Sprintf("%s %s", d.name, humanize.Comma(int64(d.n))))
+		}
+	}
+	s := humanize.Comma(int64(t.Total)) + " tokens"
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// MaxTitleLen is the length in runes titles are truncated to.
+const MaxTitleLen = 60
+
+// PromptTitle derives the title of a session from its first user prompt: the
+// prompt on a single line, without control characters or terminal escape
+// sequences and truncated to MaxTitleLen runes.
+func PromptTitle(prompt string) string {
+	var b strings.Builder
+	space := false
+	escape := 0 // 1 after ESC, 2 inside a CSI sequence
+	for _, r := range prompt {
+		switch {
+		case escape == 1:
+			escape = 0
+			if r == '[' {
+				escape = 2
+			}
+			continue
+		case escape == 2:
+			if r >= '@' && r <= '~' {
+				escape = 0
+			}
+			continue
+		case r == '\x1b':
+			escape = 1
+			continue
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case unicode.IsControl(r) || !unicode.IsPrint(r):
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	title := []rune(b.String())
+	if len(title) <= MaxTitleLen {
+		return string(title)
+	}
+	return strings.TrimRight(string(title[:MaxTitleLen-1]), " ") + "…"
+}
@@ -0,0 +1,102 @@
+package gemini
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSessionMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want SessionMeta
+	}{
+		{
+			name: "full",
+			data: `{"sessionId":"s","projectHash":"p","startTime":"a","lastUpdated":"b","extra":{"x":[1,{"y":2}]},"messages":[
+				{"type":"user","content":"hello","unknown":[1]},
+				{"type":"gemini","content":"hi","model":"pro","tokens":{"input":1,"output":2,"total":3}},
+				{"type":"gemini","content":"again","model":"flash","tokens":{"input":4,"total":4}},
+				{"type":"gemini","model":"pro"}]}`,
+			want: SessionMeta{ID: "s", ProjectHash: "p", StartTime: "a", LastUpdated: "b", MessageCount: 4,
+				Models: []string{"pro", "flash"}, Tokens: TokenStats{Input: 5, Output: 2, Total: 7}, Title: "hello"},
+		},
+		{
+			name: "messages last",
+			data: `{"messages":[{"type":"user","content":"x"}],"sessionId":"s"}`,
+			want: SessionMeta{ID: "s", MessageCount: 1, Title: "x"},
+		},
+		{name: "null messages", data: `{"sessionId":"s","messages":null}`, want: SessionMeta{ID: "s"}},
+		{name: "no messages", data: `{"sessionId":"s"}`, want: SessionMeta{ID: "s"}},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".json")
+		writeFile(t, path, tt.data)
+		got, err := ReadSessionMeta(path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadSessionMeta = %+v, want %+v", tt.name, got, tt.want)
+		}
+
+		// The streaming decoder agrees with decoding the whole session.
+		var s Session
+		if err := json.Unmarshal([]byte(tt.data), &s); err != nil {
+			t.Fatal(err)
+		}
+		if summary := SummarizeSession(s); !reflect.DeepEqual(summary, got) {
+			t.Errorf("%s: SummarizeSession = %+v, ReadSessionMeta = %+v", tt.name, summary, got)
+		}
+	}
+}
+
+func TestReadSessionMetaErrors(t *testing.T) {
+	dir := t.TempDir()
+	for i, data := range []string{``, `[]`, `{"sessionId":`, `{"messages":{}}`, `{"messages":[{"type":1}]}`, `{"messages":[}`} {
+		path := filepath.Join(dir, "broken.json")
+		writeFile(t, path, data)
+		if _, err := ReadSessionMeta(path); err == nil {
+			t.Errorf("%d: ReadSessionMeta(%s) succeeded", i, data)
+		}
+	}
+	if _, err := ReadSessionMeta(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("ReadSessionMeta of a missing file succeeded")
+	}
+}
+
+func TestPromptTitle(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	tests := []struct {
+		prompt, want string
+	}{
+		{"", ""},
+		{"  fix\tthe\n\nbuild  ", "fix the build"},
+		{"\x1b[31mred\x1b[0m text", "red text"},
+		{"bell\a and\x00 nul", "bell and nul"},
+		{long, strings.TrimSpace(long[:MaxTitleLen-1]) + "…"},
+		{strings.Repeat("é", MaxTitleLen), strings.Repeat("é", MaxTitleLen)},
+	}
+	for _, tt := range tests {
+		if got := PromptTitle(tt.prompt); got != tt.want {
+			t.Errorf("PromptTitle(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestSessionTitle(t *testing.T) {
+	// The title comes from the first user message with text.
+	data := `{"messages":[{"type":"info","content":"started"},{"type":"user","content":"  "},` +
+		`{"type":"user","content":"fix\nthe build"},{"type":"user","content":"later"}]}`
+	path := filepath.Join(t.TempDir(), "s.json")
+	writeFile(t, path, data)
+	meta, err := ReadSessionMeta(path)
+	if err != nil || meta.Title != "fix the build" {
+		t.Errorf("ReadSessionMeta title = %q, %v; want %q", meta.Title, err, "fix the build")
+	}
+}
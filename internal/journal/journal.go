@@ -0,0 +1,329 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// DirName is the name of the journal directory created inside the directory
+// an operation works on.
+const DirName = ".geminictl-journal"
+
+const (
+	journalFile = "journal.json"
+	backupDir   = "backup"
+	tmpSuffix   = ".geminictl-tmp"
+)
+
+// Step kinds.
+const (
+	StepMove   = "move"
+	StepWrite  = "write"
+	StepRemove = "remove"
+)
+
+// Step is a single recorded mutation. Steps are persisted before they are
+// performed, so undoing a step must cope with it never having happened.
+type Step struct {
+	Kind   string `json:"kind"`
+	From   string `json:"from,omitempty"`
+	Path   string `json:"path"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// Journal records the steps of a multi-file operation so that it either
+// completes or is rolled back, even across crashes.
+type Journal struct {
+	Op        string `json:"op"`
+	Steps     []Step `json:"steps"`
+	Committed bool   `json:"committed"`
+
+	dir string
+}
+
+// Begin starts a new journaled operation in dir. Only one operation can be
+// active per directory; an existing journal must be recovered first.
+func Begin(dir, op string) (*Journal, error) {
+	jdir := filepath.Join(dir, DirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(jdir, 0755); err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("another operation is in progress or was interrupted in %s", dir)
+		}
+		return nil, err
+	}
+	if err := os.Mkdir(filepath.Join(jdir, backupDir), 0755); err != nil {
+		_ = os.RemoveAll(jdir)
+		return nil, err
+	}
+
+	j := &Journal{Op: op, dir: jdir}
+	if err := j.save(); err != nil {
+		_ = os.RemoveAll(jdir)
+		return nil, err
+	}
+	return j, nil
+}
+
+// Recover finishes or rolls back an operation that was interrupted in dir.
+// It returns the name of the recovered operation, or "" if there was none.
+func Recover(dir string) (string, error) {
+	jdir := filepath.Join(dir, DirName)
+	data, err := os.ReadFile(filepath.Join(jdir, journalFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// A crash between creating the directory and writing the
+			// journal leaves nothing to undo.
+			if _, err := os.Stat(jdir); err == nil {
+				return "", os.RemoveAll(jdir)
+			}
+			return "", nil
+		}
+		return "", err
+	}
+
+	j := &Journal{dir: jdir}
+	if err := json.Unmarshal(data, j); err != nil {
+		return "", fmt.Errorf("corrupt journal in %s: %w", jdir, err)
+	}
+	if j.Committed {
+		return j.Op, j.finish()
+	}
+	return j.Op, j.Rollback()
+}
+
+// Move renames from to path, copying across filesystems if necessary. The
+// destination must not exist yet.
+func (j *Journal) Move(from, path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err := j.record(Step{Kind: StepMove, From: from, Path: path}); err != nil {
+		return err
+	}
+	return Move(from, path)
+}
+
+// WriteFile atomically replaces the contents of path, keeping a backup of the
+// previous contents until the operation is committed.
+func (j *Journal) WriteFile(path string, data []byte, perm os.FileMode) error {
+	step := Step{Kind: StepWrite, Path: path}
+	if _, err := os.Stat(path); err == nil {
+		step.Backup = j.backupPath()
+		if err := copyFile(path, step.Backup); err != nil {
+			return err
+		}
+	}
+	if err := j.record(step); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, perm)
+}
+
+// Remove moves path into the journal. It is deleted for good on commit.
+func (j *Journal) Remove(path string) error {
+	step := Step{Kind: StepRemove, Path: path, Backup: j.backupPath()}
+	if err := j.record(step); err != nil {
+		return err
+	}
+	return Move(path, step.Backup)
+}
+
+// Commit marks the operation as complete and discards all backups.
+func (j *Journal) Commit() error {
+	j.Committed = true
+	if err := j.save(); err != nil {
+		return err
+	}
+	return j.finish()
+}
+
+// Rollback undoes all recorded steps in reverse order and removes the journal.
+func (j *Journal) Rollback() error {
+	var errs []error
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		if err := undo(j.Steps[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		// Keep the journal so that recovery can be attempted again.
+		return fmt.Errorf("rollback of %q incomplete: %w", j.Op, errors.Join(errs...))
+	}
+	return j.finish()
+}
+
+// Abort rolls back a failed operation and returns err, the reason it failed.
+// If the rollback fails as well, err is returned annotated with why; the
+// journal is then kept for Recover.
+func Abort(j *Journal, err error) error {
+	if rbErr := j.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w (%v)", err, rbErr)
+	}
+	return err
+}
+
+func undo(s Step) error {
+	switch s.Kind {
+	case StepMove:
+		if exists(s.From) {
+			// Never moved, or a cross-device copy was interrupted.
+			if exists(s.Path) {
+				return os.RemoveAll(s.Path)
+			}
+			return nil
+		}
+		if exists(s.Path) {
+			return Move(s.Path, s.From)
+		}
+	case StepWrite:
+		_ = os.Remove(s.Path + tmpSuffix)
+		if s.Backup != "" {
+			if exists(s.Backup) {
+				return Move(s.Backup, s.Path)
+			}
+			return nil
+		}
+		if exists(s.Path) {
+			return os.Remove(s.Path)
+		}
+	case StepRemove:
+		if exists(s.Backup) && !exists(s.Path) {
+			return Move(s.Backup, s.Path)
+		}
+	default:
+		return fmt.Errorf("unknown journal step %q", s.Kind)
+	}
+	return nil
+}
+
+func (j *Journal) record(s Step) error {
+	j.Steps = append(j.Steps, s)
+	return j.save()
+}
+
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(j.dir, journalFile), data, 0644)
+}
+
+func (j *Journal) finish() error {
+	return os.RemoveAll(j.dir)
+}
+
+func (j *Journal) backupPath() string {
+	return filepath.Join(j.dir, backupDir, strconv.Itoa(len(j.Steps)))
+}
+
+// WriteFileAtomic writes data to a temporary file next to path, syncs it and
+// renames it into place, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + tmpSuffix
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// Move renames src to dst, falling back to copy and delete if they are on
+// different filesystems.
+func Move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,256 @@
+package journal
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// snapshot returns the contents of the files below dir by relative path,
+// leaving out the journal.
+func snapshot(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == DirName {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// setup creates a directory holding a few files and returns it with its
+// snapshot.
+func setup(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range map[string]string{"a/x": "x", "a/sub/w": "w", "b/y": "y"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, snapshot(t, dir)
+}
+
+// steps are the mutations of the operation the tests interrupt.
+var steps = []func(j *Journal, dir string) error{
+	func(j *Journal, dir string) error { return j.Move(filepath.Join(dir, "a"), filepath.Join(dir, "c")) },
+	func(j *Journal, dir string) error { return j.WriteFile(filepath.Join(dir, "b/y"), []byte("new"), 0644) },
+	func(j *Journal, dir string) error {
+		return j.WriteFile(filepath.Join(dir, "b/z"), []byte("created"), 0644)
+	},
+	func(j *Journal, dir string) error { return j.Remove(filepath.Join(dir, "c/sub")) },
+}
+
+var final = map[string]string{"c/x": "x", "b/y": "new", "b/z": "created"}
+
+func TestRecoverAfterCrash(t *testing.T) {
+	// Crash after each number of completed steps, before committing.
+	for n := 0; n <= len(steps); n++ {
+		dir, orig := setup(t)
+		j, err := Begin(dir, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, step := range steps[:n] {
+			if err := step(j, dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		op, err := Recover(dir)
+		if err != nil || op != "test" {
+			t.Errorf("crash after %d steps: Recover = %q, %v", n, op, err)
+		}
+		if got := snapshot(t, dir); !reflect.DeepEqual(got, orig) {
+			t.Errorf("crash after %d steps: recovered %v, want %v", n, got, orig)
+		}
+		if _, err := os.Stat(filepath.Join(dir, DirName)); !os.IsNotExist(err) {
+			t.Errorf("crash after %d steps: journal left behind", n)
+		}
+	}
+}
+
+func TestRecoverRecordedStepNotPerformed(t *testing.T) {
+	// Steps are recorded before they are performed; a crash in between
+	// leaves steps that never happened, possibly with a temporary file.
+	tests := []struct {
+		name string
+		step func(j *Journal, dir string) Step
+	}{
+		{"move", func(j *Journal, dir string) Step {
+			return Step{Kind: StepMove, From: filepath.Join(dir, "a"), Path: filepath.Join(dir, "c")}
+		}},
+		{"write", func(j *Journal, dir string) Step {
+			path := filepath.Join(dir, "b/y")
+			os.WriteFile(path+tmpSuffix, []byte("partial"), 0644)
+			backup := j.backupPath()
+			if err := copyFile(path, backup); err != nil {
+				t.Fatal(err)
+			}
+			return Step{Kind: StepWrite, Path: path, Backup: backup}
+		}},
+		{"create", func(j *Journal, dir string) Step {
+			return Step{Kind: StepWrite, Path: filepath.Join(dir, "b/z")}
+		}},
+		{"remove", func(j *Journal, dir string) Step {
+			return Step{Kind: StepRemove, Path: filepath.Join(dir, "a/x"), Backup: j.backupPath()}
+		}},
+	}
+	for _, tt := range tests {
+		dir, orig := setup(t)
+		j, err := Begin(dir, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.record(tt.step(j, dir)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Recover(dir); err != nil {
+			t.Errorf("%s: Recover: %v", tt.name, err)
+		}
+		if got := snapshot(t, dir); !reflect.DeepEqual(got, orig) {
+			t.Errorf("%s: recovered %v, want %v", tt.name, got, orig)
+		}
+	}
+}
+
+func TestRecoverCommitted(t *testing.T) {
+	dir, _ := setup(t)
+	j, err := Begin(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range steps {
+		if err := step(j, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Crash after the commit was recorded, before the backups were removed.
+	j.Committed = true
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Recover(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(t, dir); !reflect.DeepEqual(got, final) {
+		t.Errorf("recovered %v, want %v", got, final)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DirName)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind")
+	}
+}
+
+func TestRecoverWithoutJournal(t *testing.T) {
+	dir, orig := setup(t)
+	if op, err := Recover(dir); op != "" || err != nil {
+		t.Errorf("Recover without a journal = %q, %v", op, err)
+	}
+
+	// A crash between creating the journal directory and writing it.
+	if err := os.Mkdir(filepath.Join(dir, DirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if op, err := Recover(dir); op != "" || err != nil {
+		t.Errorf("Recover of an empty journal = %q, %v", op, err)
+	}
+	if got := snapshot(t, dir); !reflect.DeepEqual(got, orig) {
+		t.Errorf("files changed to %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DirName)); !os.IsNotExist(err) {
+		t.Errorf("empty journal left behind")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, DirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, DirName, journalFile), []byte("{"), 0644)
+	if _, err := Recover(dir); err == nil {
+		t.Errorf("Recover of a corrupt journal succeeded")
+	}
+}
+
+func TestCommitAndAbort(t *testing.T) {
+	dir, orig := setup(t)
+	j, err := Begin(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Begin(dir, "other"); err == nil {
+		t.Errorf("Begin succeeded while another operation is active")
+	}
+	for _, step := range steps {
+		if err := step(j, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	failure := errors.New("failure")
+	if err := Abort(j, failure); err != failure {
+		t.Errorf("Abort = %v, want the original error", err)
+	}
+	if got := snapshot(t, dir); !reflect.DeepEqual(got, orig) {
+		t.Errorf("aborted %v, want %v", got, orig)
+	}
+
+	if j, err = Begin(dir, "test"); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range steps {
+		if err := step(j, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot(t, dir); !reflect.DeepEqual(got, final) {
+		t.Errorf("committed %v, want %v", got, final)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DirName)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind")
+	}
+}
+
+func TestMoveExisting(t *testing.T) {
+	dir, orig := setup(t)
+	j, err := Begin(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = j.Move(filepath.Join(dir, "a/x"), filepath.Join(dir, "b/y"))
+	if err == nil || !strings.Contains(err.Error(), "exists") {
+		t.Errorf("Move onto an existing file = %v, want an error", err)
+	}
+	if err := Abort(j, err); err == nil {
+		t.Errorf("Abort lost the error")
+	}
+	if got := snapshot(t, dir); !reflect.DeepEqual(got, orig) {
+		t.Errorf("files changed to %v", got)
+	}
+}
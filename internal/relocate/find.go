@@ -0,0 +1,263 @@
+package relocate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"geminictl/internal/config"
+	"geminictl/internal/gemini"
+)
+
+// Defaults for Options.
+const (
+	DefaultLimit   = 10
+	DefaultMaxDirs = 200000
+)
+
+// minScore is the score a directory needs to be a candidate.
+const minScore = 0.3
+
+// Weights of the signals making up a score. A directory matching on all of
+// them scores 1.
+const (
+	weightName    = 0.3
+	weightRemote  = 0.4
+	weightNames   = 0.25
+	weightSibling = 0.05
+)
+
+// Options tunes Find.
+type Options struct {
+	// Resolve provides the roots searched after the surroundings of the
+	// former location, and the exclude rules.
+	Resolve config.Resolve
+	Limit   int // Candidates returned, DefaultLimit if 0
+	MaxDirs int // Directories read before giving up, DefaultMaxDirs if 0
+}
+
+// Candidate is a directory that may be the new location of a project.
+type Candidate struct {
+	Path    string
+	Score   float64  // 0 to 1
+	Reasons []string // Why the directory resembles the project
+}
+
+// Result is the outcome of Find.
+type Result struct {
+	Candidates []Candidate // Best first
+	Dirs       int         // Directories read
+	Complete   bool        // Whether every directory was searched
+}
+
+// Find searches for directories resembling the fingerprint of a project whose
+// directory at oldPath is gone: directories with the same name, the same git
+// remote or the same top-level files. The surroundings of the former location
+// are searched first, as renamed projects usually stay close to where they
+// were, then the configured roots. The search stops when ctx is done or
+// MaxDirs directories have been read; the candidates found so far are
+// returned.
+func Find(ctx context.Context, oldPath string, fp Fingerprint, opts Options) Result {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	if opts.MaxDirs <= 0 {
+		opts.MaxDirs = DefaultMaxDirs
+	}
+	if fp.Name == "" {
+		fp.Name = filepath.Base(oldPath)
+	}
+
+	f := &finder{
+		ctx:    ctx,
+		old:    filepath.Clean(oldPath),
+		fp:     fp,
+		names:  make(map[string]bool, len(fp.Names)),
+		skip:   opts.Resolve.Matcher(),
+		max:    opts.MaxDirs,
+		scored: make(map[string]bool),
+	}
+	for _, n := range fp.Names {
+		f.names[n] = true
+	}
+
+	// The parent and grandparent of the former location catch renamed
+	// siblings and projects moved into a neighbouring directory.
+	parent := filepath.Dir(f.old)
+	f.walk(parent, 2)
+	if grand := filepath.Dir(parent); grand != parent {
+		f.walk(grand, 3)
+	}
+	for _, r := range opts.Resolve.Roots {
+		f.walk(r.Path, r.MaxDepth)
+	}
+
+	sort.Slice(f.found, func(i, j int) bool {
+		if f.found[i].Score != f.found[j].Score {
+			return f.found[i].Score > f.found[j].Score
+		}
+		return f.found[i].Path < f.found[j].Path
+	})
+	if len(f.found) > opts.Limit {
+		f.found = f.found[:opts.Limit]
+	}
+	return Result{Candidates: f.found, Dirs: f.dirs, Complete: !f.stopped()}
+}
+
+// finder is the state of one Find call.
+type finder struct {
+	ctx    context.Context
+	old    string
+	fp     Fingerprint
+	names  map[string]bool
+	skip   *config.Matcher
+	max    int
+	dirs   int
+	scored map[string]bool
+	found  []Candidate
+}
+
+func (f *finder) stopped() bool {
+	return f.ctx.Err() != nil || f.dirs >= f.max
+}
+
+// walk reads the directories below root breadth-first, down to maxDepth
+// levels (0 for no limit), and scores each of them.
+func (f *finder) walk(root string, maxDepth int) {
+	type item struct {
+		path  string
+		depth int
+	}
+	queue := []item{{root, 0}}
+	for len(queue) > 0 && !f.stopped() {
+		it := queue[0]
+		queue = queue[1:]
+
+		entries, err := os.ReadDir(it.path)
+		if err != nil {
+			continue
+		}
+		f.dirs++
+		f.score(it.path, entries)
+
+		if maxDepth > 0 && it.depth >= maxDepth {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			child := filepath.Join(it.path, e.Name())
+			if !f.skip.Skip(child) {
+				queue = append(queue, item{child, it.depth + 1})
+			}
+		}
+	}
+}
+
+// score rates how much a directory resembles the fingerprint and records it
+// as a candidate if it resembles it enough.
+func (f *finder) score(dir string, entries []os.DirEntry) {
+	if f.scored[dir] || dir == f.old {
+		return
+	}
+	f.scored[dir] = true
+
+	var c Candidate
+	name := filepath.Base(dir)
+	switch {
+	case name == f.fp.Name:
+		c.Score += weightName
+		c.Reasons = append(c.Reasons, "same name")
+	case normalizeName(name) == normalizeName(f.fp.Name):
+		c.Score += weightName * 0.8
+		c.Reasons = append(c.Reasons, "similar name")
+	}
+
+	if f.fp.Remote != "" {
+		if remote := gitRemote(dir, entries); remote == f.fp.Remote {
+			c.Score += weightRemote
+			c.Reasons = append(c.Reasons, "same git remote")
+		}
+	}
+
+	if len(f.names) > 0 {
+		common, total := 0, len(f.names)
+		for _, n := range topLevelNames(entries) {
+			if f.names[n] {
+				common++
+			} else {
+				total++
+			}
+		}
+		if common > 0 {
+			c.Score += weightNames * float64(common) / float64(total)
+			c.Reasons = append(c.Reasons, plural(common, "top-level file")+" in common")
+		}
+	}
+
+	if c.Score > 0 && filepath.Dir(dir) == filepath.Dir(f.old) {
+		c.Score += weightSibling
+		c.Reasons = append(c.Reasons, "next to the former location")
+	}
+
+	if c.Score >= minScore {
+		c.Path = dir
+		f.found = append(f.found, c)
+	}
+}
+
+// normalizeName folds case and drops separators, so that "my-project",
+// "my_project" and "MyProject" compare equal.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', '.', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// NamesFromSessions returns the top-level names below oldPath mentioned in a
+// project's sessions and logs. It stands in for the fingerprint of projects
+// whose directory vanished before one was taken.
+func NamesFromSessions(rootDir, projectID, oldPath string) []string {
+	prefix := regexp.QuoteMeta(filepath.Clean(oldPath) + "/")
+	re, err := regexp.Compile(prefix + `([\w.+@%-]+)`)
+	if err != nil {
+		return nil
+	}
+
+	files, _ := gemini.ListSessionFiles(rootDir, projectID)
+	files = append(files, gemini.LogsPath(rootDir, projectID))
+	seen := make(map[string]bool)
+	var names []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil || !bytes.Contains(data, []byte(oldPath)) {
+			continue
+		}
+		for _, m := range re.FindAllSubmatch(data, -1) {
+			name := string(m[1])
+			if !seen[name] && len(names) < maxNames {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
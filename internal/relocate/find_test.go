@@ -0,0 +1,89 @@
+package relocate
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"geminictl/internal/config"
+	"geminictl/internal/gemini"
+)
+
+func TestFind(t *testing.T) {
+	base := t.TempDir()
+	far := t.TempDir()
+	old := filepath.Join(base, "work", "myproject")
+	fp := Fingerprint{Name: "myproject", Remote: "github.com/u/p", Names: []string{"README.md", "go.mod", "main.go"}}
+
+	project(t, filepath.Join(base, "work", "My_Project"), "", "README.md", "go.mod", "main.go")
+	project(t, filepath.Join(base, "archive", "2025", "renamed"), "https://github.com/u/p.git", "README.md", "go.mod", "main.go")
+	project(t, filepath.Join(base, "work", "unrelated"), "", "main.go", "package.json")
+	project(t, filepath.Join(base, "work", "node_modules", "myproject"), "", "README.md", "go.mod", "main.go")
+	project(t, filepath.Join(far, "src", "myproject"), "", "go.mod")
+
+	paths := func(res Result) []string {
+		var out []string
+		for _, c := range res.Candidates {
+			out = append(out, strings.TrimPrefix(strings.TrimPrefix(c.Path, base), far))
+		}
+		return out
+	}
+	resolve := config.Resolve{
+		Roots:   []config.Root{{Path: far, MaxDepth: 3}},
+		Exclude: []string{"node_modules"},
+	}
+	tests := []struct {
+		name     string
+		opts     Options
+		want     []string
+		complete bool
+	}{
+		{"surroundings", Options{Resolve: config.Resolve{Exclude: resolve.Exclude}}, []string{"/archive/2025/renamed", "/work/My_Project"}, true},
+		{"roots", Options{Resolve: resolve}, []string{"/archive/2025/renamed", "/work/My_Project", "/src/myproject"}, true},
+		{"limit", Options{Resolve: resolve, Limit: 1}, []string{"/archive/2025/renamed"}, true},
+		{"max dirs", Options{Resolve: resolve, MaxDirs: 2}, []string{"/work/My_Project"}, false},
+	}
+	for _, tt := range tests {
+		res := Find(context.Background(), old, fp, tt.opts)
+		if got := paths(res); !reflect.DeepEqual(got, tt.want) || res.Complete != tt.complete {
+			t.Errorf("%s: Find = %q, complete %v; want %q, %v", tt.name, got, res.Complete, tt.want, tt.complete)
+		}
+	}
+
+	res := Find(context.Background(), old, fp, Options{Resolve: resolve})
+	if c := res.Candidates[1]; !reflect.DeepEqual(c.Reasons, []string{"similar name", "3 top-level files in common", "next to the former location"}) {
+		t.Errorf("reasons for %s = %q", c.Path, c.Reasons)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res := Find(ctx, old, fp, Options{Resolve: resolve}); res.Complete || len(res.Candidates) != 0 {
+		t.Errorf("Find after cancellation = %+v", res)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for _, name := range []string{"my-project", "my_project", "MyProject", "my.project", "My Project"} {
+		if got := normalizeName(name); got != "myproject" {
+			t.Errorf("normalizeName(%q) = %q", name, got)
+		}
+	}
+}
+
+func TestNamesFromSessions(t *testing.T) {
+	root := t.TempDir()
+	id := gemini.HashPath("/old/proj")
+	writeFile(t, filepath.Join(root, id, gemini.SessionDir, "session-2025-01-01T10-00-s1.json"),
+		`{"sessionId":"s1","messages":[{"content":"edit /old/proj/main.go and /old/proj/src/x.go"}]}`)
+	writeFile(t, gemini.LogsPath(root, id),
+		`[{"message":"see /old/proj/README.md, not /old/project2/other"}]`)
+
+	if got, want := NamesFromSessions(root, id, "/old/proj"), []string{"README.md", "main.go", "src"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NamesFromSessions = %q, want %q", got, want)
+	}
+	if got := NamesFromSessions(root, gemini.HashPath("/none"), "/none"); got != nil {
+		t.Errorf("NamesFromSessions without sessions = %q", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package relocate finds where the directory of an orphaned project went.
+//
+// While a project's directory exists, a fingerprint of it is recorded: its
+// top-level file names and git remote. Once the directory is gone, Find
+// searches the filesystem for directories resembling it and ranks them.
+package relocate
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/journal"
+)
+
+// FingerprintFile is the name of the fingerprint store in geminictl's state
+// directory.
+const FingerprintFile = "fingerprints.json"
+
+// MaxAge is how long a fingerprint is kept before it is taken again.
+const MaxAge = 24 * time.Hour
+
+// maxNames caps the number of top-level names in a fingerprint.
+const maxNames = 256
+
+// Fingerprint describes a project directory well enough to recognise it after
+// it was moved or renamed.
+type Fingerprint struct {
+	Name    string    `json:"name"`             // Leaf name of the directory
+	Remote  string    `json:"remote,omitempty"` // Normalised URL of the origin remote
+	Names   []string  `json:"names"`            // Sorted top-level file and directory names
+	TakenAt time.Time `json:"takenAt"`
+}
+
+// Take fingerprints the directory at path.
+func Take(path string) (Fingerprint, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	return Fingerprint{
+		Name:    filepath.Base(path),
+		Remote:  gitRemote(path, entries),
+		Names:   topLevelNames(entries),
+		TakenAt: time.Now(),
+	}, nil
+}
+
+// topLevelNames returns the sorted names of entries, leaving out those that
+// say nothing about a project.
+func topLevelNames(entries []os.DirEntry) []string {
+	var names []string
+	for _, e := range entries {
+		switch e.Name() {
+		case ".git", ".DS_Store":
+			continue
+		}
+		names = append(names, e.Name())
+		if len(names) == maxNames {
+			break
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// gitRemote returns the normalised URL of the origin remote of the repository
+// at dir, or of its first remote, or "" if dir is not a repository.
+func gitRemote(dir string, entries []os.DirEntry) string {
+	hasGit := false
+	for _, e := range entries {
+		if e.Name() == ".git" && e.IsDir() {
+			hasGit = true
+			break
+		}
+	}
+	if !hasGit {
+		return ""
+	}
+	f, err := os.Open(filepath.Join(dir, ".git", "config"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var remote, first string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") {
+			remote = ""
+			if name, ok := strings.CutPrefix(line, "[remote \""); ok {
+				remote = strings.TrimSuffix(name, "\"]")
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if remote == "" || !ok || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		url := NormalizeRemote(strings.TrimSpace(value))
+		if remote == "origin" {
+			return url
+		}
+		if first == "" {
+			first = url
+		}
+	}
+	return first
+}
+
+// NormalizeRemote reduces the forms of a git remote URL to host/path, so that
+// the SSH and HTTPS URLs of a repository compare equal.
+func NormalizeRemote(url string) string {
+	url = strings.TrimSpace(url)
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if at := strings.Index(url, "@"); at >= 0 {
+		// scp-like syntax: git@host:path
+		url = strings.Replace(url[at+1:], ":", "/", 1)
+	}
+	if at := strings.Index(url, "@"); at >= 0 && at < strings.Index(url+"/", "/") {
+		url = url[at+1:] // User info
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	return strings.ToLower(url)
+}
+
+// Store keeps the fingerprints of projects by project ID.
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	prints   map[string]Fingerprint
+	modified bool
+}
+
+// OpenStore reads the fingerprint store from geminictl's state directory (see
+// cache.Dir). A missing or corrupt store yields an empty one.
+func OpenStore(baseDir string) (*Store, error) {
+	dir, err := cache.Dir(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		path:   filepath.Join(dir, FingerprintFile),
+		prints: make(map[string]Fingerprint),
+	}
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.prints); err != nil || s.prints == nil {
+			s.prints = make(map[string]Fingerprint)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the fingerprint of a project.
+func (s *Store) Get(id string) (Fingerprint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fp, ok := s.prints[id]
+	return fp, ok
+}
+
+// Refresh fingerprints the directory of a project if it has no fingerprint
+// or its fingerprint is older than MaxAge. Directories that cannot be read
+// keep their previous fingerprint.
+func (s *Store) Refresh(id, path string) {
+	s.mu.Lock()
+	fp, ok := s.prints[id]
+	s.mu.Unlock()
+	if ok && time.Since(fp.TakenAt) < MaxAge {
+		return
+	}
+	fp, err := Take(path)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.prints[id] = fp
+	s.modified = true
+	s.mu.Unlock()
+}
+
+// Prune drops the fingerprints of projects that no longer exist.
+func (s *Store) Prune(active map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.prints {
+		if !active[id] {
+			delete(s.prints, id)
+			s.modified = true
+		}
+	}
+}
+
+// Save writes the store if it changed.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.modified {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.prints, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	if err := journal.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return err
+	}
+	s.modified = false
+	return nil
+}
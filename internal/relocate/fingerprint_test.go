@@ -0,0 +1,123 @@
+package relocate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeFile writes a file, creating its directory.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// project creates a project directory with the given top-level files and,
+// if remote is not empty, a git repository whose origin is remote.
+func project(t *testing.T, dir, remote string, names ...string) {
+	t.Helper()
+	for _, n := range names {
+		writeFile(t, filepath.Join(dir, n), "")
+	}
+	if remote != "" {
+		writeFile(t, filepath.Join(dir, ".git", "config"),
+			"[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = https://example.com/fork.git\n[remote \"origin\"]\n\turl = "+remote+"\n")
+	}
+}
+
+func TestNormalizeRemote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://github.com/User/Repo.git", "github.com/user/repo"},
+		{"git@github.com:User/Repo.git", "github.com/user/repo"},
+		{"ssh://git@github.com/User/Repo", "github.com/user/repo"},
+		{"https://token@github.com/user/repo/", "github.com/user/repo"},
+		{"/srv/git/repo.git", "/srv/git/repo"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRemote(tt.in); got != tt.want {
+			t.Errorf("NormalizeRemote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "api")
+	project(t, dir, "git@github.com:u/api.git", "main.go", ".DS_Store", "README.md")
+	fp, err := Take(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp.Name != "api" || fp.Remote != "github.com/u/api" || fp.TakenAt.IsZero() {
+		t.Errorf("Take = %+v", fp)
+	}
+	if want := []string{"README.md", "main.go"}; !reflect.DeepEqual(fp.Names, want) {
+		t.Errorf("Names = %q, want %q", fp.Names, want)
+	}
+
+	// The first remote stands in for a missing origin.
+	other := filepath.Join(t.TempDir(), "other")
+	writeFile(t, filepath.Join(other, ".git", "config"), "[remote \"fork\"]\n\turl = https://example.com/fork\n")
+	if fp, _ := Take(other); fp.Remote != "example.com/fork" {
+		t.Errorf("Remote without origin = %q", fp.Remote)
+	}
+	if _, err := Take(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Take of a missing directory succeeded")
+	}
+}
+
+func TestStore(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "api")
+	project(t, dir, "", "main.go")
+
+	s, err := OpenStore(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Refresh("p1", dir)
+	s.Refresh("p2", dir)
+	s.Refresh("gone", filepath.Join(dir, "missing"))
+	if _, ok := s.Get("gone"); ok {
+		t.Errorf("fingerprint of an unreadable directory recorded")
+	}
+	s.Prune(map[string]bool{"p1": true})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ = OpenStore(base)
+	fp, ok := s.Get("p1")
+	if !ok || fp.Name != "api" || !reflect.DeepEqual(fp.Names, []string{"main.go"}) {
+		t.Errorf("Get(p1) = %+v, %v", fp, ok)
+	}
+	if _, ok := s.Get("p2"); ok {
+		t.Errorf("pruned fingerprint kept")
+	}
+
+	// Recent fingerprints are kept, outdated ones taken again.
+	writeFile(t, filepath.Join(dir, "go.mod"), "")
+	s.Refresh("p1", dir)
+	if fp, _ := s.Get("p1"); len(fp.Names) != 1 {
+		t.Errorf("recent fingerprint taken again: %+v", fp)
+	}
+	s.prints["p1"] = Fingerprint{TakenAt: time.Now().Add(-2 * MaxAge)}
+	s.Refresh("p1", dir)
+	if fp, _ := s.Get("p1"); len(fp.Names) != 2 {
+		t.Errorf("outdated fingerprint kept: %+v", fp)
+	}
+
+	// A corrupt store is replaced by an empty one.
+	writeFile(t, s.path, "{")
+	if s, err := OpenStore(base); err != nil || len(s.prints) != 0 {
+		t.Errorf("OpenStore of a corrupt store = %v, %v", s.prints, err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package scanner
+
+import "os"
+
+// deviceID is not supported on this platform, so filesystem boundaries are
+// never detected.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
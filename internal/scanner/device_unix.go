@@ -0,0 +1,17 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceID returns the ID of the filesystem a file is on.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
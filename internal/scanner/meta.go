@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+)
+
+// MetaFile is the name of the metadata index in geminictl's state directory.
+const MetaFile = "metadata.json"
+
+// metaVersion is bumped whenever the cached metadata changes shape; older
+// indexes are discarded.
+const metaVersion = 2
+
+// metaEntry is the cached metadata of a session file, valid as long as the
+// file's size and modification time are unchanged.
+type metaEntry struct {
+	Size    int64              `json:"size"`
+	ModTime time.Time          `json:"modTime"`
+	Meta    gemini.SessionMeta `json:"meta"`
+}
+
+// fileStamp identifies the version of a file.
+type fileStamp struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// mergedEntry is the cached metadata of a session split across several
+// files, valid as long as the same files are unchanged.
+type mergedEntry struct {
+	Files []fileStamp        `json:"files"`
+	Meta  gemini.SessionMeta `json:"meta"`
+}
+
+// metaIndex caches session file metadata by path so that scans only parse
+// files that changed since the previous scan. The metadata of sessions
+// split across files is cached by project and session ID.
+type metaIndex struct {
+	mu       sync.Mutex
+	path     string
+	files    map[string]metaEntry
+	merged   map[string]mergedEntry
+	modified bool
+}
+
+type metaIndexFile struct {
+	Version int                    `json:"version"`
+	Files   map[string]metaEntry   `json:"files"`
+	Merged  map[string]mergedEntry `json:"merged,omitempty"`
+}
+
+// loadMetaIndex reads the index from dir. A missing, outdated or corrupt
+// index yields an empty one.
+func loadMetaIndex(dir string) *metaIndex {
+	idx := &metaIndex{
+		path:   filepath.Join(dir, MetaFile),
+		files:  make(map[string]metaEntry),
+		merged: make(map[string]mergedEntry),
+	}
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return idx
+	}
+	var f metaIndexFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != metaVersion || f.Files == nil {
+		idx.modified = true
+		return idx
+	}
+	idx.files = f.Files
+	if f.Merged != nil {
+		idx.merged = f.Merged
+	}
+	return idx
+}
+
+// get returns the metadata of a session file, reading it only if the file
+// changed since it was cached. A nil index reads every file.
+func (idx *metaIndex) get(path string) (gemini.SessionMeta, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return gemini.SessionMeta{}, time.Time{}, err
+	}
+	if idx == nil {
+		meta, err := gemini.ReadSessionMeta(path)
+		return meta, info.ModTime(), err
+	}
+
+	idx.mu.Lock()
+	e, ok := idx.files[path]
+	idx.mu.Unlock()
+	if ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+		return e.Meta, info.ModTime(), nil
+	}
+
+	meta, err := gemini.ReadSessionMeta(path)
+	if err != nil {
+		return gemini.SessionMeta{}, time.Time{}, err
+	}
+
+	idx.mu.Lock()
+	idx.files[path] = metaEntry{Size: info.Size(), ModTime: info.ModTime(), Meta: meta}
+	idx.modified = true
+	idx.mu.Unlock()
+	return meta, info.ModTime(), nil
+}
+
+// getMerged returns the metadata of a session split across the files at
+// paths, merging them with gemini.MergeSessions unless they are unchanged
+// since they were cached under key. A nil index reads every file.
+func (idx *metaIndex) getMerged(key string, paths []string) (gemini.SessionMeta, time.Time, error) {
+	stamps := make([]fileStamp, len(paths))
+	var modTime time.Time
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return gemini.SessionMeta{}, time.Time{}, err
+		}
+		stamps[i] = fileStamp{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	if idx != nil {
+		idx.mu.Lock()
+		e, ok := idx.merged[key]
+		idx.mu.Unlock()
+		if ok && slices.EqualFunc(e.Files, stamps, func(a, b fileStamp) bool {
+			return a.Path == b.Path && a.Size == b.Size && a.ModTime.Equal(b.ModTime)
+		}) {
+			return e.Meta, modTime, nil
+		}
+	}
+
+	files := make([]gemini.Session, len(paths))
+	for i, path := range paths {
+		s, err := gemini.ReadSessionFile(path)
+		if err != nil {
+			return gemini.SessionMeta{}, time.Time{}, err
+		}
+		files[i] = s
+	}
+	meta := gemini.SummarizeSession(gemini.MergeSessions(files))
+
+	if idx != nil {
+		idx.mu.Lock()
+		idx.merged[key] = mergedEntry{Files: stamps, Meta: meta}
+		idx.modified = true
+		idx.mu.Unlock()
+	}
+	return meta, modTime, nil
+}
+
+// prune drops the entries of files that were not seen by a full scan.
+func (idx *metaIndex) prune(seen map[string]bool) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for path := range idx.files {
+		if !seen[path] {
+			delete(idx.files, path)
+			idx.modified = true
+		}
+	}
+	for key, e := range idx.merged {
+		for _, f := range e.Files {
+			if !seen[f.Path] {
+				delete(idx.merged, key)
+				idx.modified = true
+				break
+			}
+		}
+	}
+}
+
+// save writes the index if it changed.
+func (idx *metaIndex) save() error {
+	if idx == nil {
+		return nil
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.modified {
+		return nil
+	}
+
+	data, err := json.Marshal(metaIndexFile{Version: metaVersion, Files: idx.files, Merged: idx.merged})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	if err := journal.WriteFileAtomic(idx.path, data, 0644); err != nil {
+		return err
+	}
+	idx.modified = false
+	return nil
+}
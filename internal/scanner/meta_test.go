@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSessionFile writes a session file with the given number of user
+// messages and sets its modification time.
+func writeSessionFile(t *testing.T, path string, messages int, modTime time.Time) {
+	t.Helper()
+	data := `{"sessionId":"s","messages":[`
+	for i := 0; i < messages; i++ {
+		if i > 0 {
+			data += ","
+		}
+		data += `{"type":"user","content":"x"}`
+	}
+	data += `]}`
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetaIndex(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	stamp := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	writeSessionFile(t, a, 1, stamp)
+	writeSessionFile(t, b, 2, stamp)
+
+	idx := loadMetaIndex(dir)
+	count := func(path string) int {
+		t.Helper()
+		meta, _, err := idx.get(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return meta.MessageCount
+	}
+	if count(a) != 1 || count(b) != 2 {
+		t.Fatalf("counts %d and %d, want 1 and 2", count(a), count(b))
+	}
+	if err := idx.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx = loadMetaIndex(dir)
+	if idx.modified || len(idx.files) != 2 {
+		t.Fatalf("reloaded index holds %d files, modified %v", len(idx.files), idx.modified)
+	}
+
+	// Same size and time: the cached metadata is used without reading.
+	os.WriteFile(a, []byte(`{"sessionId":"s","messages":[{"type":"user","content":"y"}]}`), 0644)
+	os.Chtimes(a, stamp, stamp)
+	if got := count(a); got != 1 || idx.modified {
+		t.Errorf("unchanged file re-read: count %d, modified %v", got, idx.modified)
+	}
+	// A different time or size invalidates the entry.
+	writeSessionFile(t, a, 3, stamp.Add(time.Second))
+	if got := count(a); got != 3 || !idx.modified {
+		t.Errorf("changed file: count %d, modified %v; want 3, true", got, idx.modified)
+	}
+
+	idx.prune(map[string]bool{a: true})
+	if _, ok := idx.files[b]; ok {
+		t.Errorf("prune kept an unseen file")
+	}
+
+	// Merged sessions are cached as long as all their files are unchanged.
+	meta, _, err := idx.getMerged("s", []string{a, b})
+	if err != nil || meta.MessageCount != 5 {
+		t.Fatalf("getMerged = %+v, %v", meta, err)
+	}
+	writeSessionFile(t, b, 4, stamp.Add(time.Second))
+	if meta, _, _ := idx.getMerged("s", []string{a, b}); meta.MessageCount != 7 {
+		t.Errorf("getMerged after a change counted %d messages, want 7", meta.MessageCount)
+	}
+	idx.prune(map[string]bool{a: true})
+	if _, ok := idx.merged["s"]; ok {
+		t.Errorf("prune kept a merged session with an unseen file")
+	}
+}
+
+func TestLoadMetaIndexDiscardsOutdated(t *testing.T) {
+	for _, data := range []string{`{`, `{"version":1,"files":{"x":{}}}`, `{"version":2}`} {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, MetaFile), []byte(data), 0644)
+		if idx := loadMetaIndex(dir); len(idx.files) != 0 || !idx.modified {
+			t.Errorf("loadMetaIndex(%s) kept %d files, modified %v", data, len(idx.files), idx.modified)
+		}
+	}
+
+	var idx *metaIndex // A nil index reads every file.
+	path := filepath.Join(t.TempDir(), "s.json")
+	writeSessionFile(t, path, 2, time.Now())
+	if meta, _, err := idx.get(path); err != nil || meta.MessageCount != 2 {
+		t.Errorf("nil index: get = %+v, %v", meta, err)
+	}
+	if err := idx.save(); err != nil {
+		t.Errorf("nil index: save: %v", err)
+	}
+}
@@ -0,0 +1,448 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"geminictl/internal/config"
+	"geminictl/internal/gemini"
+	"geminictl/internal/variant"
+)
+
+// Defaults for ResolveOptions.
+const (
+	DefaultWorkers          = 8
+	DefaultProgressInterval = 200 * time.Millisecond
+)
+
+// ResolveOptions tunes the resolver. The roots, candidate sources, exclude
+// rules, budget and number of workers come from the configuration; without
+// roots, the roots, sources and filesystem settings of the default
+// configuration are used.
+type ResolveOptions struct {
+	config.Resolve
+	ProgressInterval time.Duration // DefaultProgressInterval if 0
+
+	// KnownPaths are the paths of projects resolved earlier, from which
+	// candidate sources derive related directories such as git worktrees.
+	KnownPaths []string
+}
+
+// Progress reports the state of a running resolution.
+type Progress struct {
+	Source      string // Candidate source being checked, empty while walking
+	Tier        string // Root directory of the current tier
+	TierIndex   int    // 1-based
+	Tiers       int
+	DirsScanned int
+	Remaining   int // Projects not resolved yet
+	Elapsed     time.Duration
+	ETA         time.Duration // Estimated time left in the current tier, 0 if unknown
+}
+
+// ResolveEvent is either a resolved project or a progress update.
+type ResolveEvent struct {
+	Resolution *Resolution
+	Progress   *Progress
+}
+
+// Resolve searches the filesystem for the directories whose hashes are the
+// given project IDs. The paths harvested by the candidate sources are hashed
+// first; the roots are only walked for projects still unresolved. Candidates
+// need not exist, so a project may resolve to a directory that was moved or
+// deleted, which still tells where it used to be.
+//
+// Resolved projects and periodic progress updates are sent on the returned
+// channel, which is closed once all projects are resolved, all tiers are
+// walked, the budget is exhausted or ctx is canceled.
+func (s *Scanner) Resolve(ctx context.Context, unknownIDs []string, opts ResolveOptions) <-chan ResolveEvent {
+	if len(opts.Roots) == 0 {
+		def := config.Default().Resolve
+		def.Budget, def.Workers = opts.Budget, opts.Workers
+		opts.Resolve = def
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = DefaultProgressInterval
+	}
+
+	out := make(chan ResolveEvent)
+	r := &resolver{
+		opts:    opts,
+		skip:    opts.Matcher(),
+		mounts:  variant.NewTable(opts.Mounts),
+		out:     out,
+		targets: make(map[string]bool),
+		visited: make(map[string]bool),
+		checked: make(map[string]bool),
+		start:   time.Now(),
+	}
+	for _, id := range unknownIDs {
+		r.targets[id] = true
+	}
+	r.cond = sync.NewCond(&r.mu)
+
+	go func() {
+		defer close(out)
+		if len(r.targets) == 0 {
+			return
+		}
+
+		if opts.Budget > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.Budget)
+			defer cancel()
+		}
+		r.ctx = ctx
+
+		// The reporter must be gone before out is closed.
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.reportProgress(done)
+		}()
+		defer wg.Wait()
+		defer close(done)
+
+		r.checkSources(s.RootDir, sourcesByName(opts.Sources))
+
+		// Roots are walked in order; directories walked by an earlier root
+		// are skipped by later ones.
+		for i, root := range opts.Roots {
+			if ctx.Err() != nil || r.remaining() == 0 {
+				return
+			}
+			r.walkTier(root, i+1, len(opts.Roots))
+		}
+	}()
+	return out
+}
+
+// resolver is the shared state of one Resolve call.
+type resolver struct {
+	ctx   context.Context
+	opts  ResolveOptions
+	skip  *config.Matcher
+	out   chan<- ResolveEvent
+	start time.Time
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	targets map[string]bool
+	visited map[string]bool
+	checked map[string]bool // Candidates hashed already
+	dirs    int
+
+	// mounts holds the configured mounts and the symlinks to directories
+	// seen so far. Directories are matched under both sides of each.
+	mounts *variant.Table
+
+	source string // Candidate source being checked
+
+	// State of the current tier
+	tier      string
+	tierIndex int
+	tierCount int
+	tierStart time.Time
+	maxDepth  int
+	device    uint64 // Filesystem of the root, if known
+	hasDevice bool
+	queue     []item
+	active    int
+	subtrees  []int // Pending directories per top-level subtree
+	done      int   // Finished top-level subtrees
+}
+
+// item is a directory waiting to be read, tagged with its depth below the
+// root and the top-level subtree of the root it belongs to (-1 for the root
+// itself).
+type item struct {
+	path  string
+	depth int
+	top   int
+}
+
+func (r *resolver) remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.targets)
+}
+
+// walkTier reads all directories below a root with a pool of workers.
+func (r *resolver) walkTier(root config.Root, index, count int) {
+	r.mu.Lock()
+	if r.visited[root.Path] {
+		r.mu.Unlock()
+		return
+	}
+	info, err := os.Stat(root.Path)
+	if err != nil || !info.IsDir() {
+		r.mu.Unlock()
+		return
+	}
+	r.visited[root.Path] = true
+	if real, err := filepath.EvalSymlinks(root.Path); err == nil && real != root.Path {
+		r.mounts.AddSymlink(real, root.Path)
+	}
+	r.tier, r.tierIndex, r.tierCount = root.Path, index, count
+	r.tierStart = time.Now()
+	r.maxDepth = root.MaxDepth
+	r.device, r.hasDevice = deviceID(info)
+	r.subtrees, r.done = nil, 0
+	r.queue = []item{{path: root.Path, top: -1}}
+	r.mu.Unlock()
+
+	r.check(root.Path, SourceWalk)
+
+	// Wake up waiting workers when the walk is canceled.
+	stop := context.AfterFunc(r.ctx, func() {
+		r.mu.Lock()
+		r.cond.Broadcast()
+		r.mu.Unlock()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work()
+		}()
+	}
+	wg.Wait()
+}
+
+// work processes queued directories until the tier is exhausted, every
+// target is resolved or the context is done.
+func (r *resolver) work() {
+	for {
+		r.mu.Lock()
+		for len(r.queue) == 0 && r.active > 0 && r.ctx.Err() == nil && len(r.targets) > 0 {
+			r.cond.Wait()
+		}
+		if len(r.queue) == 0 || r.ctx.Err() != nil || len(r.targets) == 0 {
+			r.cond.Broadcast()
+			r.mu.Unlock()
+			return
+		}
+		it := r.queue[len(r.queue)-1]
+		r.queue = r.queue[:len(r.queue)-1]
+		r.active++
+		r.mu.Unlock()
+
+		var children, links []string
+		if r.maxDepth == 0 || it.depth < r.maxDepth {
+			children, links = r.readDir(it.path)
+		}
+
+		r.mu.Lock()
+		r.dirs++
+		for _, child := range children {
+			if r.visited[child] {
+				continue
+			}
+			r.visited[child] = true
+			top := it.top
+			if top < 0 {
+				top = len(r.subtrees)
+				r.subtrees = append(r.subtrees, 0)
+			}
+			r.subtrees[top]++
+			r.queue = append(r.queue, item{path: child, depth: it.depth + 1, top: top})
+		}
+		if it.top >= 0 {
+			r.subtrees[it.top]--
+			if r.subtrees[it.top] == 0 {
+				r.done++
+			}
+		}
+		r.active--
+		r.cond.Broadcast()
+		r.mu.Unlock()
+
+		for _, child := range children {
+			r.check(child, SourceWalk)
+		}
+		for _, link := range links {
+			r.check(link, SourceWalk)
+		}
+	}
+}
+
+// readDir returns the subdirectories of dir worth descending into, and the
+// symlinks to directories in it. Symlinks are not followed; they are recorded
+// as mounts instead, so that the directories they point to are also matched
+// under the symlink's path.
+func (r *resolver) readDir(dir string) (dirs, links []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.Type()&os.ModeSymlink != 0 {
+			if !r.skip.Skip(path) && r.addLink(path) {
+				links = append(links, path)
+			}
+			continue
+		}
+		if !e.IsDir() || r.skip.Skip(path) {
+			continue
+		}
+		if !r.opts.CrossFilesystems && r.hasDevice {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			if dev, ok := deviceID(info); ok && dev != r.device {
+				continue
+			}
+		}
+		dirs = append(dirs, path)
+	}
+	return dirs, links
+}
+
+// addLink records a symlink to a directory in the mount table. It reports
+// whether the symlink points to a directory.
+func (r *resolver) addLink(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if info, err := os.Stat(real); err != nil || !info.IsDir() {
+		return false
+	}
+	r.mounts.AddSymlink(real, path)
+	return true
+}
+
+// checkSources hashes the candidates of each source in turn until every
+// target is resolved.
+func (r *resolver) checkSources(rootDir string, sources []CandidateSource) {
+	in := SourceInput{
+		RootDir: rootDir,
+		Known:   append([]string(nil), r.opts.KnownPaths...),
+	}
+	for _, src := range sources {
+		r.mu.Lock()
+		r.source = src.Name()
+		in.Targets = in.Targets[:0]
+		for id := range r.targets {
+			in.Targets = append(in.Targets, id)
+		}
+		r.mu.Unlock()
+		if r.ctx.Err() != nil || len(in.Targets) == 0 {
+			break
+		}
+
+		for _, dir := range src.Candidates(r.ctx, in) {
+			if r.ctx.Err() != nil || r.remaining() == 0 {
+				break
+			}
+			dir = filepath.Clean(dir)
+			if r.checked[dir] {
+				continue
+			}
+			r.checked[dir] = true
+			in.Known = append(in.Known, dir)
+			r.check(dir, src.Name())
+		}
+	}
+	r.mu.Lock()
+	r.source = ""
+	r.mu.Unlock()
+}
+
+// check hashes the variants of a directory and reports it if one of them is
+// one of the targets.
+func (r *resolver) check(dir, source string) {
+	for _, v := range r.mounts.Lexical(dir) {
+		h := gemini.HashPath(v.Path)
+		r.mu.Lock()
+		found := r.targets[h]
+		delete(r.targets, h)
+		if len(r.targets) == 0 {
+			r.cond.Broadcast()
+		}
+		r.mu.Unlock()
+
+		if found {
+			r.send(ResolveEvent{Resolution: &Resolution{Hash: h, Path: dir, Source: source, Variant: v}})
+		}
+	}
+}
+
+func (r *resolver) send(ev ResolveEvent) bool {
+	select {
+	case r.out <- ev:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+// reportProgress sends a progress update every ProgressInterval until done
+// is closed.
+func (r *resolver) reportProgress(done <-chan struct{}) {
+	ticker := time.NewTicker(r.opts.ProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p := r.progress()
+		select {
+		case r.out <- ResolveEvent{Progress: &p}:
+		case <-done:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// progress snapshots the current state. The ETA extrapolates the time spent
+// on the current tier by the share of its top-level subtrees finished so far
+// and is capped by the remaining budget.
+func (r *resolver) progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	p := Progress{
+		Source:      r.source,
+		Tier:        r.tier,
+		TierIndex:   r.tierIndex,
+		Tiers:       r.tierCount,
+		DirsScanned: r.dirs,
+		Remaining:   len(r.targets),
+		Elapsed:     now.Sub(r.start),
+	}
+	if r.done > 0 && len(r.subtrees) > 0 {
+		frac := float64(r.done) / float64(len(r.subtrees))
+		p.ETA = time.Duration(float64(now.Sub(r.tierStart)) * (1 - frac) / frac)
+	}
+	if r.opts.Budget > 0 {
+		left := r.opts.Budget - p.Elapsed
+		if left < 0 {
+			left = 0
+		}
+		if p.ETA == 0 || p.ETA > left {
+			p.ETA = left
+		}
+	}
+	return p
+}
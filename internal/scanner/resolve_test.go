@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"geminictl/internal/config"
+	"geminictl/internal/gemini"
+)
+
+// writeFile writes a file, creating its directory.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resolveAll runs Resolve to the end and returns the source of each resolved
+// project by path.
+func resolveAll(ctx context.Context, s *Scanner, paths []string, opts ResolveOptions) map[string]string {
+	ids := make([]string, len(paths))
+	byID := make(map[string]string)
+	for i, p := range paths {
+		ids[i] = gemini.HashPath(p)
+		byID[ids[i]] = p
+	}
+	got := make(map[string]string)
+	for ev := range s.Resolve(ctx, ids, opts) {
+		if res := ev.Resolution; res != nil {
+			if res.Hash != gemini.HashPath(res.Variant.Path) {
+				got[byID[res.Hash]] = "bad variant " + res.Variant.Path
+				continue
+			}
+			got[byID[res.Hash]] = res.Source
+		}
+	}
+	return got
+}
+
+func TestResolve(t *testing.T) {
+	base := t.TempDir()
+	other := t.TempDir()
+	for _, dir := range []string{"a/b/proj", "c", "node_modules/dep"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(other, "real", "inner"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(other, "real"), filepath.Join(base, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sessions of a project whose directory is gone mention files in it.
+	s := &Scanner{RootDir: t.TempDir()}
+	gone := gemini.HashPath("/gone/dir")
+	writeFile(t, filepath.Join(s.RootDir, gone, gemini.SessionDir, "session-2025-01-01T10-00-s1.json"),
+		`{"sessionId":"s1","messages":[{"content":"read /gone/dir/src/main.go\nok"}]}`)
+
+	proj, c, dep := filepath.Join(base, "a/b/proj"), filepath.Join(base, "c"), filepath.Join(base, "node_modules/dep")
+	viaLink := filepath.Join(base, "link", "inner")
+	targets := []string{proj, c, dep, viaLink, "/gone/dir", "/never/existed"}
+	tests := []struct {
+		name string
+		opts config.Resolve
+		want map[string]string
+	}{
+		{"walk", config.Resolve{Roots: []config.Root{{Path: base}}},
+			map[string]string{proj: SourceWalk, c: SourceWalk, dep: SourceWalk}},
+		{"max depth", config.Resolve{Roots: []config.Root{{Path: base, MaxDepth: 2}}},
+			map[string]string{c: SourceWalk, dep: SourceWalk}},
+		{"exclude", config.Resolve{Roots: []config.Root{{Path: base}}, Exclude: []string{"node_modules", "b"}},
+			map[string]string{c: SourceWalk}},
+		{"symlink", config.Resolve{Roots: []config.Root{{Path: base}, {Path: other}}, Exclude: []string{"node_modules"}},
+			map[string]string{proj: SourceWalk, c: SourceWalk, viaLink: SourceWalk}},
+		{"sessions", config.Resolve{Roots: []config.Root{{Path: t.TempDir()}}, Sources: []string{"sessions", "unknown"}},
+			map[string]string{"/gone/dir": "sessions"}},
+	}
+	for _, tt := range tests {
+		got := resolveAll(context.Background(), s, targets, ResolveOptions{Resolve: tt.opts})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: resolved %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveStops(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := &Scanner{RootDir: t.TempDir()}
+	roots := config.Resolve{Roots: []config.Root{{Path: base}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := resolveAll(ctx, s, []string{filepath.Join(base, "a")}, ResolveOptions{Resolve: roots}); len(got) != 0 {
+		t.Errorf("Resolve after cancellation = %v", got)
+	}
+
+	roots.Budget = time.Nanosecond
+	done := make(chan struct{})
+	go func() {
+		resolveAll(context.Background(), s, []string{"/never/existed"}, ResolveOptions{Resolve: roots})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Resolve ran past its budget")
+	}
+
+	// Without targets, the channel is closed right away.
+	if got := resolveAll(context.Background(), s, nil, ResolveOptions{Resolve: roots}); len(got) != 0 {
+		t.Errorf("Resolve without targets = %v", got)
+	}
+}
@@ -1,43 +1,74 @@
 package scanner
 
 import (
-	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 	"time"
 
+	"geminictl/internal/cache"
 	"geminictl/internal/gemini"
+	"geminictl/internal/variant"
 )
 
 // Session metadata for TUI display.
 type Session struct {
 	ID           string
+	Title        string // Derived from the first user prompt, see gemini.PromptTitle
 	MessageCount int
+	StartTime    time.Time
 	LastUpdate   time.Time
+	Models       []string
+	Tokens       gemini.TokenStats
 }
 
-// ProjectData aggregates sessions for a specific project hash.
+// Checkpoint metadata for TUI display.
+type Checkpoint struct {
+	Name         string
+	MessageCount int
+	Size         int64
+	SavedAt      time.Time
+}
+
+// ProjectData aggregates sessions and other artifacts for a specific project hash.
 type ProjectData struct {
-	ID       string
-	Sessions []Session
+	ID          string
+	Sessions    []Session
+	LogEntries  int
+	LogsSize    int64
+	Checkpoints []Checkpoint
+}
+
+// SessionIDs returns the IDs of the sessions of the project.
+func (p ProjectData) SessionIDs() []string {
+	ids := make([]string, len(p.Sessions))
+	for i, s := range p.Sessions {
+		ids[i] = s.ID
+	}
+	return ids
 }
 
 // Resolution represents a found project mapping.
 type Resolution struct {
-	Hash string
-	Path string
+	Hash   string
+	Path   string
+	Source string // Name of the candidate source that found the path, or SourceWalk
+
+	// Variant is the spelling of Path whose hash matched, e.g. the path
+	// through a symlink. Its Kind is variant.KindExact if Path itself matched.
+	Variant variant.Variant
 }
 
 // Scanner handles discovery of Gemini sessions.
 type Scanner struct {
 	RootDir string
+
+	meta *metaIndex
 }
 
 // NewScanner creates a scanner. If baseDir is provided, it uses it as the root
-// and looks for storage in a 'gemini' subdirectory of that path.
+// and looks for storage in a 'gemini' subdirectory of that path. Session
+// metadata is cached in geminictl's state directory (see cache.Dir).
 func NewScanner(baseDir string) (*Scanner, error) {
 	var root string
 	if baseDir != "" {
@@ -50,8 +81,14 @@ func NewScanner(baseDir string) (*Scanner, error) {
 		root = filepath.Join(home, ".gemini", "tmp")
 	}
 
+	dir, err := cache.Dir(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Scanner{
 		RootDir: root,
+		meta:    loadMetaIndex(dir),
 	}, nil
 }
 
@@ -65,143 +102,122 @@ func (s *Scanner) Scan() ([]ProjectData, error) {
 		return nil, err
 	}
 
+	seen := make(map[string]bool)
 	var projects []ProjectData
 	for _, id := range ids {
-		project := ProjectData{ID: id}
-		
-		sessions, err := gemini.ReadSessions(s.RootDir, id)
+		project, err := s.scanProject(id, seen)
 		if err != nil {
 			continue
 		}
-
-		// Aggregate multi-file sessions
-		sessionMap := make(map[string]*Session)
-		for _, sess := range sessions {
-			lastUpdate := sess.GetLastUpdate()
-			if existing, ok := sessionMap[sess.ID]; ok {
-				existing.MessageCount += len(sess.Messages)
-				if lastUpdate.After(existing.LastUpdate) {
-					existing.LastUpdate = lastUpdate
-				}
-			} else {
-				sessionMap[sess.ID] = &Session{
-					ID:           sess.ID,
-					MessageCount: len(sess.Messages),
-					LastUpdate:   lastUpdate,
-				}
-			}
-		}
-
-		var projectSessions []Session
-		for _, sess := range sessionMap {
-			projectSessions = append(projectSessions, *sess)
-		}
-
-		// Sort sessions by last update descending
-		sort.Slice(projectSessions, func(i, j int) bool {
-			return projectSessions[i].LastUpdate.After(projectSessions[j].LastUpdate)
-		})
-
-		project.Sessions = projectSessions
 		projects = append(projects, project)
 	}
 
+	s.meta.prune(seen)
+	_ = s.meta.save()
 	return projects, nil
 }
 
-// ResolveBackground starts a 4-tier scan to resolve project hashes to paths.
-func (s *Scanner) ResolveBackground(unknownIDs []string) <-chan Resolution {
-	out := make(chan Resolution)
-	go func() {
-		defer close(out)
+// ScanProject collects the sessions and artifacts of a single project. It
+// returns an error satisfying os.IsNotExist if the project no longer exists.
+func (s *Scanner) ScanProject(id string) (ProjectData, error) {
+	project, err := s.scanProject(id, nil)
+	if err == nil {
+		_ = s.meta.save()
+	}
+	return project, err
+}
 
-		targets := make(map[string]bool)
-		for _, id := range unknownIDs {
-			targets[id] = true
-		}
+// scanProject scans a project, adding the session files it saw to seen if
+// not nil. Session files are only parsed if the metadata index has no
+// current entry for them.
+func (s *Scanner) scanProject(id string, seen map[string]bool) (ProjectData, error) {
+	if _, err := os.Stat(filepath.Join(s.RootDir, id)); err != nil {
+		return ProjectData{}, err
+	}
 
-		if len(targets) == 0 {
-			return
-		}
+	project := ProjectData{ID: id}
 
-		visited := make(map[string]bool)
-		home, _ := os.UserHomeDir()
+	paths, err := gemini.ListSessionFiles(s.RootDir, id)
+	if err != nil {
+		return ProjectData{}, err
+	}
 
-		// Tier 1: Desktop
-		s.scanTier(filepath.Join(home, "Desktop"), targets, visited, out)
-		if len(targets) == 0 {
-			return
+	// Group the files by session. Sessions split across several files are
+	// merged like gemini.GetSession does.
+	type sessionFile struct {
+		path    string
+		meta    gemini.SessionMeta
+		modTime time.Time
+	}
+	sessionFiles := make(map[string][]sessionFile)
+	for _, path := range paths {
+		meta, modTime, err := s.meta.get(path)
+		if err != nil {
+			continue
 		}
-
-		// Tier 2: Home
-		s.scanTier(home, targets, visited, out)
-		if len(targets) == 0 {
-			return
+		if seen != nil {
+			seen[path] = true
 		}
+		sessionFiles[meta.ID] = append(sessionFiles[meta.ID], sessionFile{path, meta, modTime})
+	}
 
-		// Tier 3: External Common
-		externalCommon := []string{"/opt", "/var/www", "/usr/local/src", "/srv"}
-		for _, path := range externalCommon {
-			s.scanTier(path, targets, visited, out)
-			if len(targets) == 0 {
-				return
+	var projectSessions []Session
+	for sessionID, files := range sessionFiles {
+		meta, modTime := files[0].meta, files[0].modTime
+		if len(files) > 1 {
+			paths := make([]string, len(files))
+			for i, f := range files {
+				paths[i] = f.path
+			}
+			var err error
+			meta, modTime, err = s.meta.getMerged(id+"/"+sessionID, paths)
+			if err != nil {
+				continue
 			}
 		}
-
-		// Tier 4: Root
-		s.scanTier("/", targets, visited, out)
-	}()
-	return out
-}
-
-var ignoreDirs = map[string]bool{
-	"node_modules": true,
-	".git":         true,
-	".npm":         true,
-	".cache":       true,
-	".gemini":      true,
-	".vscode":      true,
-	".idea":        true,
-	"go":           true,
-	"Library":      true,
-}
-
-func (s *Scanner) scanTier(root string, targets map[string]bool, visited map[string]bool, out chan<- Resolution) {
-	if visited[root] {
-		return
+		projectSessions = append(projectSessions, Session{
+			ID:           meta.ID,
+			Title:        meta.Title,
+			MessageCount: meta.MessageCount,
+			StartTime:    meta.Started(),
+			LastUpdate:   meta.LastUpdate(modTime),
+			Models:       meta.Models,
+			Tokens:       meta.Tokens,
+		})
 	}
 
-	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return filepath.SkipDir
-		}
-
-		if visited[path] {
-			return filepath.SkipDir
+	// Sort sessions by last update descending
+	sort.Slice(projectSessions, func(i, j int) bool {
+		if !projectSessions[i].LastUpdate.Equal(projectSessions[j].LastUpdate) {
+			return projectSessions[i].LastUpdate.After(projectSessions[j].LastUpdate)
 		}
+		return projectSessions[i].ID < projectSessions[j].ID
+	})
 
-		if d.IsDir() {
-			name := d.Name()
-			if ignoreDirs[name] || (strings.HasPrefix(name, ".") && name != ".") {
-				visited[path] = true
-				return filepath.SkipDir
-			}
+	project.Sessions = projectSessions
+	s.scanArtifacts(&project)
+	return project, nil
+}
 
-			h, err := gemini.HashProjectID(path)
-			if err != nil {
-				return nil
-			}
-			
-			if targets[h] {
-				out <- Resolution{Hash: h, Path: path}
-				delete(targets, h)
-				if len(targets) == 0 {
-					return fmt.Errorf("all resolved")
-				}
-			}
+// scanArtifacts collects the logs.json and checkpoint metadata of a project.
+func (s *Scanner) scanArtifacts(project *ProjectData) {
+	if info, err := os.Stat(gemini.LogsPath(s.RootDir, project.ID)); err == nil {
+		project.LogsSize = info.Size()
+		if logs, err := gemini.ReadLogs(s.RootDir, project.ID); err == nil {
+			project.LogEntries = len(logs)
 		}
+	}
 
-		visited[path] = true
-		return nil
-	})
+	checkpoints, err := gemini.ListCheckpoints(s.RootDir, project.ID)
+	if err != nil {
+		return
+	}
+	for _, cp := range checkpoints {
+		project.Checkpoints = append(project.Checkpoints, Checkpoint{
+			Name:         cp.Name,
+			MessageCount: len(cp.History),
+			Size:         cp.Size,
+			SavedAt:      cp.ModTime,
+		})
+	}
 }
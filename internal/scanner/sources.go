@@ -0,0 +1,394 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"geminictl/internal/gemini"
+)
+
+// SourceWalk is the Resolution.Source of paths found by walking the roots.
+const SourceWalk = "walk"
+
+// CandidateSource harvests directories likely to be project paths, such as
+// directories from shell histories. Candidates are hashed before the roots are
+// walked, which resolves most projects without touching the rest of the disk.
+type CandidateSource interface {
+	// Name identifies the source in the configuration and in resolutions.
+	Name() string
+
+	// Candidates returns paths worth hashing. They need not exist: a path
+	// whose hash matches a project is where the project was created, even if
+	// the directory has since been moved or deleted.
+	Candidates(ctx context.Context, in SourceInput) []string
+}
+
+// SourceInput is what candidate sources can draw on besides the filesystem.
+type SourceInput struct {
+	RootDir string   // Gemini storage
+	Targets []string // IDs of the projects being resolved
+
+	// Known holds the paths of projects resolved earlier and the candidates
+	// of the sources before this one.
+	Known []string
+}
+
+// candidateSources are the available sources by name; config.Sources lists
+// their names.
+var candidateSources = map[string]CandidateSource{
+	"sessions": sessionContent{},
+	"bash":     bashHistory{},
+	"zsh":      zshHistory{},
+	"fish":     fishHistory{},
+	"zoxide":   zoxide{},
+	"autojump": autojump{},
+	"git":      gitWorktrees{},
+}
+
+// sourcesByName returns the sources with the given names, skipping unknown
+// ones.
+func sourcesByName(names []string) []CandidateSource {
+	var sources []CandidateSource
+	for _, name := range names {
+		if s, ok := candidateSources[name]; ok {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+// sessionContent mines the sessions and logs of the projects being resolved
+// for the absolute paths mentioned in them: files read by tools, directories
+// changed into, stack traces. Projects mostly mention files within their own
+// directory, so the ancestors of those paths are likely to include it.
+type sessionContent struct{}
+
+func (sessionContent) Name() string { return "sessions" }
+
+// pathPattern matches absolute and home-relative paths in raw session files.
+// Backslashes end a match, so paths followed by JSON escapes such as \n are
+// cut off correctly.
+var pathPattern = regexp.MustCompile(`~?(?:/[\w.+@%-]+)+`)
+
+func (sessionContent) Candidates(ctx context.Context, in SourceInput) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, id := range in.Targets {
+		files, _ := gemini.ListSessionFiles(in.RootDir, id)
+		files = append(files, gemini.LogsPath(in.RootDir, id))
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return dirs
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			for _, m := range pathPattern.FindAll(data, -1) {
+				dirs = appendAncestors(dirs, seen, expandHome(string(m)))
+			}
+		}
+	}
+	return dirs
+}
+
+// bashHistory reads the commands in ~/.bash_history, or $HISTFILE.
+type bashHistory struct{}
+
+func (bashHistory) Name() string { return "bash" }
+
+func (bashHistory) Candidates(ctx context.Context, in SourceInput) []string {
+	return historyCandidates(ctx, historyFile(".bash_history"), func(line string) string {
+		if strings.HasPrefix(line, "#") {
+			return "" // Timestamp written with HISTTIMEFORMAT
+		}
+		return line
+	})
+}
+
+// zshHistory reads the commands in ~/.zsh_history, or $HISTFILE, in both the
+// plain and the extended format (": <time>:<duration>;<command>").
+type zshHistory struct{}
+
+func (zshHistory) Name() string { return "zsh" }
+
+func (zshHistory) Candidates(ctx context.Context, in SourceInput) []string {
+	return historyCandidates(ctx, historyFile(".zsh_history"), func(line string) string {
+		if strings.HasPrefix(line, ": ") {
+			if i := strings.Index(line, ";"); i >= 0 {
+				return line[i+1:]
+			}
+		}
+		return line
+	})
+}
+
+// fishHistory reads the commands and the recorded paths in fish's history.
+type fishHistory struct{}
+
+func (fishHistory) Name() string { return "fish" }
+
+func (fishHistory) Candidates(ctx context.Context, in SourceInput) []string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		dir = expandHome("~/.local/share")
+	}
+	return historyCandidates(ctx, filepath.Join(dir, "fish", "fish_history"), func(line string) string {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			return strings.TrimPrefix(line, "- cmd: ")
+		case strings.HasPrefix(line, "- "):
+			return strings.TrimPrefix(line, "- ") // Entry of a paths list
+		}
+		return ""
+	})
+}
+
+// zoxide lists the directories in zoxide's database.
+type zoxide struct{}
+
+func (zoxide) Name() string { return "zoxide" }
+
+func (zoxide) Candidates(ctx context.Context, in SourceInput) []string {
+	if _, err := exec.LookPath("zoxide"); err != nil {
+		return nil
+	}
+	out, err := exec.CommandContext(ctx, "zoxide", "query", "--list").Output()
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); filepath.IsAbs(line) {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs
+}
+
+// autojump reads the directories in autojump's database, which holds one
+// "<weight>\t<path>" line per directory.
+type autojump struct{}
+
+func (autojump) Name() string { return "autojump" }
+
+func (autojump) Candidates(ctx context.Context, in SourceInput) []string {
+	var dirs []string
+	for _, path := range []string{
+		expandHome("~/.local/share/autojump/autojump.txt"),
+		expandHome("~/Library/Application Support/autojump/autojump.txt"),
+	} {
+		_ = readLines(ctx, path, func(line string) {
+			if _, dir, ok := strings.Cut(line, "\t"); ok && filepath.IsAbs(dir) {
+				dirs = append(dirs, dir)
+			}
+		})
+	}
+	return dirs
+}
+
+// gitWorktrees finds the worktrees of the git repositories among the known
+// directories, and the main repositories of known worktrees.
+type gitWorktrees struct{}
+
+func (gitWorktrees) Name() string { return "git" }
+
+func (gitWorktrees) Candidates(ctx context.Context, in SourceInput) []string {
+	var dirs []string
+	repos := make(map[string]bool) // Common git directories already listed
+	for _, dir := range in.Known {
+		if ctx.Err() != nil {
+			break
+		}
+		gitDir := filepath.Join(dir, ".git")
+		info, err := os.Stat(gitDir)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			// A worktree's .git file points into the main repository's
+			// .git/worktrees directory.
+			gitDir = worktreeCommonDir(gitDir)
+			if gitDir == "" {
+				continue
+			}
+			dirs = append(dirs, filepath.Dir(gitDir))
+		}
+		if repos[gitDir] {
+			continue
+		}
+		repos[gitDir] = true
+		dirs = append(dirs, listWorktrees(gitDir)...)
+	}
+	return dirs
+}
+
+// worktreeCommonDir returns the .git directory of the main repository of the
+// worktree whose .git file is at path, or "" if it is not a worktree.
+func worktreeCommonDir(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return ""
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	common, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return "" // Not a worktree, e.g. a submodule
+	}
+	dir := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// listWorktrees returns the worktree directories registered in a repository's
+// .git directory.
+func listWorktrees(gitDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(gitDir, "worktrees"))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		// gitdir holds the path of the worktree's .git file.
+		data, err := os.ReadFile(filepath.Join(gitDir, "worktrees", e.Name(), "gitdir"))
+		if err != nil {
+			continue
+		}
+		if path := strings.TrimSpace(string(data)); filepath.IsAbs(path) {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	return dirs
+}
+
+// historyFile returns $HISTFILE if it names the given file, or the file in
+// the home directory.
+func historyFile(name string) string {
+	if h := os.Getenv("HISTFILE"); h != "" && filepath.Base(h) == name {
+		return h
+	}
+	return expandHome("~/" + name)
+}
+
+// historyCandidates extracts the absolute paths mentioned in the commands of
+// a history file, together with their ancestors. command turns a line of the
+// file into a command, or "" for lines that are not commands.
+func historyCandidates(ctx context.Context, path string, command func(string) string) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	_ = readLines(ctx, path, func(line string) {
+		for _, p := range commandPaths(command(line)) {
+			dirs = appendAncestors(dirs, seen, p)
+		}
+	})
+	return dirs
+}
+
+// appendAncestors appends an absolute path and its ancestors below the
+// filesystem root that are not in seen yet. Paths mentioned in commands and
+// sessions are often files deep within a project, so their ancestors are
+// candidates as well.
+func appendAncestors(dirs []string, seen map[string]bool, path string) []string {
+	if !filepath.IsAbs(path) {
+		return dirs
+	}
+	for p := filepath.Clean(path); p != "/" && !seen[p]; p = filepath.Dir(p) {
+		seen[p] = true
+		dirs = append(dirs, p)
+	}
+	return dirs
+}
+
+// commandPaths returns the arguments of a shell command that are absolute or
+// home-relative paths, including the values of --flag=<path> arguments.
+func commandPaths(cmd string) []string {
+	var paths []string
+	fields := strings.FieldsFunc(cmd, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ';' || r == '|' || r == '&' || r == '(' || r == ')'
+	})
+	for _, f := range fields {
+		if _, v, ok := strings.Cut(f, "="); ok && strings.HasPrefix(f, "-") {
+			f = v
+		}
+		f = strings.Trim(f, `"'`)
+		if strings.HasPrefix(f, "~") {
+			f = expandHome(f)
+		}
+		if filepath.IsAbs(f) && f != "/" {
+			paths = append(paths, filepath.Clean(f))
+		}
+	}
+	return paths
+}
+
+// maxLineLength is the length of the longest line, with its line ending,
+// that readLines passes on.
+const maxLineLength = 1024 * 1024
+
+// readLines calls fn for each line of a file, without its line ending. Lines
+// longer than maxLineLength, e.g. pasted blobs in a shell history, are
+// skipped.
+func readLines(ctx context.Context, path string, fn func(string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var line []byte
+	skip := false
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		chunk, err := r.ReadSlice('\n')
+		if !skip {
+			line = append(line, chunk...)
+			if len(line) > maxLineLength {
+				skip, line = true, line[:0]
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue // The line goes on
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if !skip && len(line) > 0 {
+			text := strings.TrimSuffix(string(line), "\n")
+			fn(strings.TrimSuffix(text, "\r"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		line, skip = line[:0], false
+	}
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"geminictl/internal/gemini"
+)
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", maxLineLength+1)
+	fits := long[2:] // With its line ending
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"", nil},
+		{"a\nb", []string{"a", "b"}},
+		{"a\r\n\nb\n", []string{"a", "", "b"}},
+		{"a\n" + long + "\nb\n", []string{"a", "b"}},
+		{long, nil},
+		{"a\n" + fits + "\n", []string{"a", fits}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "history")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		if err := readLines(context.Background(), path, func(line string) { got = append(got, line) }); err != nil {
+			t.Errorf("readLines: %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("readLines(%.20q) = %.40q, want %.40q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCommandPaths(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"cd /srv/app && make", []string{"/srv/app"}},
+		{`vim "/etc/hosts"; ls -la /tmp/x/`, []string{"/etc/hosts", "/tmp/x"}},
+		{"go test --coverprofile=/tmp/c.out ./...", []string{"/tmp/c.out"}},
+		{"ls / relative ./dir", nil},
+	}
+	for _, tt := range tests {
+		if got := commandPaths(tt.cmd); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commandPaths(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestHistorySources(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("HISTFILE", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	writeFile(t, filepath.Join(home, ".bash_history"), "#1700000000\ncd /srv/bash\n")
+	writeFile(t, filepath.Join(home, ".zsh_history"), ": 1700000000:0;cd /srv/zsh\nls ~/plain\n")
+	writeFile(t, filepath.Join(home, ".local/share/fish/fish_history"), "- cmd: cd /srv/fish\n  when: 1700000000\n  paths:\n    - /srv/fish/file\n")
+	writeFile(t, filepath.Join(home, ".local/share/autojump/autojump.txt"), "10.0\t/srv/jump\nbroken\n")
+
+	tests := []struct {
+		src  CandidateSource
+		want []string
+	}{
+		{bashHistory{}, []string{"/srv/bash", "/srv"}},
+		{zshHistory{}, []string{"/srv/zsh", "/srv", filepath.Join(home, "plain"), home, filepath.Dir(home)}},
+		{fishHistory{}, []string{"/srv/fish", "/srv", "/srv/fish/file"}},
+		{autojump{}, []string{"/srv/jump"}},
+	}
+	for _, tt := range tests {
+		got := tt.src.Candidates(context.Background(), SourceInput{})
+		// Ancestors above the temporary home directory vary.
+		if len(got) > len(tt.want) {
+			got = got[:len(tt.want)]
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Candidates = %q, want %q", tt.src.Name(), got, tt.want)
+		}
+	}
+
+	// $HISTFILE is used when it names the history of the shell.
+	histfile := filepath.Join(t.TempDir(), ".bash_history")
+	writeFile(t, histfile, "cd /opt/custom\n")
+	t.Setenv("HISTFILE", histfile)
+	if got := (bashHistory{}).Candidates(context.Background(), SourceInput{}); len(got) == 0 || got[0] != "/opt/custom" {
+		t.Errorf("Candidates with $HISTFILE = %q", got)
+	}
+}
+
+func TestGitWorktrees(t *testing.T) {
+	base := t.TempDir()
+	repo, wt := filepath.Join(base, "main"), filepath.Join(base, "wt")
+	admin := filepath.Join(repo, ".git", "worktrees", "wt")
+	writeFile(t, filepath.Join(admin, "gitdir"), filepath.Join(wt, ".git")+"\n")
+	writeFile(t, filepath.Join(admin, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(wt, ".git"), "gitdir: "+admin+"\n")
+	// A submodule's .git file points to a directory without commondir.
+	writeFile(t, filepath.Join(base, "sub", ".git"), "gitdir: ../main/.git/modules/sub\n")
+
+	tests := []struct {
+		known []string
+		want  []string
+	}{
+		{[]string{repo}, []string{wt}},
+		{[]string{wt}, []string{repo, wt}},
+		{[]string{wt, repo}, []string{repo, wt}},
+		{[]string{filepath.Join(base, "sub"), base}, nil},
+	}
+	for _, tt := range tests {
+		if got := (gitWorktrees{}).Candidates(context.Background(), SourceInput{Known: tt.known}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Candidates(%q) = %q, want %q", tt.known, got, tt.want)
+		}
+	}
+}
+
+func TestSessionContent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	target, other := gemini.HashPath("/work/api"), gemini.HashPath("/other")
+	writeFile(t, filepath.Join(root, target, gemini.SessionDir, "session-2025-01-01T10-00-s1.json"),
+		`{"sessionId":"s1","messages":[{"content":"read /work/api/src/main.go\nthen ~/notes"}]}`)
+	writeFile(t, gemini.LogsPath(root, target), `[{"message":"cd /work/api/src && make"}]`)
+	writeFile(t, filepath.Join(root, other, gemini.SessionDir, "session-2025-01-01T10-00-s2.json"),
+		`{"sessionId":"s2","messages":[{"content":"/elsewhere/x"}]}`)
+
+	got := sessionContent{}.Candidates(context.Background(), SourceInput{RootDir: root, Targets: []string{target}})
+	want := []string{"/work/api/src/main.go", "/work/api/src", "/work/api", "/work", filepath.Join(home, "notes")}
+	// Ancestors above the temporary home directory vary.
+	if len(got) < len(want) || !reflect.DeepEqual(got[:len(want)], want) {
+		t.Errorf("Candidates = %q, want %q followed by the ancestors of the home directory", got, want)
+	}
+	for _, p := range got {
+		if strings.HasPrefix(p, "/elsewhere") {
+			t.Errorf("Candidates include %s from a project not being resolved", p)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := (sessionContent{}).Candidates(ctx, SourceInput{RootDir: root, Targets: []string{target}}); len(got) != 0 {
+		t.Errorf("Candidates after cancellation = %q", got)
+	}
+}
+
+func TestAppendAncestors(t *testing.T) {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, p := range []string{"/a/b/c", "relative/x", "/a/b/d/", "/"} {
+		dirs = appendAncestors(dirs, seen, p)
+	}
+	if want := []string{"/a/b/c", "/a/b", "/a", "/a/b/d"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("appendAncestors = %q, want %q", dirs, want)
+	}
+}
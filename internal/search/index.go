@@ -0,0 +1,307 @@
+package search
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+)
+
+// version is bumped whenever the on-disk format changes; older indexes are
+// discarded and rebuilt.
+const version = 1
+
+// IndexFile is the name of the index file in geminictl's state directory.
+const IndexFile = "index.gob"
+
+// Message is an indexed message of a session file.
+type Message struct {
+	ID        string
+	Role      string
+	Model     string
+	Timestamp time.Time
+	Text      string // Message content followed by its thoughts
+}
+
+// File is an indexed session file.
+type File struct {
+	ID        uint32
+	Path      string
+	Size      int64
+	ModTime   time.Time
+	ProjectID string
+	SessionID string
+	Messages  []Message
+}
+
+// posting locates a term occurrence: the file ID and the message indexes.
+type postings map[uint32][]uint32
+
+// Index is a persistent inverted index over the content of all session files.
+// It is updated incrementally based on file sizes and modification times.
+type Index struct {
+	mu       sync.Mutex
+	path     string
+	files    map[string]*File // keyed by path
+	byID     map[uint32]*File
+	terms    map[string]postings
+	sorted   []string // sorted terms for prefix queries, nil if stale
+	nextID   uint32
+	modified bool
+}
+
+// onDisk is the serialized form of the index.
+type onDisk struct {
+	Version int
+	NextID  uint32
+	Files   []*File
+	Terms   map[string]postings
+}
+
+// UpdateStats reports what an Update changed.
+type UpdateStats struct {
+	Added   int
+	Updated int
+	Removed int
+}
+
+// Open loads the index from geminictl's state directory (see cache.Dir). A
+// missing, outdated or unreadable index yields an empty index.
+func Open(baseDir string) (*Index, error) {
+	dir, err := cache.Dir(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{path: filepath.Join(dir, IndexFile)}
+	idx.reset()
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, err
+	}
+
+	var d onDisk
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&d); err != nil || d.Version != version {
+		// Start over; the next Update rebuilds everything.
+		idx.modified = true
+		return idx, nil
+	}
+	idx.nextID = d.NextID
+	idx.terms = d.Terms
+	if idx.terms == nil {
+		idx.terms = make(map[string]postings)
+	}
+	for _, f := range d.Files {
+		idx.files[f.Path] = f
+		idx.byID[f.ID] = f
+	}
+	return idx, nil
+}
+
+func (idx *Index) reset() {
+	idx.files = make(map[string]*File)
+	idx.byID = make(map[uint32]*File)
+	idx.terms = make(map[string]postings)
+	idx.sorted = nil
+	idx.nextID = 0
+}
+
+// Clear drops all indexed content so that the next Update rebuilds it.
+func (idx *Index) Clear() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.reset()
+	idx.modified = true
+}
+
+// Update brings the index in line with the session files in rootDir. Only
+// files whose size or modification time changed are parsed again.
+func (idx *Index) Update(rootDir string) (UpdateStats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var stats UpdateStats
+	ids, err := gemini.ListProjectIDs(rootDir)
+	if err != nil && !os.IsNotExist(err) {
+		return stats, err
+	}
+
+	seen := make(map[string]bool)
+	for _, projectID := range ids {
+		paths, err := gemini.ListSessionFiles(rootDir, projectID)
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			seen[path] = true
+
+			existing, ok := idx.files[path]
+			if ok && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) && existing.ProjectID == projectID {
+				continue
+			}
+
+			s, err := gemini.ReadSessionFile(path)
+			if err != nil {
+				continue
+			}
+			if ok {
+				idx.remove(existing)
+				stats.Updated++
+			} else {
+				stats.Added++
+			}
+			idx.add(projectID, info, s)
+		}
+	}
+
+	for path, f := range idx.files {
+		if !seen[path] {
+			idx.remove(f)
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+// Save writes the index to disk if it changed since it was loaded.
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.modified {
+		return nil
+	}
+
+	d := onDisk{Version: version, NextID: idx.nextID, Terms: idx.terms}
+	for _, f := range idx.files {
+		d.Files = append(d.Files, f)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	if err := journal.WriteFileAtomic(idx.path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	idx.modified = false
+	return nil
+}
+
+// Stats returns the number of indexed files, messages and distinct terms.
+func (idx *Index) Stats() (files, messages, terms int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, f := range idx.files {
+		messages += len(f.Messages)
+	}
+	return len(idx.files), messages, len(idx.terms)
+}
+
+func (idx *Index) add(projectID string, info os.FileInfo, s gemini.Session) {
+	f := &File{
+		ID:        idx.nextID,
+		Path:      s.FilePath,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		ProjectID: projectID,
+		SessionID: s.ID,
+	}
+	idx.nextID++
+
+	for i, msg := range s.Messages {
+		ts, _ := time.Parse(time.RFC3339, msg.Timestamp)
+		text := msg.Content
+		for _, t := range msg.Thoughts {
+			text += "\n" + t.Subject + "\n" + t.Description
+		}
+		f.Messages = append(f.Messages, Message{
+			ID:        msg.ID,
+			Role:      msg.Type,
+			Model:     msg.Model,
+			Timestamp: ts,
+			Text:      text,
+		})
+
+		for term := range termSet(text) {
+			p := idx.terms[term]
+			if p == nil {
+				p = make(postings)
+				idx.terms[term] = p
+				idx.sorted = nil
+			}
+			p[f.ID] = append(p[f.ID], uint32(i))
+		}
+	}
+
+	idx.files[f.Path] = f
+	idx.byID[f.ID] = f
+	idx.modified = true
+}
+
+func (idx *Index) remove(f *File) {
+	for _, msg := range f.Messages {
+		for term := range termSet(msg.Text) {
+			p := idx.terms[term]
+			if p == nil {
+				continue
+			}
+			delete(p, f.ID)
+			if len(p) == 0 {
+				delete(idx.terms, term)
+				idx.sorted = nil
+			}
+		}
+	}
+	delete(idx.files, f.Path)
+	delete(idx.byID, f.ID)
+	idx.modified = true
+}
+
+// sortedTerms returns all terms in lexical order.
+func (idx *Index) sortedTerms() []string {
+	if idx.sorted == nil {
+		idx.sorted = make([]string, 0, len(idx.terms))
+		for t := range idx.terms {
+			idx.sorted = append(idx.sorted, t)
+		}
+		sort.Strings(idx.sorted)
+	}
+	return idx.sorted
+}
+
+// tokenize splits text into lowercase terms. Letters, digits and underscores
+// form terms; everything else separates them.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+func termSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range tokenize(text) {
+		set[t] = true
+	}
+	return set
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/gemini"
+)
+
+// ids returns the IDs of the messages matching a query.
+func ids(t *testing.T, idx *Index, query string) []string {
+	t.Helper()
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, h := range idx.Search(q, 0) {
+		out = append(out, h.MessageID)
+	}
+	return out
+}
+
+func TestUpdate(t *testing.T) {
+	root := t.TempDir()
+	p1, p2 := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	writeSession(t, root, p1, "s1", "deploy the frontend")
+	writeSession(t, root, p1, "s2", "fix the database")
+	path := func(project, session string) string {
+		return filepath.Join(root, project, gemini.SessionDir, gemini.SessionPrefix+session+gemini.SessionSuffix)
+	}
+	touch := func(p string) {
+		later := time.Now().Add(time.Hour)
+		if err := os.Chtimes(p, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx := &Index{}
+	idx.reset()
+	steps := []struct {
+		name   string
+		change func()
+		want   UpdateStats
+		query  string
+		hits   int
+	}{
+		{"initial", func() {}, UpdateStats{Added: 2}, "frontend", 1},
+		{"unchanged", func() {}, UpdateStats{}, "database", 1},
+		{"rewritten", func() {
+			writeSession(t, root, p1, "s1", "deploy the backend", "done")
+			touch(path(p1, "s1"))
+		}, UpdateStats{Updated: 1}, "frontend", 0},
+		{"removed", func() { os.Remove(path(p1, "s2")) }, UpdateStats{Removed: 1}, "database", 0},
+		{"moved to another project", func() {
+			os.MkdirAll(filepath.Dir(path(p2, "s1")), 0755)
+			os.Rename(path(p1, "s1"), path(p2, "s1"))
+		}, UpdateStats{Added: 1, Removed: 1}, "backend project:bbb", 1},
+	}
+	for _, s := range steps {
+		s.change()
+		stats, err := idx.Update(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats != s.want {
+			t.Errorf("%s: Update = %+v, want %+v", s.name, stats, s.want)
+		}
+		if got := ids(t, idx, s.query); len(got) != s.hits {
+			t.Errorf("%s: Search(%q) = %q, want %d hits", s.name, s.query, got, s.hits)
+		}
+	}
+
+	// Terms of removed files are dropped.
+	if files, messages, terms := idx.Stats(); files != 1 || messages != 2 || terms != 4 {
+		t.Errorf("Stats() = %d files, %d messages, %d terms; want 1, 2, 4", files, messages, terms)
+	}
+}
+
+func TestOpenAndSave(t *testing.T) {
+	base := t.TempDir()
+	root := t.TempDir()
+	writeSession(t, root, strings.Repeat("a", 64), "s1", "deploy the frontend", "deployed")
+
+	idx, err := Open(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Update(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(t, reopened, "deploy*"); len(got) != 2 {
+		t.Errorf("Search after reopening = %q", got)
+	}
+	if stats, _ := reopened.Update(root); stats != (UpdateStats{}) {
+		t.Errorf("Update after reopening = %+v, want no changes", stats)
+	}
+
+	reopened.Clear()
+	if files, _, _ := reopened.Stats(); files != 0 || !reopened.modified {
+		t.Errorf("Clear kept %d files", files)
+	}
+
+	// A corrupt index is rebuilt.
+	if err := os.WriteFile(idx.path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = Open(base)
+	if err != nil || !idx.modified {
+		t.Fatalf("Open of a corrupt index = %v", err)
+	}
+	if files, _, _ := idx.Stats(); files != 0 {
+		t.Errorf("corrupt index loaded %d files", files)
+	}
+}
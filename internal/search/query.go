@@ -0,0 +1,467 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// term is a single search term: a word, a word prefix (foo*) or a phrase
+// ("foo bar") given as its consecutive words.
+type term struct {
+	words  []string
+	prefix bool
+}
+
+// Query is a parsed search query. A message matches if it matches at least
+// one term of every group, none of the excluded terms and all filters.
+type Query struct {
+	Groups  [][]term
+	Exclude []term
+
+	Project string // Project ID prefix
+	Session string // Session ID prefix
+	Role    string // "user" or "gemini"
+	Model   string // Substring of the model name
+	After   time.Time
+	Before  time.Time
+}
+
+// Empty reports whether the query has neither terms nor filters.
+func (q Query) Empty() bool {
+	return len(q.Groups) == 0 && len(q.Exclude) == 0 && q.Project == "" && q.Session == "" &&
+		q.Role == "" && q.Model == "" && q.After.IsZero() && q.Before.IsZero()
+}
+
+// Parse parses a query string. Supported syntax:
+//
+//	foo bar          both words
+//	"foo bar"        the phrase
+//	foo*             words starting with foo
+//	foo OR bar       either word
+//	-foo, NOT foo    messages without foo (filters cannot be negated)
+//	project:<id>     project ID prefix
+//	session:<id>     session ID prefix
+//	role:user        message type (user or gemini)
+//	model:<name>     model name substring
+//	after:<date>     messages at or after the date (YYYY-MM-DD or RFC 3339)
+//	before:<date>    messages before the date
+func Parse(s string) (Query, error) {
+	var q Query
+	tokens, err := split(s)
+	if err != nil {
+		return q, err
+	}
+
+	negate, or := false, false
+	for _, tok := range tokens {
+		if !tok.quoted {
+			switch tok.text {
+			case "OR":
+				or = len(q.Groups) > 0
+				continue
+			case "NOT", "-":
+				negate = true
+				continue
+			}
+			if strings.HasPrefix(tok.text, "-") && len(tok.text) > 1 {
+				negate = true
+				tok.text = tok.text[1:]
+			}
+			if ok, err := q.setFilter(tok.text); err != nil {
+				return q, err
+			} else if ok {
+				if negate {
+					return q, fmt.Errorf("filter %s cannot be negated", tok.text)
+				}
+				negate, or = false, false
+				continue
+			}
+		}
+
+		t, ok := parseTerm(tok)
+		if ok {
+			switch {
+			case negate:
+				q.Exclude = append(q.Exclude, t)
+			case or:
+				last := len(q.Groups) - 1
+				q.Groups[last] = append(q.Groups[last], t)
+			default:
+				q.Groups = append(q.Groups, []term{t})
+			}
+		}
+		negate, or = false, false
+	}
+	return q, nil
+}
+
+// SetAfter and SetBefore parse a date the same way the after: and before:
+// filters do.
+func (q *Query) SetAfter(s string) error {
+	t, err := parseDate(s)
+	q.After = t
+	return err
+}
+
+func (q *Query) SetBefore(s string) error {
+	t, err := parseDate(s)
+	q.Before = t
+	return err
+}
+
+func (q *Query) setFilter(s string) (bool, error) {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok || value == "" {
+		return false, nil
+	}
+	switch strings.ToLower(key) {
+	case "project":
+		q.Project = strings.ToLower(value)
+	case "session":
+		q.Session = strings.ToLower(value)
+	case "role":
+		q.Role = strings.ToLower(value)
+	case "model":
+		q.Model = strings.ToLower(value)
+	case "after":
+		return true, q.SetAfter(value)
+	case "before":
+		return true, q.SetBefore(value)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// split breaks a query into whitespace separated tokens, keeping quoted
+// phrases together.
+func split(s string) ([]token, error) {
+	var tokens []token
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return tokens, nil
+		}
+
+		// A quote may follow a negation or filter prefix, e.g. -"foo bar".
+		if i := strings.IndexByte(s, '"'); i >= 0 && !strings.ContainsFunc(s[:i], unicode.IsSpace) {
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in query")
+			}
+			phrase := s[i+1 : i+1+end]
+			switch {
+			case strings.HasSuffix(s[:i], ":"):
+				tokens = append(tokens, token{text: s[:i] + phrase})
+			case i > 0:
+				tokens = append(tokens, token{text: s[:i]}, token{text: phrase, quoted: true})
+			default:
+				tokens = append(tokens, token{text: phrase, quoted: true})
+			}
+			s = s[i+2+end:]
+			continue
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		tokens = append(tokens, token{text: s[:end]})
+		s = s[end:]
+	}
+}
+
+func parseTerm(tok token) (term, bool) {
+	prefix := !tok.quoted && strings.HasSuffix(tok.text, "*")
+	words := tokenize(strings.TrimSuffix(tok.text, "*"))
+	if len(words) == 0 {
+		return term{}, false
+	}
+	return term{words: words, prefix: prefix && len(words) == 1}, true
+}
+
+// Hit is a message matching a query.
+type Hit struct {
+	ProjectID string
+	SessionID string
+	FilePath  string
+	MessageID string
+	Index     int // Index of the message within its file
+	Role      string
+	Model     string
+	Timestamp time.Time
+
+	Snippet    string
+	Highlights [][2]int // Byte ranges of matched words within Snippet
+}
+
+// msgRef identifies a message by file ID and message index.
+type msgRef struct {
+	file uint32
+	msg  uint32
+}
+
+// Search returns the messages matching the query, newest first. A limit of
+// zero returns all hits.
+func (idx *Index) Search(q Query, limit int) []Hit {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var candidates map[msgRef]bool
+	for _, group := range q.Groups {
+		matched := make(map[msgRef]bool)
+		for _, t := range group {
+			for ref := range idx.match(t) {
+				matched[ref] = true
+			}
+		}
+		if candidates == nil {
+			candidates = matched
+			continue
+		}
+		for ref := range candidates {
+			if !matched[ref] {
+				delete(candidates, ref)
+			}
+		}
+	}
+	if candidates == nil {
+		// Only filters and exclusions: start from every message.
+		candidates = make(map[msgRef]bool)
+		for _, f := range idx.files {
+			for i := range f.Messages {
+				candidates[msgRef{f.ID, uint32(i)}] = true
+			}
+		}
+	}
+	for _, t := range q.Exclude {
+		for ref := range idx.match(t) {
+			delete(candidates, ref)
+		}
+	}
+
+	var words []string
+	for _, group := range q.Groups {
+		for _, t := range group {
+			words = append(words, t.words...)
+		}
+	}
+
+	var hits []Hit
+	for ref := range candidates {
+		f := idx.byID[ref.file]
+		if f == nil || int(ref.msg) >= len(f.Messages) {
+			continue
+		}
+		msg := f.Messages[ref.msg]
+		if !q.accepts(f, msg) {
+			continue
+		}
+		snippet, highlights := makeSnippet(msg.Text, words)
+		hits = append(hits, Hit{
+			ProjectID:  f.ProjectID,
+			SessionID:  f.SessionID,
+			FilePath:   f.Path,
+			MessageID:  msg.ID,
+			Index:      int(ref.msg),
+			Role:       msg.Role,
+			Model:      msg.Model,
+			Timestamp:  msg.Timestamp,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if !hits[i].Timestamp.Equal(hits[j].Timestamp) {
+			return hits[i].Timestamp.After(hits[j].Timestamp)
+		}
+		if hits[i].FilePath != hits[j].FilePath {
+			return hits[i].FilePath < hits[j].FilePath
+		}
+		return hits[i].Index < hits[j].Index
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func (q Query) accepts(f *File, msg Message) bool {
+	if q.Project != "" && !strings.HasPrefix(f.ProjectID, q.Project) {
+		return false
+	}
+	if q.Session != "" && !strings.HasPrefix(strings.ToLower(f.SessionID), q.Session) {
+		return false
+	}
+	if q.Role != "" && !strings.EqualFold(msg.Role, q.Role) {
+		return false
+	}
+	if q.Model != "" && !strings.Contains(strings.ToLower(msg.Model), q.Model) {
+		return false
+	}
+	if !q.After.IsZero() && msg.Timestamp.Before(q.After) {
+		return false
+	}
+	if !q.Before.IsZero() && !msg.Timestamp.Before(q.Before) {
+		return false
+	}
+	return true
+}
+
+// match returns the messages containing a term.
+func (idx *Index) match(t term) map[msgRef]bool {
+	result := make(map[msgRef]bool)
+
+	if t.prefix {
+		terms := idx.sortedTerms()
+		for i := sort.SearchStrings(terms, t.words[0]); i < len(terms) && strings.HasPrefix(terms[i], t.words[0]); i++ {
+			addPostings(result, idx.terms[terms[i]])
+		}
+		return result
+	}
+
+	// Intersect the postings of all words; phrases are then verified
+	// against the message text.
+	for i, w := range t.words {
+		found := make(map[msgRef]bool)
+		addPostings(found, idx.terms[w])
+		if i == 0 {
+			result = found
+			continue
+		}
+		for ref := range result {
+			if !found[ref] {
+				delete(result, ref)
+			}
+		}
+	}
+	if len(t.words) > 1 {
+		for ref := range result {
+			f := idx.byID[ref.file]
+			if f == nil || !containsPhrase(tokenize(f.Messages[ref.msg].Text), t.words) {
+				delete(result, ref)
+			}
+		}
+	}
+	return result
+}
+
+func addPostings(set map[msgRef]bool, p postings) {
+	for file, msgs := range p {
+		for _, m := range msgs {
+			set[msgRef{file, m}] = true
+		}
+	}
+}
+
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// snippetRadius is the number of bytes shown around the first match.
+const snippetRadius = 60
+
+// makeSnippet cuts a single-line excerpt around the first occurrence of one of
+// the query words, or from the start of the text if none occurs, and returns
+// it along with the ranges of all occurrences.
+func makeSnippet(text string, words []string) (string, [][2]int) {
+	text = strings.Join(strings.Fields(text), " ")
+
+	spans := wordSpans(text, words)
+	start, end := 0, len(text)
+	if len(spans) > 0 {
+		start = spans[0][0] - snippetRadius
+		if start < 0 {
+			start = 0
+		} else if sp := strings.IndexByte(text[start:spans[0][0]], ' '); sp >= 0 {
+			start += sp + 1 // Don't start in the middle of a word
+		}
+	}
+	if end-start > 2*snippetRadius {
+		end = start + 2*snippetRadius
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	snippet := text[start:end]
+	offset := 0
+	if start > 0 {
+		snippet = "…" + snippet
+		offset = len("…") - start
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+
+	var highlights [][2]int
+	for _, s := range spans {
+		if s[0] >= start && s[1] <= end {
+			highlights = append(highlights, [2]int{s[0] + offset, s[1] + offset})
+		}
+	}
+	return snippet, highlights
+}
+
+// wordSpans returns the byte ranges of the words in text that equal or, for
+// prefix queries, start with one of the query words.
+func wordSpans(text string, words []string) [][2]int {
+	if len(words) == 0 {
+		return nil
+	}
+	var spans [][2]int
+	start := -1
+	for i, r := range text + " " {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			w := strings.ToLower(text[start:i])
+			for _, q := range words {
+				if strings.HasPrefix(w, q) {
+					spans = append(spans, [2]int{start, i})
+					break
+				}
+			}
+			start = -1
+		}
+	}
+	return spans
+}
@@ -0,0 +1,185 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/gemini"
+)
+
+func TestParse(t *testing.T) {
+	word := func(w ...string) term { return term{words: w} }
+	tests := []struct {
+		query   string
+		groups  [][]term
+		exclude []term
+		check   func(Query) bool
+	}{
+		{query: "foo bar", groups: [][]term{{word("foo")}, {word("bar")}}},
+		{query: `"Foo Bar"`, groups: [][]term{{word("foo", "bar")}}},
+		{query: "foo*", groups: [][]term{{{words: []string{"foo"}, prefix: true}}}},
+		{query: `"foo*"`, groups: [][]term{{word("foo")}}},
+		{query: "foo OR bar baz", groups: [][]term{{word("foo"), word("bar")}, {word("baz")}}},
+		{query: "OR foo", groups: [][]term{{word("foo")}}},
+		{query: "foo -bar NOT baz", groups: [][]term{{word("foo")}}, exclude: []term{word("bar"), word("baz")}},
+		{query: `-"foo bar"`, exclude: []term{word("foo", "bar")}},
+		{query: "- foo", exclude: []term{word("foo")}},
+		{query: "role:User project:ABC session:12 model:Pro", check: func(q Query) bool {
+			return q.Role == "user" && q.Project == "abc" && q.Session == "12" && q.Model == "pro"
+		}},
+		{query: `model:"gemini pro"`, check: func(q Query) bool { return q.Model == "gemini pro" }},
+		{query: "after:2025-01-02 before:2025-02-01T10:00:00Z", check: func(q Query) bool {
+			return q.After.Equal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)) &&
+				q.Before.Equal(time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC))
+		}},
+		{query: "http://example.com", groups: [][]term{{word("http", "example", "com")}}},
+		{query: "unknown:filter", groups: [][]term{{word("unknown", "filter")}}},
+		{query: "!!! ???"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(q.Groups, tt.groups) {
+			t.Errorf("Parse(%q).Groups = %v, want %v", tt.query, q.Groups, tt.groups)
+		}
+		if !reflect.DeepEqual(q.Exclude, tt.exclude) {
+			t.Errorf("Parse(%q).Exclude = %v, want %v", tt.query, q.Exclude, tt.exclude)
+		}
+		if tt.check != nil && !tt.check(q) {
+			t.Errorf("Parse(%q) = %+v: unexpected filters", tt.query, q)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		`"foo`, "after:yesterday", "before:2025-13-01",
+		// Negated filters would otherwise match what they exclude.
+		"-role:user foo", "NOT project:abc", "- session:12", "foo -model:pro", "-after:2025-01-01",
+	} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 20)
+	tests := []struct {
+		text, want string
+		words      []string
+		highlights []string
+	}{
+		{text: "Hello  world\nagain", words: []string{"world"}, want: "Hello world again", highlights: []string{"world"}},
+		{text: "Hello world", words: []string{"wor"}, want: "Hello world", highlights: []string{"world"}},
+		{text: "Hello world", words: nil, want: "Hello world"},
+		{text: "Hello world", words: []string{"missing"}, want: "Hello world"},
+		{text: "", words: []string{"x"}, want: ""},
+		{text: long, words: nil, want: strings.TrimSpace(long)[:2*snippetRadius] + "…"},
+		{text: long + "needle " + long, words: []string{"needle"}, highlights: []string{"needle"}},
+		{text: "ééééé needle", words: []string{"needle"}, want: "ééééé needle", highlights: []string{"needle"}},
+	}
+	for _, tt := range tests {
+		snippet, highlights := makeSnippet(tt.text, tt.words)
+		if tt.want != "" && snippet != tt.want {
+			t.Errorf("makeSnippet(%q, %q) = %q, want %q", tt.text, tt.words, snippet, tt.want)
+		}
+		var got []string
+		for _, h := range highlights {
+			got = append(got, snippet[h[0]:h[1]])
+		}
+		if !reflect.DeepEqual(got, tt.highlights) {
+			t.Errorf("makeSnippet(%q, %q) highlights %q, want %q", tt.text, tt.words, got, tt.highlights)
+		}
+	}
+}
+
+// writeSession writes a session file with the given messages, alternating
+// user and gemini messages, to a project in rootDir.
+func writeSession(t *testing.T, rootDir, projectID, sessionID string, contents ...string) {
+	t.Helper()
+	s := gemini.Session{ID: sessionID, ProjectHash: projectID, StartTime: "2025-01-01T10:00:00Z"}
+	for i, c := range contents {
+		typ := gemini.MessageUser
+		if i%2 == 1 {
+			typ = gemini.MessageGemini
+		}
+		s.Messages = append(s.Messages, gemini.Message{
+			ID:        sessionID + "-" + string(rune('a'+i)),
+			Timestamp: time.Date(2025, 1, 1, 10, i, 0, 0, time.UTC).Format(time.RFC3339),
+			Type:      typ,
+			Content:   c,
+		})
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(rootDir, projectID, gemini.SessionDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, gemini.SessionPrefix+sessionID+gemini.SessionSuffix), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	p1, p2 := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	writeSession(t, root, p1, "s1", "deploy the frontend", "The frontend is deployed", "thanks")
+	writeSession(t, root, p2, "s2", "fix the database migration", "Migration fixed")
+
+	idx := &Index{}
+	idx.reset()
+	if _, err := idx.Update(root); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string // Message IDs, newest first
+	}{
+		{"frontend", []string{"s1-b", "s1-a"}},
+		{"deploy*", []string{"s1-b", "s1-a"}},
+		{`"the frontend"`, []string{"s1-b", "s1-a"}},
+		{`"frontend the"`, nil},
+		{"frontend OR migration", []string{"s1-b", "s2-b", "s1-a", "s2-a"}},
+		{"frontend deploy", []string{"s1-a"}},
+		{"frontend -deployed", []string{"s1-a"}},
+		{"migration project:bbb", []string{"s2-b", "s2-a"}},
+		// Only filters or excluded terms.
+		{"role:user", []string{"s1-c", "s1-a", "s2-a"}},
+		{"-frontend", []string{"s1-c", "s2-b", "s2-a"}},
+		{"-nothing session:s2", []string{"s2-b", "s2-a"}},
+		{"after:2025-01-01T10:01:00Z before:2025-01-01T10:02:00Z", []string{"s1-b", "s2-b"}},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		var got []string
+		for _, h := range idx.Search(q, 0) {
+			got = append(got, h.MessageID)
+			if h.Snippet == "" {
+				t.Errorf("Search(%q): empty snippet for %s", tt.query, h.MessageID)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if hits := idx.Search(Query{}, 2); len(hits) != 2 {
+		t.Errorf("Search with limit 2 returned %d hits", len(hits))
+	}
+}
@@ -0,0 +1,458 @@
+package trash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+	"github.com/google/uuid"
+)
+
+// Default purge policy applied on startup.
+const (
+	DefaultMaxAge  = 30 * 24 * time.Hour
+	DefaultMaxSize = 1 << 30 // 1 GiB
+)
+
+const (
+	entryFile = "entry.json"
+	dataDir   = "data"
+)
+
+// Kind identifies what a trash entry holds.
+type Kind string
+
+const (
+	KindProject Kind = "project"
+	KindSession Kind = "session"
+)
+
+// Entry describes a deleted project or session held in the trash.
+type Entry struct {
+	ID          string            `json:"id"`
+	Kind        Kind              `json:"kind"`
+	ProjectID   string            `json:"projectId"`
+	ProjectPath string            `json:"projectPath,omitempty"`
+	SessionID   string            `json:"sessionId,omitempty"`
+	Files       []string          `json:"files,omitempty"` // Session file names within the chats directory
+	Logs        []gemini.LogEntry `json:"logs,omitempty"`  // Session entries pruned from logs.json
+	Size        int64             `json:"size"`
+	DeletedAt   time.Time         `json:"deletedAt"`
+
+	// Meta is what the user recorded about the project or session, and
+	// SessionMeta what they recorded about the sessions of a project, by
+	// session ID.
+	Meta        cache.Meta            `json:"meta,omitzero"`
+	SessionMeta map[string]cache.Meta `json:"sessionMeta,omitempty"`
+}
+
+// Trash is a geminictl-managed directory that deleted data is moved into so
+// that it can be restored later.
+type Trash struct {
+	Dir string
+}
+
+// New creates a Trash rooted in geminictl's state directory (see cache.Dir).
+func New(baseDir string) (*Trash, error) {
+	dir, err := cache.Dir(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Trash{Dir: filepath.Join(dir, "trash")}, nil
+}
+
+// DeleteProject moves an entire project directory into the trash, keeping
+// what the user recorded about the project and its sessions with the entry.
+func (t *Trash) DeleteProject(rootDir, projectID, projectPath string, meta cache.Meta, sessionMeta map[string]cache.Meta) (Entry, error) {
+	src := filepath.Join(rootDir, projectID)
+	size, err := dirSize(src)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e, dir, err := t.newEntry(KindProject, projectID, projectPath)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Size = size
+	e.Meta = meta
+	e.SessionMeta = sessionMeta
+
+	j, err := journal.Begin(t.Dir, "trash project "+projectID)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, err
+	}
+	if err := writeEntry(j, dir, e); err != nil {
+		return Entry{}, abort(j, dir, err)
+	}
+	if err := j.Move(src, filepath.Join(dir, dataDir)); err != nil {
+		return Entry{}, abort(j, dir, err)
+	}
+	return e, j.Commit()
+}
+
+// DeleteSession moves all files of a session into the trash. Its entries in
+// the project's logs.json are pruned and kept with the trash entry, as is
+// what the user recorded about the session.
+func (t *Trash) DeleteSession(rootDir, projectID, projectPath, sessionID string, meta cache.Meta) (Entry, error) {
+	sessions, err := gemini.ReadSessions(rootDir, projectID)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var files []string
+	var size int64
+	for _, s := range sessions {
+		if s.ID != sessionID {
+			continue
+		}
+		info, err := os.Stat(s.FilePath)
+		if err != nil {
+			return Entry{}, err
+		}
+		files = append(files, filepath.Base(s.FilePath))
+		size += info.Size()
+	}
+	if len(files) == 0 {
+		return Entry{}, fmt.Errorf("session %s not found in project %s", sessionID, projectID)
+	}
+
+	e, dir, err := t.newEntry(KindSession, projectID, projectPath)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.SessionID = sessionID
+	e.Files = files
+	e.Size = size
+	e.Meta = meta
+
+	if err := os.MkdirAll(filepath.Join(dir, dataDir), 0755); err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, err
+	}
+
+	j, err := journal.Begin(t.Dir, "trash session "+sessionID)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, err
+	}
+	if e.Logs, err = gemini.ExtractSessionLogs(j, rootDir, projectID, sessionID); err != nil {
+		return Entry{}, abort(j, dir, err)
+	}
+	if err := writeEntry(j, dir, e); err != nil {
+		return Entry{}, abort(j, dir, err)
+	}
+
+	chats := filepath.Join(rootDir, projectID, gemini.SessionDir)
+	for _, name := range files {
+		if err := j.Move(filepath.Join(chats, name), filepath.Join(dir, dataDir, name)); err != nil {
+			return Entry{}, abort(j, dir, err)
+		}
+	}
+	return e, j.Commit()
+}
+
+// Restore moves the data of a trash entry back into Gemini storage and
+// removes the entry from the trash.
+func (t *Trash) Restore(rootDir, id string) (Entry, error) {
+	dir := filepath.Join(t.Dir, id)
+	e, err := readEntry(dir)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	j, err := journal.Begin(t.Dir, "restore "+id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	switch e.Kind {
+	case KindProject:
+		dst := filepath.Join(rootDir, e.ProjectID)
+		if _, err := os.Stat(dst); err == nil {
+			return Entry{}, journal.Abort(j, fmt.Errorf("project %s already exists in storage", gemini.ShortID(e.ProjectID)))
+		}
+		if err := os.MkdirAll(rootDir, 0755); err != nil {
+			return Entry{}, journal.Abort(j, err)
+		}
+		if err := j.Move(filepath.Join(dir, dataDir), dst); err != nil {
+			return Entry{}, journal.Abort(j, err)
+		}
+	case KindSession:
+		chats := filepath.Join(rootDir, e.ProjectID, gemini.SessionDir)
+		if err := os.MkdirAll(chats, 0755); err != nil {
+			return Entry{}, journal.Abort(j, err)
+		}
+		for _, name := range e.Files {
+			if err := j.Move(filepath.Join(dir, dataDir, name), filepath.Join(chats, name)); err != nil {
+				return Entry{}, journal.Abort(j, err)
+			}
+		}
+		if err := gemini.AppendLogs(j, rootDir, e.ProjectID, e.Logs); err != nil {
+			return Entry{}, journal.Abort(j, err)
+		}
+	default:
+		return Entry{}, journal.Abort(j, fmt.Errorf("unknown trash entry kind %q", e.Kind))
+	}
+
+	if err := j.Remove(dir); err != nil {
+		return Entry{}, journal.Abort(j, err)
+	}
+	return e, j.Commit()
+}
+
+// RestoreCache records the path of a restored project, and what the user
+// recorded about the project or session of the entry, in c again. What c
+// records in the meantime is kept. An alias meanwhile given to another
+// project is left out and reported as an error.
+func (e Entry) RestoreCache(c *cache.Cache) error {
+	if e.Kind == KindSession {
+		if !e.Meta.IsZero() && c.SessionMeta(e.SessionID).IsZero() {
+			c.SetSessionMeta(e.SessionID, e.Meta)
+		}
+		return nil
+	}
+
+	if e.ProjectPath != "" {
+		c.Set(e.ProjectID, e.ProjectPath)
+	}
+	for id, m := range e.SessionMeta {
+		if c.SessionMeta(id).IsZero() {
+			c.SetSessionMeta(id, m)
+		}
+	}
+	if e.Meta.IsZero() || !c.ProjectMeta(e.ProjectID).IsZero() {
+		return nil
+	}
+	err := c.SetProjectMeta(e.ProjectID, e.Meta)
+	if err != nil {
+		m := e.Meta
+		m.Alias = ""
+		_ = c.SetProjectMeta(e.ProjectID, m)
+	}
+	return err
+}
+
+// ForgetCache removes the record of the project, or what the user recorded
+// about the session, of a permanently deleted entry from c. Projects that
+// are back in storage, e.g. because Gemini CLI was run in their directory
+// again, are kept.
+func (e Entry) ForgetCache(c *cache.Cache, rootDir string) {
+	if e.Kind == KindSession {
+		for _, name := range e.Files {
+			if _, err := os.Stat(filepath.Join(rootDir, e.ProjectID, gemini.SessionDir, name)); err == nil {
+				return
+			}
+		}
+		c.ForgetSession(e.SessionID)
+		return
+	}
+
+	if _, err := os.Stat(filepath.Join(rootDir, e.ProjectID)); err == nil {
+		return
+	}
+	c.Forget(e.ProjectID)
+	for id := range e.SessionMeta {
+		c.ForgetSession(id)
+	}
+}
+
+// Recover completes or rolls back a trash operation that was interrupted and
+// removes entries left incomplete by it.
+func (t *Trash) Recover() (string, error) {
+	op, err := journal.Recover(t.Dir)
+	if err != nil {
+		return op, err
+	}
+
+	dirs, err := os.ReadDir(t.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return op, nil
+		}
+		return op, err
+	}
+	for _, d := range dirs {
+		if !d.IsDir() || d.Name() == journal.DirName {
+			continue
+		}
+		dir := filepath.Join(t.Dir, d.Name())
+		if _, err := os.Stat(filepath.Join(dir, entryFile)); os.IsNotExist(err) {
+			_ = os.RemoveAll(dir)
+		}
+	}
+	return op, nil
+}
+
+// List returns all trash entries, most recently deleted first.
+func (t *Trash) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(t.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		e, err := readEntry(filepath.Join(t.Dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// Remove permanently deletes a single trash entry.
+func (t *Trash) Remove(id string) error {
+	dir := filepath.Join(t.Dir, id)
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// Empty permanently deletes all trash entries deleted before the given time.
+// A zero time removes everything. It returns the removed entries.
+func (t *Trash) Empty(before time.Time) ([]Entry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Entry
+	for _, e := range entries {
+		if !before.IsZero() && !e.DeletedAt.Before(before) {
+			continue
+		}
+		if err := t.Remove(e.ID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Purge enforces the retention policy: entries older than maxAge are removed,
+// then the oldest remaining entries are removed until the trash fits into
+// maxSize bytes. A zero value disables the respective limit. It returns the
+// removed entries.
+func (t *Trash) Purge(maxAge time.Duration, maxSize int64) ([]Entry, error) {
+	var removed []Entry
+	if maxAge > 0 {
+		var err error
+		if removed, err = t.Empty(time.Now().Add(-maxAge)); err != nil {
+			return removed, err
+		}
+	}
+	if maxSize <= 0 {
+		return removed, nil
+	}
+
+	entries, err := t.List()
+	if err != nil {
+		return removed, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	// Entries are sorted newest first, so drop from the end.
+	for i := len(entries) - 1; i >= 0 && total > maxSize; i-- {
+		if err := t.Remove(entries[i].ID); err != nil {
+			return removed, err
+		}
+		total -= entries[i].Size
+		removed = append(removed, entries[i])
+	}
+	return removed, nil
+}
+
+func (t *Trash) newEntry(kind Kind, projectID, projectPath string) (Entry, string, error) {
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return Entry{}, "", err
+	}
+	for {
+		id := uuid.NewString()[:8]
+		dir := filepath.Join(t.Dir, id)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			return Entry{}, "", err
+		}
+		return Entry{
+			ID:          id,
+			Kind:        kind,
+			ProjectID:   projectID,
+			ProjectPath: projectPath,
+			DeletedAt:   time.Now(),
+		}, dir, nil
+	}
+}
+
+func readEntry(dir string) (Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, entryFile))
+	if err != nil {
+		return Entry{}, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+func writeEntry(j *journal.Journal, dir string, e Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return j.WriteFile(filepath.Join(dir, entryFile), data, 0644)
+}
+
+// abort rolls back a failed operation with journal.Abort and removes the
+// entry directory it was creating. The directory is kept if the rollback
+// failed, as the journal may still have to move data out of it.
+func abort(j *journal.Journal, entryDir string, err error) error {
+	if abortErr := journal.Abort(j, err); abortErr != err {
+		return abortErr
+	}
+	_ = os.RemoveAll(entryDir)
+	return err
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
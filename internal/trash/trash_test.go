@@ -0,0 +1,253 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/journal"
+)
+
+// writeFile writes a file, creating its directory.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setup creates a project with two sessions, the first split across two
+// files, and returns the storage root, the trash and the project ID.
+func setup(t *testing.T) (string, *Trash, string) {
+	t.Helper()
+	root := t.TempDir()
+	tr := &Trash{Dir: filepath.Join(t.TempDir(), "trash")}
+	id := gemini.HashPath("/project")
+	chats := filepath.Join(root, id, gemini.SessionDir)
+	writeFile(t, filepath.Join(chats, "session-2025-01-01T10-00-s1.json"), `{"sessionId":"s1","messages":[]}`)
+	writeFile(t, filepath.Join(chats, "session-2025-01-01T10-00-s1-2.json"), `{"sessionId":"s1","messages":[]}`)
+	writeFile(t, filepath.Join(chats, "session-2025-01-02T10-00-s2.json"), `{"sessionId":"s2","messages":[]}`)
+	writeFile(t, gemini.LogsPath(root, id),
+		`[{"sessionId":"s1","messageId":0,"type":"user","message":"a","timestamp":"2025-01-01T10:00:00Z"},`+
+			`{"sessionId":"s2","messageId":0,"type":"user","message":"b","timestamp":"2025-01-02T10:00:00Z"},`+
+			`{"sessionId":"s1","messageId":1,"type":"user","message":"c","timestamp":"2025-01-03T10:00:00Z"}]`)
+	return root, tr, id
+}
+
+// files returns the names of the files below dir.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	var names []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			names = append(names, rel)
+		}
+		return nil
+	})
+	return names
+}
+
+// messages returns the messages of log entries.
+func messages(logs []gemini.LogEntry) []string {
+	var out []string
+	for _, e := range logs {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+func TestDeleteAndRestoreProject(t *testing.T) {
+	root, tr, id := setup(t)
+	before := files(t, root)
+	meta := cache.Meta{Alias: "api", Tags: []string{"work"}}
+	sessionMeta := map[string]cache.Meta{"s1": {Name: "first"}}
+
+	e, err := tr.DeleteProject(root, id, "/project", meta, sessionMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, id)); !os.IsNotExist(err) {
+		t.Errorf("project still in storage")
+	}
+	entries, err := tr.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != e.ID || entries[0].Meta.Alias != "api" || entries[0].SessionMeta["s1"].Name != "first" {
+		t.Fatalf("List() = %+v, %v", entries, err)
+	}
+
+	// Restoring fails while the project is back in storage.
+	writeFile(t, filepath.Join(root, id, "logs.json"), "[]")
+	if _, err := tr.Restore(root, e.ID); err == nil {
+		t.Errorf("Restore over an existing project succeeded")
+	}
+	os.RemoveAll(filepath.Join(root, id))
+
+	if _, err := tr.Restore(root, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if after := files(t, root); !reflect.DeepEqual(after, before) {
+		t.Errorf("restored files %v, want %v", after, before)
+	}
+	if entries, _ := tr.List(); len(entries) != 0 {
+		t.Errorf("entry left in the trash: %+v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(tr.Dir, journal.DirName)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind")
+	}
+}
+
+func TestDeleteAndRestoreSession(t *testing.T) {
+	root, tr, id := setup(t)
+	before := files(t, root)
+	logs, _ := gemini.ReadLogs(root, id)
+
+	e, err := tr.DeleteSession(root, id, "/project", "s1", cache.Meta{Name: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Files) != 2 || len(e.Logs) != 2 {
+		t.Errorf("entry holds files %v and %d log entries, want 2 of each", e.Files, len(e.Logs))
+	}
+	if remaining, _ := gemini.ReadLogs(root, id); len(remaining) != 1 || remaining[0].SessionID != "s2" {
+		t.Errorf("logs after delete = %+v", remaining)
+	}
+	if _, err := tr.DeleteSession(root, id, "/project", "missing", cache.Meta{}); err == nil {
+		t.Errorf("DeleteSession of a missing session succeeded")
+	}
+
+	if _, err := tr.Restore(root, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if after := files(t, root); !reflect.DeepEqual(after, before) {
+		t.Errorf("restored files %v, want %v", after, before)
+	}
+	restored, _ := gemini.ReadLogs(root, id)
+	if got, want := messages(restored), messages(logs); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored logs %q, want %q", got, want)
+	}
+}
+
+func TestRestoreCache(t *testing.T) {
+	id := gemini.HashPath("/project")
+	entry := Entry{
+		Kind:        KindProject,
+		ProjectID:   id,
+		ProjectPath: "/project",
+		Meta:        cache.Meta{Alias: "api", Note: "kept"},
+		SessionMeta: map[string]cache.Meta{"s1": {Name: "first"}, "s2": {Name: "second"}},
+	}
+	c, err := cache.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("other", "/other")
+	c.SetProjectMeta("other", cache.Meta{Alias: "api"})
+	c.SetSessionMeta("s2", cache.Meta{Name: "renamed meanwhile"})
+
+	// The alias was given to another project in the meantime.
+	if err := entry.RestoreCache(c); err == nil {
+		t.Errorf("RestoreCache did not report the alias conflict")
+	}
+	if path, ok := c.Get(id); !ok || path != "/project" {
+		t.Errorf("Get = %q, %v", path, ok)
+	}
+	if m := c.ProjectMeta(id); m.Alias != "" || m.Note != "kept" {
+		t.Errorf("ProjectMeta = %+v, want the note without the alias", m)
+	}
+	if c.SessionMeta("s1").Name != "first" || c.SessionMeta("s2").Name != "renamed meanwhile" {
+		t.Errorf("sessions = %v", c.Sessions)
+	}
+
+	// Forgetting keeps projects that are back in storage.
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, id, "logs.json"), "[]")
+	entry.ForgetCache(c, root)
+	if _, ok := c.Get(id); !ok {
+		t.Errorf("ForgetCache removed a project in storage")
+	}
+	entry.ForgetCache(c, t.TempDir())
+	if _, ok := c.Get(id); ok || !c.SessionMeta("s1").IsZero() {
+		t.Errorf("ForgetCache kept the project or its sessions")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	tr := &Trash{Dir: t.TempDir()}
+	now := time.Now()
+	add := func(id string, age time.Duration, size int64) {
+		e := Entry{ID: id, Kind: KindSession, Size: size, DeletedAt: now.Add(-age)}
+		j, err := journal.Begin(tr.Dir, "add")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Join(tr.Dir, id), 0755)
+		if err := writeEntry(j, filepath.Join(tr.Dir, id), e); err != nil {
+			t.Fatal(err)
+		}
+		j.Commit()
+	}
+	ids := func(entries []Entry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		maxAge  time.Duration
+		maxSize int64
+		removed []string
+		kept    []string
+	}{
+		{0, 0, nil, []string{"new", "mid", "old"}},
+		{48 * time.Hour, 0, []string{"old"}, []string{"new", "mid"}},
+		{0, 25, []string{"old", "mid"}, []string{"new"}},
+		{0, 30, []string{"old"}, []string{"new", "mid"}},
+		{time.Minute, 100, []string{"mid", "old"}, []string{"new"}},
+	}
+	for _, tt := range tests {
+		os.RemoveAll(tr.Dir)
+		add("new", 0, 10)
+		add("mid", 24*time.Hour, 20)
+		add("old", 72*time.Hour, 5)
+
+		removed, err := tr.Purge(tt.maxAge, tt.maxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(removed); !reflect.DeepEqual(got, tt.removed) {
+			t.Errorf("Purge(%v, %d) removed %v, want %v", tt.maxAge, tt.maxSize, got, tt.removed)
+		}
+		if entries, _ := tr.List(); !reflect.DeepEqual(ids(entries), tt.kept) {
+			t.Errorf("Purge(%v, %d) kept %v, want %v", tt.maxAge, tt.maxSize, ids(entries), tt.kept)
+		}
+	}
+}
+
+func TestRecoverRemovesIncompleteEntries(t *testing.T) {
+	root, tr, id := setup(t)
+	e, err := tr.DeleteSession(root, id, "/project", "s2", cache.Meta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An entry directory left behind before its entry.json was written.
+	os.MkdirAll(filepath.Join(tr.Dir, "partial", dataDir), 0755)
+
+	if _, err := tr.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tr.Dir, "partial")); !os.IsNotExist(err) {
+		t.Errorf("incomplete entry kept")
+	}
+	if entries, _ := tr.List(); len(entries) != 1 || entries[0].ID != e.ID {
+		t.Errorf("List() = %+v, want the complete entry", entries)
+	}
+}
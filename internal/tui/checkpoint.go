@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"geminictl/internal/scanner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+// Checkpoint actions selectable in the CheckpointModal.
+const (
+	CheckpointInspect = "inspect"
+	CheckpointDelete  = "delete"
+	CheckpointRename  = "rename"
+	CheckpointCopy    = "copy"
+	CheckpointPromote = "promote"
+)
+
+// CheckpointAction is the result value of a CheckpointModal.
+type CheckpointAction struct {
+	Kind string
+	Name string
+}
+
+// CheckpointModal lists the checkpoints of a project and lets the user pick
+// an action for one of them.
+type CheckpointModal struct {
+	Title       string
+	Checkpoints []scanner.Checkpoint
+	Cursor      int
+}
+
+func (m CheckpointModal) Init() tea.Cmd { return nil }
+func (m CheckpointModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		action := ""
+		switch msg.String() {
+		case "up", "k":
+			if m.Cursor > 0 {
+				m.Cursor--
+			}
+		case "down", "j":
+			if m.Cursor < len(m.Checkpoints)-1 {
+				m.Cursor++
+			}
+		case "enter", " ":
+			action = CheckpointInspect
+		case "d":
+			action = CheckpointDelete
+		case "r":
+			action = CheckpointRename
+		case "c":
+			action = CheckpointCopy
+		case "p":
+			action = CheckpointPromote
+		case "esc", "q":
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		}
+		if action != "" && len(m.Checkpoints) > 0 {
+			name := m.Checkpoints[m.Cursor].Name
+			return m, func() tea.Msg { return ModalResult{Value: CheckpointAction{Kind: action, Name: name}} }
+		}
+	}
+	return m, nil
+}
+
+func (m CheckpointModal) View(w, h int) string {
+	var b strings.Builder
+	if len(m.Checkpoints) == 0 {
+		b.WriteString("No checkpoints.")
+	}
+	for i, cp := range m.Checkpoints {
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if m.Cursor == i {
+			cursor = "> "
+			style = style.Foreground(special)
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, style.Render(cp.Name),
+			lipgloss.NewStyle().Foreground(subtle).Render(fmt.Sprintf("%d messages | %s | %s",
+				cp.MessageCount, humanize.IBytes(uint64(cp.Size)), formatRelativeTime(cp.SavedAt)))))
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(subtle).Render("\n(enter inspect, d delete, r rename, c copy, p promote to session, esc close)"))
+	return renderModal(w, h, m.Title, b.String())
+}
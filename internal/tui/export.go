@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"geminictl/internal/export"
+	"geminictl/internal/gemini"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Rows of the ExportModal.
+const (
+	exportRowFormat = iota
+	exportRowThoughts
+	exportRowTokens
+	exportRowTimestamps
+	exportRowPath
+)
+
+// exportRequest is the result value of the ExportModal.
+type exportRequest struct {
+	Format  string
+	Options export.Options
+	Path    string
+}
+
+// ExportModal chooses the format, the contents and the destination of an
+// export. The destination is a file, or a directory when exporting every
+// session of a project.
+type ExportModal struct {
+	Title  string
+	dir    bool
+	format int // Index in export.Formats
+	opts   export.Options
+	row    int
+	input  textinput.Model
+}
+
+func NewExportModal(title, path string, dir bool) ExportModal {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Width = 41
+	ti.SetValue(path)
+	ti.CursorEnd()
+	return ExportModal{
+		Title: title,
+		dir:   dir,
+		opts:  export.Options{Timestamps: true},
+		input: ti,
+	}
+}
+
+func (m ExportModal) Init() tea.Cmd { return nil }
+
+func (m ExportModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	switch key.String() {
+	case "esc":
+		return m, func() tea.Msg { return ModalResult{Canceled: true} }
+	case "enter":
+		path := strings.TrimSpace(m.input.Value())
+		if path == "" {
+			return m, nil
+		}
+		req := exportRequest{Format: export.Formats[m.format], Options: m.opts, Path: path}
+		return m, func() tea.Msg { return ModalResult{Value: req} }
+	case "up", "shift+tab":
+		return m.focusRow(max(m.row-1, exportRowFormat))
+	case "down", "tab":
+		return m.focusRow(min(m.row+1, exportRowPath))
+	}
+	if m.row == exportRowPath {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch key.String() {
+	case "k":
+		return m.focusRow(max(m.row-1, exportRowFormat))
+	case "j":
+		return m.focusRow(min(m.row+1, exportRowPath))
+	case "left", "h":
+		if m.row == exportRowFormat {
+			m.setFormat((m.format + len(export.Formats) - 1) % len(export.Formats))
+		}
+	case "right", "l", " ", "x":
+		switch m.row {
+		case exportRowFormat:
+			m.setFormat((m.format + 1) % len(export.Formats))
+		case exportRowThoughts:
+			m.opts.Thoughts = !m.opts.Thoughts
+		case exportRowTokens:
+			m.opts.Tokens = !m.opts.Tokens
+		case exportRowTimestamps:
+			m.opts.Timestamps = !m.opts.Timestamps
+		}
+	}
+	return m, nil
+}
+
+// focusRow moves to a row, giving the focus to the input on the path row.
+func (m ExportModal) focusRow(row int) (Modal, tea.Cmd) {
+	m.row = row
+	if row == exportRowPath {
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	}
+	m.input.Blur()
+	return m, nil
+}
+
+// setFormat changes the format, and the extension of the file to match it.
+func (m *ExportModal) setFormat(i int) {
+	m.format = i
+	path := m.input.Value()
+	if _, ok := export.FormatOf(path); ok && !m.dir {
+		m.input.SetValue(strings.TrimSuffix(path, filepath.Ext(path)) + "." + export.Formats[i])
+	}
+}
+
+func (m ExportModal) View(w, h int) string {
+	var b strings.Builder
+	selected := lipgloss.NewStyle().Foreground(special)
+	row := func(i int, label, value string) {
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if m.row == i {
+			cursor, style = "> ", selected
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, style.Render(fmt.Sprintf("%-11s", label)), value))
+	}
+	check := func(on bool) string {
+		if on {
+			return "[x]"
+		}
+		return "[ ]"
+	}
+
+	row(exportRowFormat, "Format", "‹ "+export.FormatNames[export.Formats[m.format]]+" ›")
+	row(exportRowThoughts, "Thoughts", check(m.opts.Thoughts))
+	row(exportRowTokens, "Tokens", check(m.opts.Tokens))
+	row(exportRowTimestamps, "Timestamps", check(m.opts.Timestamps))
+	label := "File"
+	if m.dir {
+		label = "Directory"
+	}
+	row(exportRowPath, label, m.input.View())
+
+	help := lipgloss.NewStyle().Foreground(subtle).Render("(↑/↓ move, ←/→ format, space toggle,\n enter export, esc cancel)")
+	return renderModal(w, h, m.Title, b.String()+"\n"+help)
+}
+
+// startExport opens the modal exporting the focused session, or every
+// session of the selected project when the projects pane is focused.
+func (m *Model) startExport() (tea.Model, tea.Cmd) {
+	if len(m.Projects) == 0 {
+		return m, nil
+	}
+	p := m.Projects[m.Selected]
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "."
+	}
+	if m.Focus == FocusSessions {
+		if len(p.Sessions) == 0 {
+			return m, nil
+		}
+		s := p.Sessions[m.SessionCursor]
+		m.exportSession = s.ID
+		name := export.FileName(gemini.Session{ID: s.ID, StartTime: s.StartTime.Format(time.RFC3339)},
+			m.cache.SessionTitle(s.ID, s.Title), export.Markdown)
+		m.modal = NewExportModal(fmt.Sprintf("Export session [%s]", gemini.ShortID(s.ID)), filepath.Join(cwd, name), false)
+	} else {
+		if len(p.Sessions) == 0 {
+			return m, nil
+		}
+		m.exportSession = ""
+		name := gemini.ShortID(p.ID) + "-sessions"
+		if p.Path != "" {
+			name = filepath.Base(p.Path) + "-sessions"
+		}
+		m.modal = NewExportModal(fmt.Sprintf("Export %s of project [%s]", plural(len(p.Sessions), "session"), gemini.ShortID(p.ID)),
+			filepath.Join(cwd, name), true)
+	}
+	m.Mode = ModeExport
+	return m, m.modal.Init()
+}
+
+// applyExport writes the export chosen in the ExportModal and returns a
+// description of what was written.
+func (m *Model) applyExport(req exportRequest) (string, error) {
+	if len(m.Projects) == 0 {
+		return "", nil
+	}
+	p := m.Projects[m.Selected]
+	path := req.Path
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	if m.exportSession != "" {
+		s, err := gemini.GetSession(m.scanner.RootDir, p.ID, m.exportSession)
+		if err != nil {
+			return "", err
+		}
+		req.Options.Title = m.cache.SessionTitle(s.ID, gemini.SummarizeSession(s).Title)
+		if err := export.WriteFile(path, s, req.Format, req.Options); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Exported session [%s] to %s", gemini.ShortID(s.ID), path), nil
+	}
+
+	sessions, err := gemini.Sessions(m.scanner.RootDir, p.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sessions {
+		title := m.cache.SessionTitle(s.ID, gemini.SummarizeSession(s).Title)
+		req.Options.Title = title
+		if err := export.WriteFile(filepath.Join(path, export.FileName(s, title, req.Format)), s, req.Format, req.Options); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Exported %s to %s", plural(len(sessions), "session"), path), nil
+}
@@ -0,0 +1,740 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"geminictl/internal/gemini"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Colors of the message types without a color of their own in the palette.
+var (
+	infoColor    = lipgloss.AdaptiveColor{Light: "#1F6FEB", Dark: "#58A6FF"}
+	cautionColor = lipgloss.AdaptiveColor{Light: "#B08800", Dark: "#E3B341"}
+	toolColor    = lipgloss.AdaptiveColor{Light: "#D46B08", Dark: "#FFA657"}
+	mutedColor   = lipgloss.AdaptiveColor{Light: "#6E7781", Dark: "#8B949E"}
+)
+
+// messageStyle returns the label and style of a message type.
+func messageStyle(typ string) (string, lipgloss.Style) {
+	style := lipgloss.NewStyle().Bold(true).Foreground(mutedColor)
+	switch typ {
+	case gemini.MessageUser:
+		style = style.Foreground(special)
+	case gemini.MessageGemini:
+		style = style.Foreground(highlight)
+	case gemini.MessageInfo:
+		style = style.Foreground(infoColor)
+	case gemini.MessageWarning:
+		style = style.Foreground(cautionColor)
+	case gemini.MessageError:
+		style = style.Foreground(warning)
+	}
+	return gemini.TypeLabel(typ), style
+}
+
+// --- Inspect Session Modal ---
+
+// Inputs of the bar at the bottom of the InspectModal.
+const (
+	inspectInputNone = iota
+	inspectInputSearch
+	inspectInputGoto
+)
+
+// inspectMatch is a match of the search in a line of the viewport, as byte
+// offsets into the line without styling.
+type inspectMatch struct {
+	line, start, end int
+}
+
+// InspectModal shows the messages of a session in a scrollable viewport.
+type InspectModal struct {
+	Title    string
+	Session  gemini.Session
+	viewport viewport.Model
+	ready    bool
+	width    int
+
+	// showThoughts expands the chain-of-thought of every message.
+	showThoughts bool
+
+	// raw shows the content of the messages as written instead of
+	// rendering it as markdown.
+	raw bool
+	// markdown renders the messages at the current width, caching them by
+	// message index until the width changes.
+	markdown *glamour.TermRenderer
+	rendered map[int]string
+
+	// lines holds the rendered content and msgLines the line each message
+	// starts at.
+	lines    []string
+	msgLines []int
+
+	// The search highlights the matches of query in the lines, as a regular
+	// expression if regex is set. It ignores case unless matchCase is set.
+	input     textinput.Model
+	inputMode int
+	query     string
+	regex     bool
+	matchCase bool
+	searchErr error
+	matches   []inspectMatch
+	current   int
+
+	// showFiles replaces the messages with the raw JSON of the session
+	// files, of which the one at fileIndex is shown in tree.
+	showFiles bool
+	fileIndex int
+	tree      *jsonTree
+	treeErr   error
+}
+
+func NewInspectModal(s gemini.Session) *InspectModal {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	return &InspectModal{
+		Title:   fmt.Sprintf("Inspect Session [%s]", gemini.ShortID(s.ID)),
+		Session: s,
+		input:   ti,
+	}
+}
+
+func (m *InspectModal) Init() tea.Cmd { return nil }
+
+func (m *InspectModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.inputMode != inspectInputNone {
+			return m, m.updateInput(msg)
+		}
+		if m.showFiles {
+			return m, m.updateFiles(msg)
+		}
+		switch msg.String() {
+		case "esc":
+			if m.query != "" {
+				m.setQuery("")
+				return m, nil
+			}
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		case "q", " ":
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		case "/":
+			return m, m.openInput(inspectInputSearch, "/", "")
+		case ":":
+			return m, m.openInput(inspectInputGoto, "Go to message #", "")
+		case "J":
+			m.showFiles = true
+			if m.tree == nil && m.treeErr == nil && len(m.Session.Files) > 0 {
+				m.openFile(0)
+			}
+			return m, nil
+		case "n":
+			m.nextMatch(1)
+			return m, nil
+		case "N":
+			m.nextMatch(-1)
+			return m, nil
+		case "]":
+			m.nextPrompt(1)
+			return m, nil
+		case "[":
+			m.nextPrompt(-1)
+			return m, nil
+		case "t":
+			m.showThoughts = !m.showThoughts
+			m.refresh()
+			return m, nil
+		case "r":
+			m.raw = !m.raw
+			m.refresh()
+			return m, nil
+		}
+	case tea.WindowSizeMsg:
+		// Viewport needs to be initialized or resized: the border and
+		// padding, the title and the status and help lines take 8 lines.
+		verticalMargin := 8
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width-10, msg.Height-verticalMargin)
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width - 10
+			m.viewport.Height = msg.Height - verticalMargin
+		}
+		if width := msg.Width - 12; width != m.width {
+			m.width = width
+			m.markdown, m.rendered = nil, nil
+		}
+		m.refresh()
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// refresh renders the content again, keeping the scroll position where
+// possible.
+func (m *InspectModal) refresh() {
+	if !m.ready {
+		return
+	}
+	m.lines = strings.Split(m.formatContent(m.width), "\n")
+	m.search()
+}
+
+// openInput focuses the bar at the bottom on an input of the given mode.
+func (m *InspectModal) openInput(mode int, prompt, value string) tea.Cmd {
+	m.inputMode = mode
+	m.input.Prompt = prompt
+	m.input.Placeholder = ""
+	if mode == inspectInputGoto {
+		m.input.Placeholder = fmt.Sprintf("1-%d", len(m.Session.Messages))
+	}
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// updateInput handles the keys typed in the bar. The search is updated as
+// the query is typed.
+func (m *InspectModal) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		if m.inputMode == inspectInputSearch {
+			m.setQuery("")
+		}
+		m.closeInput()
+		return nil
+	case "enter":
+		if m.inputMode == inspectInputSearch {
+			m.expandMatchingThoughts()
+		}
+		if m.inputMode == inspectInputGoto {
+			if n, err := strconv.Atoi(strings.TrimSpace(m.input.Value())); err == nil && n >= 1 && n <= len(m.msgLines) {
+				m.viewport.SetYOffset(m.msgLines[n-1])
+			}
+		}
+		m.closeInput()
+		return nil
+	case "ctrl+r":
+		if m.inputMode == inspectInputSearch {
+			m.regex = !m.regex
+			m.search()
+			m.showMatch()
+		}
+		return nil
+	case "ctrl+t":
+		if m.inputMode == inspectInputSearch {
+			m.matchCase = !m.matchCase
+			m.search()
+			m.showMatch()
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.inputMode == inspectInputSearch && m.input.Value() != m.query {
+		m.setQuery(m.input.Value())
+	}
+	return cmd
+}
+
+func (m *InspectModal) closeInput() {
+	m.inputMode = inspectInputNone
+	m.input.Blur()
+}
+
+// setQuery searches for query, moving to the first match from the top of
+// the view.
+func (m *InspectModal) setQuery(query string) {
+	m.query = query
+	m.current = -1
+	m.search()
+	m.showMatch()
+}
+
+// searchPattern compiles the query with the regex and case options.
+func (m *InspectModal) searchPattern() (*regexp.Regexp, error) {
+	expr := m.query
+	if !m.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !m.matchCase {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// search finds the matches of the query in the lines and sets the content
+// of the viewport with the matches highlighted. Without a current match,
+// the first one from the top of the view becomes current.
+func (m *InspectModal) search() {
+	m.matches, m.searchErr = nil, nil
+	offset := m.viewport.YOffset
+	defer func() {
+		m.highlight()
+		m.viewport.SetYOffset(offset)
+	}()
+	if m.query == "" {
+		return
+	}
+	re, err := m.searchPattern()
+	if err != nil {
+		m.searchErr = err
+		return
+	}
+	for i, line := range m.lines {
+		for _, loc := range re.FindAllStringIndex(ansi.Strip(line), -1) {
+			if loc[1] > loc[0] {
+				m.matches = append(m.matches, inspectMatch{line: i, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	m.current = min(m.current, len(m.matches)-1)
+	if m.current < 0 && len(m.matches) > 0 {
+		m.current = 0
+		for i, match := range m.matches {
+			if match.line >= offset {
+				m.current = i
+				break
+			}
+		}
+	}
+}
+
+// expandMatchingThoughts expands the thoughts when the query matches
+// collapsed ones, so that their matches are shown.
+func (m *InspectModal) expandMatchingThoughts() {
+	if m.showThoughts || m.query == "" {
+		return
+	}
+	re, err := m.searchPattern()
+	if err != nil || !thoughtsMatch(m.Session.Messages, re) {
+		return
+	}
+	m.showThoughts = true
+	m.refresh()
+	if len(m.matches) > 0 {
+		m.current = max(m.current, 0)
+		m.highlight()
+		m.showMatch()
+	}
+}
+
+// thoughtsMatch reports whether the thoughts of any message match re.
+func thoughtsMatch(messages []gemini.Message, re *regexp.Regexp) bool {
+	for _, msg := range messages {
+		for _, t := range msg.Thoughts {
+			if re.MatchString(t.Subject) || re.MatchString(t.Description) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// highlight sets the content of the viewport. The lines with matches lose
+// their styling so that the matches stand out, the current one most.
+func (m *InspectModal) highlight() {
+	if len(m.matches) == 0 {
+		m.viewport.SetContent(strings.Join(m.lines, "\n"))
+		return
+	}
+	matchStyle := lipgloss.NewStyle().Reverse(true)
+	currentStyle := lipgloss.NewStyle().Background(cautionColor).Foreground(lipgloss.Color("#000000")).Bold(true)
+
+	lines := slices.Clone(m.lines)
+	for i := 0; i < len(m.matches); {
+		line := m.matches[i].line
+		plain := ansi.Strip(m.lines[line])
+		var b strings.Builder
+		pos := 0
+		for ; i < len(m.matches) && m.matches[i].line == line; i++ {
+			match := m.matches[i]
+			style := matchStyle
+			if i == m.current {
+				style = currentStyle
+			}
+			b.WriteString(plain[pos:match.start])
+			b.WriteString(style.Render(plain[match.start:match.end]))
+			pos = match.end
+		}
+		b.WriteString(plain[pos:])
+		lines[line] = b.String()
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// nextMatch moves to the next match in direction dir, wrapping around.
+func (m *InspectModal) nextMatch(dir int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.current = (m.current + dir + len(m.matches)) % len(m.matches)
+	offset := m.viewport.YOffset
+	m.highlight()
+	m.viewport.SetYOffset(offset)
+	m.showMatch()
+}
+
+// showMatch scrolls the current match into view, a third down the viewport
+// when it has to scroll.
+func (m *InspectModal) showMatch() {
+	if m.current < 0 {
+		return
+	}
+	line := m.matches[m.current].line
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/3))
+	}
+}
+
+// nextPrompt scrolls to the next user prompt below the top of the view in
+// direction dir.
+func (m *InspectModal) nextPrompt(dir int) {
+	top := m.viewport.YOffset
+	for i := range m.msgLines {
+		if dir < 0 {
+			i = len(m.msgLines) - 1 - i
+		}
+		if m.Session.Messages[i].Type != gemini.MessageUser {
+			continue
+		}
+		if (dir > 0 && m.msgLines[i] > top) || (dir < 0 && m.msgLines[i] < top) {
+			m.viewport.SetYOffset(m.msgLines[i])
+			return
+		}
+	}
+}
+
+func (m *InspectModal) formatContent(width int) string {
+	var b strings.Builder
+	m.msgLines = m.msgLines[:0]
+
+	contentStyle := lipgloss.NewStyle().Width(width).PaddingLeft(2)
+	mutedStyle := lipgloss.NewStyle().Foreground(mutedColor)
+
+	b.WriteString(m.formatHeader(width))
+	b.WriteString("\n\n")
+
+	start := m.Session.Started()
+	lines, counted := 0, 0
+	for i, msg := range m.Session.Messages {
+		lines += strings.Count(b.String()[counted:], "\n")
+		counted = b.Len()
+		m.msgLines = append(m.msgLines, lines)
+		role, style := messageStyle(msg.Type)
+		label := style.Render(role)
+		if ts := msg.Time(); !ts.IsZero() {
+			label += mutedStyle.Render("  " + formatMessageTime(ts, start))
+		}
+		if len(m.Session.Files) > 1 {
+			label += mutedStyle.Render(fmt.Sprintf("  file %d/%d", m.sourceFile(msg)+1, len(m.Session.Files)))
+		}
+		b.WriteString(mutedStyle.Render(fmt.Sprintf("#%d ", i+1)) + label + "\n")
+
+		if len(msg.Thoughts) > 0 {
+			b.WriteString(m.formatThoughts(msg.Thoughts, width))
+		}
+		if msg.Content != "" {
+			b.WriteString(m.formatMessageContent(i, msg, contentStyle) + "\n")
+		}
+		for _, tc := range msg.ToolCalls {
+			b.WriteString(formatToolCall(tc, width))
+		}
+		if footer := formatMessageFooter(msg); footer != "" {
+			b.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(mutedStyle.Render(footer)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// statusLine renders the input bar, or the state of the search.
+func (m *InspectModal) statusLine() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(mutedColor)
+	var status string
+	switch {
+	case m.query == "":
+	case m.searchErr != nil:
+		status = lipgloss.NewStyle().Foreground(warning).Render("invalid pattern")
+	case len(m.matches) == 0:
+		status = lipgloss.NewStyle().Foreground(warning).Render("no matches")
+	default:
+		status = fmt.Sprintf("match %d/%d", m.current+1, len(m.matches))
+	}
+
+	switch m.inputMode {
+	case inspectInputGoto:
+		return m.input.View()
+	case inspectInputSearch:
+		option := func(label string, on bool) string {
+			if on {
+				return highlightStyle.Bold(true).Render(label)
+			}
+			return mutedStyle.Render(label)
+		}
+		return fmt.Sprintf("%s  %s %s  %s %s", m.input.View(),
+			option("[.*]", m.regex), option("[Aa]", m.matchCase), status,
+			mutedStyle.Render("(ctrl+r regex, ctrl+t case, enter done)"))
+	}
+	if m.query == "" {
+		return ""
+	}
+	return mutedStyle.Render("/"+m.query) + "  " + status
+}
+
+// formatMessageContent renders the content of the user and Gemini messages
+// as markdown, unless in raw mode. Other messages are not markdown, and are
+// wrapped as they are.
+func (m *InspectModal) formatMessageContent(i int, msg gemini.Message, style lipgloss.Style) string {
+	if m.raw || (msg.Type != gemini.MessageUser && msg.Type != gemini.MessageGemini) {
+		return style.Render(msg.Content)
+	}
+	if out, ok := m.rendered[i]; ok {
+		return out
+	}
+	if m.markdown == nil {
+		r, err := newMarkdownRenderer(m.width)
+		if err != nil {
+			return style.Render(msg.Content)
+		}
+		m.markdown, m.rendered = r, map[int]string{}
+	}
+	out, err := m.markdown.Render(msg.Content)
+	if err != nil {
+		return style.Render(msg.Content)
+	}
+	out = strings.Trim(out, "\n")
+	m.rendered[i] = out
+	return out
+}
+
+// newMarkdownRenderer returns a renderer wrapping markdown at width, with
+// the style matching the background of the terminal. The margin of the
+// document indents the content like the other messages.
+func newMarkdownRenderer(width int) (*glamour.TermRenderer, error) {
+	style := styles.LightStyleConfig
+	if lipgloss.HasDarkBackground() {
+		style = styles.DarkStyleConfig
+	}
+	return glamour.NewTermRenderer(
+		glamour.WithStyles(style),
+		glamour.WithWordWrap(width),
+	)
+}
+
+// sourceFile returns the index in Files of the file msg came from.
+func (m *InspectModal) sourceFile(msg gemini.Message) int {
+	for i, f := range m.Session.Files {
+		if f.Path == msg.Source {
+			return i
+		}
+	}
+	return 0
+}
+
+// formatHeader renders the time span, the message counts and the token
+// totals of the session.
+func (m *InspectModal) formatHeader(width int) string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(mutedColor).Width(10)
+	line := func(label, value string) {
+		b.WriteString(labelStyle.Render(label) + truncateEnd(value, max(width-10, 10)) + "\n")
+	}
+
+	start, end := m.Session.Started(), m.Session.GetLastUpdate()
+	if !start.IsZero() {
+		line("Started", start.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !end.IsZero() {
+		span := end.Local().Format("2006-01-02 15:04:05")
+		if !start.IsZero() && !end.Before(start) {
+			span += fmt.Sprintf(" (%s)", formatSpan(end.Sub(start)))
+		}
+		line("Ended", span)
+	}
+
+	counts := map[string]int{}
+	var types []string // In order of appearance
+	var tokens gemini.TokenStats
+	var models []string
+	tools := 0
+	for _, msg := range m.Session.Messages {
+		if counts[msg.Type] == 0 {
+			types = append(types, msg.Type)
+		}
+		counts[msg.Type]++
+		tools += len(msg.ToolCalls)
+		if msg.Tokens != nil {
+			tokens.Add(*msg.Tokens)
+		}
+		if msg.Model != "" && !slices.Contains(models, msg.Model) {
+			models = append(models, msg.Model)
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool { return counts[types[i]] > counts[types[j]] })
+	var parts []string
+	for _, t := range types {
+		role, _ := messageStyle(t)
+		parts = append(parts, fmt.Sprintf("%d %s", counts[t], strings.ToLower(role)))
+	}
+	if tools > 0 {
+		parts = append(parts, plural(tools, "tool call"))
+	}
+	summary := fmt.Sprintf("%d", len(m.Session.Messages))
+	if len(parts) > 0 {
+		summary += " (" + strings.Join(parts, ", ") + ")"
+	}
+	line("Messages", summary)
+	if tokens.Total > 0 {
+		line("Tokens", tokens.String())
+	}
+	if len(models) > 0 {
+		line("Models", strings.Join(models, ", "))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(subtle).
+		Render(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// formatThoughts renders the chain-of-thought of a message, collapsed to a
+// single line unless the thoughts are expanded.
+func (m *InspectModal) formatThoughts(thoughts []gemini.Thought, width int) string {
+	style := lipgloss.NewStyle().Foreground(mutedColor).Italic(true).PaddingLeft(2)
+	if !m.showThoughts {
+		return style.Render("▸ "+plural(len(thoughts), "thought")) + "\n"
+	}
+	var b strings.Builder
+	b.WriteString(style.Render("▾ "+plural(len(thoughts), "thought")) + "\n")
+	body := style.PaddingLeft(4).Width(width)
+	for _, t := range thoughts {
+		text := t.Description
+		if t.Subject != "" {
+			text = lipgloss.NewStyle().Bold(true).Render(t.Subject) + ": " + text
+		}
+		b.WriteString(body.Render(text) + "\n")
+	}
+	return b.String()
+}
+
+// formatToolCall renders a tool call as its name, status and description.
+func formatToolCall(tc gemini.ToolCall, width int) string {
+	name := tc.DisplayName
+	if name == "" {
+		name = tc.Name
+	}
+	line := lipgloss.NewStyle().Foreground(toolColor).Bold(true).Render("⚙ " + name)
+	if tc.Status != "" {
+		statusStyle := lipgloss.NewStyle().Foreground(mutedColor)
+		if tc.Status == "error" || tc.Status == "cancelled" {
+			statusStyle = statusStyle.Foreground(warning)
+		}
+		line += " " + statusStyle.Render("("+tc.Status+")")
+	}
+	if tc.Description != "" {
+		line += " " + tc.Description
+	}
+	return lipgloss.NewStyle().Width(width).PaddingLeft(2).Render(line) + "\n"
+}
+
+// formatMessageFooter returns the model and the token usage of a message.
+func formatMessageFooter(msg gemini.Message) string {
+	var parts []string
+	if msg.Model != "" {
+		parts = append(parts, msg.Model)
+	}
+	if msg.Tokens != nil && msg.Tokens.Total > 0 {
+		parts = append(parts, msg.Tokens.String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// formatMessageTime renders ts in local time, without the date when it is
+// the day the session started.
+func formatMessageTime(ts, start time.Time) string {
+	ts = ts.Local()
+	s := start.Local()
+	if ts.YearDay() == s.YearDay() && ts.Year() == s.Year() {
+		return ts.Format("15:04:05")
+	}
+	return ts.Format("2006-01-02 15:04:05")
+}
+
+// formatSpan renders a duration rounded to a precision fitting its length.
+func formatSpan(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+func (m *InspectModal) View(w, h int) string {
+	if !m.ready {
+		return "Initializing..."
+	}
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlight).
+		Padding(1)
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(highlight).
+		MarginBottom(1)
+
+	thoughts := "expand"
+	if m.showThoughts {
+		thoughts = "collapse"
+	}
+	mode := "raw"
+	if m.raw {
+		mode = "rendered"
+	}
+	header := titleStyle.Render(m.Title)
+	footerStyle := lipgloss.NewStyle().Foreground(subtle)
+	if m.showFiles {
+		footer := footerStyle.Render("(esc back, q close, j/k move, enter/h/l fold, u next unmodelled, tab next file)")
+		modal := style.Render(header + "\n" + m.viewFiles() + "\n" + m.filesStatusLine() + "\n" + footer)
+		return lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, modal)
+	}
+	footer := footerStyle.Render(fmt.Sprintf(
+		"(esc/q close, / search, n/N match, : go to message, ]/[ prompt, t %s thoughts, r %s, J json)", thoughts, mode))
+
+	modal := style.Render(header + "\n" + m.viewport.View() + "\n" + m.statusLine() + "\n" + footer)
+
+	return lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,269 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/gemini"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// session has a message of every type, a thought, a tool call and token
+// usage. Its last message is from the day after it started.
+var session = gemini.Session{
+	ID:          "3f2a0000-1111-2222-3333-444455556666",
+	StartTime:   "2025-01-01T10:00:00Z",
+	LastUpdated: "2025-01-02T09:00:00Z",
+	Messages: []gemini.Message{
+		{ID: "m1", Timestamp: "2025-01-01T10:00:00Z", Type: gemini.MessageUser, Content: "Fix the **parser**"},
+		{
+			ID: "m2", Timestamp: "2025-01-01T10:01:00Z", Type: gemini.MessageGemini, Content: "Done, see `parse.go`.",
+			Thoughts:  []gemini.Thought{{Subject: "Planning", Description: "read the parser first"}},
+			ToolCalls: []gemini.ToolCall{{Name: "read_file", DisplayName: "ReadFile", Status: "success", Description: "parse.go"}},
+			Model:     "gemini-2.5-pro",
+			Tokens:    &gemini.TokenStats{Input: 100, Output: 20, Total: 120},
+		},
+		{Type: gemini.MessageInfo, Content: "Switched model"},
+		{Timestamp: "2025-01-02T09:00:00Z", Type: gemini.MessageError, Content: "Quota exceeded"},
+		{Timestamp: "2025-01-02T09:00:00Z", Type: gemini.MessageUser, Content: "Try the parser again"},
+	},
+}
+
+// newInspect returns an InspectModal of s sized for a large terminal.
+func newInspect(s gemini.Session) *InspectModal {
+	m := NewInspectModal(s)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	return m
+}
+
+// press sends a key to m.
+func press(m *InspectModal, key string) {
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+}
+
+// content returns the lines shown by m without styling.
+func content(m *InspectModal) string {
+	return ansi.Strip(strings.Join(m.lines, "\n"))
+}
+
+func TestInspectContent(t *testing.T) {
+	m := newInspect(session)
+	day2 := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC).Local().Format("2006-01-02 15:04:05")
+	for _, want := range []string{
+		"Messages  5 (2 user, 1 gemini, 1 info, 1 error, 1 tool call)",
+		"Tokens    120 tokens (in 100, out 20)",
+		"Models    gemini-2.5-pro",
+		"#3 Info",
+		"#4 Error  " + day2,
+		"▸ 1 thought",
+		"⚙ ReadFile (success) parse.go",
+		"gemini-2.5-pro · 120 tokens (in 100, out 20)",
+	} {
+		if !strings.Contains(content(m), want) {
+			t.Errorf("content lacks %q:\n%s", want, content(m))
+		}
+	}
+	if strings.Contains(content(m), "read the parser first") {
+		t.Errorf("thoughts shown while collapsed")
+	}
+
+	press(m, "t")
+	if !strings.Contains(content(m), "▾ 1 thought") || !strings.Contains(content(m), "Planning: read the parser first") {
+		t.Errorf("thoughts not expanded:\n%s", content(m))
+	}
+}
+
+func TestFormatSpan(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Millisecond, "2s"},
+		{5*time.Minute + 3*time.Second, "5m03s"},
+		{3*time.Hour + 7*time.Minute, "3h07m"},
+		{47 * time.Hour, "47h00m"},
+		{50 * time.Hour, "2d02h"},
+	}
+	for _, tt := range tests {
+		if got := formatSpan(tt.d); got != tt.want {
+			t.Errorf("formatSpan(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMessageFooter(t *testing.T) {
+	tests := []struct {
+		msg  gemini.Message
+		want string
+	}{
+		{gemini.Message{}, ""},
+		{gemini.Message{Model: "m"}, "m"},
+		{gemini.Message{Tokens: &gemini.TokenStats{}}, ""},
+		{gemini.Message{Model: "m", Tokens: &gemini.TokenStats{Cached: 1500, Total: 2000}}, "m · 2,000 tokens (cached 1,500)"},
+	}
+	for _, tt := range tests {
+		if got := formatMessageFooter(tt.msg); got != tt.want {
+			t.Errorf("formatMessageFooter(%+v) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestInspectMarkdown(t *testing.T) {
+	s := session
+	s.Messages = []gemini.Message{
+		{Type: gemini.MessageUser, Content: "Fix the **parser**"},
+		{Type: gemini.MessageGemini, Content: "# Plan\n\n```go\nfunc parse() {}\n```"},
+		{Type: gemini.MessageError, Content: "**not** markdown"},
+	}
+	m := newInspect(s)
+	got := content(m)
+	if strings.Contains(got, "**parser**") || !strings.Contains(got, "parser") || strings.Contains(got, "```") {
+		t.Errorf("markdown not rendered:\n%s", got)
+	}
+	if !strings.Contains(got, "func parse() {}") || !strings.Contains(got, "**not** markdown") {
+		t.Errorf("rendered content lacks the code or the error as written:\n%s", got)
+	}
+	if len(m.rendered) != 2 {
+		t.Errorf("%d messages rendered, want the user and Gemini messages", len(m.rendered))
+	}
+
+	press(m, "r")
+	if got := content(m); !strings.Contains(got, "Fix the **parser**") || !strings.Contains(got, "```go") {
+		t.Errorf("raw mode renders markdown:\n%s", got)
+	}
+	press(m, "r")
+	if strings.Contains(content(m), "**parser**") {
+		t.Errorf("markdown not rendered after leaving raw mode")
+	}
+
+	// Rendered messages are kept until the width changes.
+	m.rendered[0] = "cached"
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if !strings.Contains(content(m), "cached") {
+		t.Errorf("rendered message not reused")
+	}
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	if strings.Contains(content(m), "cached") {
+		t.Errorf("rendered message reused at another width")
+	}
+}
+
+// send sends a special key to m.
+func send(m *InspectModal, key tea.KeyType) {
+	m.Update(tea.KeyMsg{Type: key})
+}
+
+func TestInspectSearch(t *testing.T) {
+	m := newInspect(session)
+	press(m, "r") // Raw mode keeps the content as written.
+	press(m, "/")
+	press(m, "PARSER")
+	tests := []struct {
+		name    string
+		key     tea.KeyType
+		matches int
+		err     bool
+	}{
+		{"typed", 0, 2, false},
+		{"match case", tea.KeyCtrlT, 0, false},
+		{"ignore case", tea.KeyCtrlT, 2, false},
+		{"regex", tea.KeyCtrlR, 2, false},
+	}
+	for _, tt := range tests {
+		if tt.key != 0 {
+			send(m, tt.key)
+		}
+		if len(m.matches) != tt.matches || (m.searchErr != nil) != tt.err {
+			t.Errorf("%s: %d matches, error %v; want %d", tt.name, len(m.matches), m.searchErr, tt.matches)
+		}
+	}
+
+	// The query is edited as a regular expression now.
+	for range "PARSER" {
+		send(m, tea.KeyBackspace)
+	}
+	press(m, "pars(er|e\\.go)")
+	if len(m.matches) != 4 {
+		t.Errorf("regex: %d matches, want 4", len(m.matches))
+	}
+	press(m, "(")
+	if m.searchErr == nil || !strings.Contains(ansi.Strip(m.statusLine()), "invalid pattern") {
+		t.Errorf("invalid pattern not reported: %q", ansi.Strip(m.statusLine()))
+	}
+	send(m, tea.KeyBackspace)
+	send(m, tea.KeyEnter)
+	if m.inputMode != inspectInputNone || m.query != `pars(er|e\.go)` {
+		t.Errorf("after enter: input mode %d, query %q", m.inputMode, m.query)
+	}
+	// The match in the collapsed thoughts expanded them.
+	if !m.showThoughts || len(m.matches) != 5 {
+		t.Fatalf("after enter: thoughts shown %v, %d matches; want 5", m.showThoughts, len(m.matches))
+	}
+
+	// n and N move through the matches, wrapping around.
+	m.current = 0
+	for _, step := range []struct {
+		key  string
+		want int
+	}{{"n", 1}, {"N", 0}, {"N", 4}, {"n", 0}} {
+		press(m, step.key)
+		if m.current != step.want {
+			t.Errorf("after %s: match %d, want %d", step.key, m.current, step.want)
+		}
+	}
+	if got := ansi.Strip(m.statusLine()); !strings.Contains(got, "match 1/5") {
+		t.Errorf("status line = %q", got)
+	}
+
+	send(m, tea.KeyEsc)
+	if m.query != "" || len(m.matches) != 0 {
+		t.Errorf("esc kept the search %q", m.query)
+	}
+}
+
+func TestInspectSearchExpandsThoughts(t *testing.T) {
+	m := newInspect(session)
+	press(m, "/")
+	press(m, "first")
+	if len(m.matches) != 0 || m.showThoughts {
+		t.Fatalf("collapsed thoughts matched while typing")
+	}
+	send(m, tea.KeyEnter)
+	if !m.showThoughts || len(m.matches) != 1 || m.current != 0 {
+		t.Errorf("after enter: thoughts shown %v, %d matches, current %d", m.showThoughts, len(m.matches), m.current)
+	}
+}
+
+func TestInspectNavigation(t *testing.T) {
+	// Enough content below the last prompt to scroll to it.
+	s := session
+	s.Messages = append(slices.Clone(s.Messages), gemini.Message{Type: gemini.MessageGemini, Content: strings.Repeat("line\n\n", 20)})
+	m := newInspect(s)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 14})
+
+	press(m, ":")
+	press(m, "4")
+	send(m, tea.KeyEnter)
+	if m.viewport.YOffset != m.msgLines[3] {
+		t.Errorf("go to #4: offset %d, want %d", m.viewport.YOffset, m.msgLines[3])
+	}
+	// Prompts are the user messages, #1 and #5.
+	press(m, "]")
+	if m.viewport.YOffset != m.msgLines[4] {
+		t.Errorf("next prompt: offset %d, want %d", m.viewport.YOffset, m.msgLines[4])
+	}
+	press(m, "[")
+	if m.viewport.YOffset != m.msgLines[0] {
+		t.Errorf("previous prompt: offset %d, want %d", m.viewport.YOffset, m.msgLines[0])
+	}
+
+	press(m, ":")
+	press(m, "99")
+	send(m, tea.KeyEnter)
+	if m.viewport.YOffset != m.msgLines[0] {
+		t.Errorf("go to a missing message moved to offset %d", m.viewport.YOffset)
+	}
+}
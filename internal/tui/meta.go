@@ -0,0 +1,235 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the metadata edited by the metadata modals.
+const (
+	metaAlias = "alias"
+	metaName  = "name"
+	metaTags  = "tags"
+	metaNote  = "note"
+)
+
+// allTags is the option of the tag filter showing every project.
+const allTags = ""
+
+// NoteModal edits a multi-line markdown note. The result value is the note.
+type NoteModal struct {
+	Title string
+	Input textarea.Model
+}
+
+func NewNoteModal(title, note string) NoteModal {
+	ta := textarea.New()
+	ta.Placeholder = "Markdown note..."
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetWidth(56)
+	ta.SetHeight(10)
+	ta.SetValue(note)
+	ta.Focus()
+	return NoteModal{Title: title, Input: ta}
+}
+
+func (m NoteModal) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m NoteModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+s":
+			return m, func() tea.Msg { return ModalResult{Value: strings.TrimSpace(m.Input.Value())} }
+		case "esc":
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		}
+	}
+	m.Input, cmd = m.Input.Update(msg)
+	return m, cmd
+}
+
+func (m NoteModal) View(w, h int) string {
+	help := lipgloss.NewStyle().Foreground(subtle).Render("(ctrl+s save, esc cancel)")
+	return renderModal(w, h, m.Title, m.Input.View()+"\n\n"+help)
+}
+
+// metaTarget returns the label, the metadata and the session ID (empty for
+// the project) of the project or session the focused pane points at.
+func (m *Model) metaTarget() (string, cache.Meta, string, bool) {
+	if len(m.Projects) == 0 {
+		return "", cache.Meta{}, "", false
+	}
+	p := m.Projects[m.Selected]
+	if m.Focus == FocusSessions {
+		if len(p.Sessions) == 0 {
+			return "", cache.Meta{}, "", false
+		}
+		s := p.Sessions[m.SessionCursor]
+		return fmt.Sprintf("session [%s]", gemini.ShortID(s.ID)), m.cache.SessionMeta(s.ID), s.ID, true
+	}
+	return fmt.Sprintf("project [%s]", gemini.ShortID(p.ID)), m.cache.ProjectMeta(p.ID), "", true
+}
+
+// startEditMeta opens the modal editing a field of the metadata of the
+// focused project or session.
+func (m *Model) startEditMeta(field string) (tea.Model, tea.Cmd) {
+	label, meta, sessionID, ok := m.metaTarget()
+	if !ok || (field == metaName && sessionID == "") {
+		return m, nil
+	}
+	m.metaSession = sessionID
+	m.metaField = field
+	switch field {
+	case metaAlias:
+		m.modal = NewTextInputModal(fmt.Sprintf("Alias of %s:", label), meta.Alias, "Short name, empty to remove...")
+	case metaName:
+		s := m.Projects[m.Selected].Sessions[m.SessionCursor]
+		m.modal = NewTextInputModal(fmt.Sprintf("Name of %s:", label), m.cache.SessionTitle(s.ID, s.Title), "Title, empty for the first prompt...")
+	case metaTags:
+		m.modal = NewTextInputModal(fmt.Sprintf("Tags of %s:", label), strings.Join(meta.Tags, " "), "Tags separated by spaces or commas...")
+	case metaNote:
+		m.modal = NewNoteModal(fmt.Sprintf("Note on %s", label), meta.Note)
+	}
+	m.Mode = ModeEditMeta
+	return m, m.modal.Init()
+}
+
+// applyMeta stores the value entered in a metadata modal.
+func (m *Model) applyMeta(value string) error {
+	if len(m.Projects) == 0 {
+		return nil
+	}
+	p := m.Projects[m.Selected]
+	meta := m.cache.ProjectMeta(p.ID)
+	if m.metaSession != "" {
+		meta = m.cache.SessionMeta(m.metaSession)
+	}
+	switch m.metaField {
+	case metaAlias:
+		meta.Alias = strings.TrimSpace(value)
+	case metaName:
+		meta.Name = strings.TrimSpace(value)
+		for _, s := range p.Sessions {
+			if s.ID == m.metaSession && s.Title == meta.Name {
+				meta.Name = "" // Same as derived, keep following the prompt
+			}
+		}
+	case metaTags:
+		meta.Tags = cache.ParseTags(value)
+	case metaNote:
+		meta.Note = value
+	}
+	if m.metaSession != "" {
+		m.cache.SetSessionMeta(m.metaSession, meta)
+	} else if err := m.cache.SetProjectMeta(p.ID, meta); err != nil {
+		return err
+	}
+	return m.cache.Save()
+}
+
+// togglePin pins or unpins the selected project, which moves it to or from
+// the top of the list.
+func (m *Model) togglePin() error {
+	p := m.Projects[m.Selected]
+	meta := m.cache.ProjectMeta(p.ID)
+	meta.Pinned = !meta.Pinned
+	if err := m.cache.SetProjectMeta(p.ID, meta); err != nil {
+		return err
+	}
+	if err := m.cache.Save(); err != nil {
+		return err
+	}
+	m.sortProjects()
+	return nil
+}
+
+// startTagFilter opens the list of tags to filter the projects by.
+func (m *Model) startTagFilter() (tea.Model, tea.Cmd) {
+	tags := m.cache.Tags()
+	if len(tags) == 0 {
+		m.modal = ErrorModal{
+			Title: "No Tags",
+			Err:   fmt.Errorf("no project or session is tagged yet. Press [t] to tag the selected one."),
+		}
+		return m, m.modal.Init()
+	}
+	options := []ListOption{{ID: allTags, Label: "All projects"}}
+	for _, t := range tags {
+		options = append(options, ListOption{ID: t, Label: "#" + t})
+	}
+	m.modal = ListSelectorModal{Title: "Show projects tagged:", Options: options}
+	m.Mode = ModeTagFilter
+	return m, m.modal.Init()
+}
+
+// setTagFilter shows only the projects tagged with tag, or with a session
+// tagged with it, moving the selection to a shown project.
+func (m *Model) setTagFilter(tag string) {
+	m.tagFilter = tag
+	if len(m.Projects) == 0 || m.visible(m.Projects[m.Selected]) {
+		return
+	}
+	for i, p := range m.Projects {
+		if m.visible(p) {
+			m.Selected, m.Cursor, m.SessionCursor = i, i, 0
+			return
+		}
+	}
+}
+
+// visible reports whether a project passes the tag filter.
+func (m *Model) visible(p projectView) bool {
+	if m.tagFilter == allTags || m.cache.ProjectMeta(p.ID).HasTag(m.tagFilter) {
+		return true
+	}
+	for _, s := range p.Sessions {
+		if m.cache.SessionMeta(s.ID).HasTag(m.tagFilter) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveCursor moves the project cursor by one shown project in direction dir.
+func (m *Model) moveCursor(dir int) {
+	for i := m.Cursor + dir; i >= 0 && i < len(m.Projects); i += dir {
+		if m.visible(m.Projects[i]) {
+			m.Cursor = i
+			m.Selected = i
+			m.SessionCursor = 0
+			return
+		}
+	}
+}
+
+// renderTags formats tags as #tag #other.
+func renderTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
+// renderMeta formats the tags and note of a project for the details pane.
+func renderMeta(meta cache.Meta, width int) string {
+	var b strings.Builder
+	style := lipgloss.NewStyle().Width(width)
+	if len(meta.Tags) > 0 {
+		b.WriteString(style.Foreground(highlight).Render(renderTags(meta.Tags)) + "\n")
+	}
+	if meta.Note != "" {
+		b.WriteString(style.Render(meta.Note) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,465 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"geminictl/internal/gemini"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+)
+
+// jsonFoldDepth is the depth from which containers start folded: the
+// messages are shown, their nested values are not.
+const jsonFoldDepth = 3
+
+// jsonNode is a value of a JSON document shown in the raw JSON view.
+type jsonNode struct {
+	key      string // Quoted key of object members
+	kind     byte   // '{' or '[' for containers, 0 for scalars
+	literal  string // JSON text of scalars
+	children []*jsonNode
+	parent   *jsonNode
+	depth    int
+	path     string // See gemini.UnmodelledFields
+	folded   bool
+
+	// unmodelled is set on the fields Session does not model; flagged counts
+	// them within a container.
+	unmodelled bool
+	flagged    int
+}
+
+// jsonRow is a line of the tree: a node, or the closing bracket of an
+// expanded container.
+type jsonRow struct {
+	node    *jsonNode
+	closing bool
+}
+
+// jsonTree is a foldable, scrollable view of a JSON document with a cursor
+// on one of its rows.
+type jsonTree struct {
+	root   *jsonNode
+	rows   []jsonRow
+	cursor int
+	offset int
+
+	// lines holds the rows rendered at width; rowLines the line each row
+	// starts at, as long values wrap.
+	width    int
+	lines    []string
+	rowLines []int
+}
+
+// newJSONTree parses data, flagging the paths in unmodelled.
+func newJSONTree(data []byte, unmodelled map[string]bool) (*jsonTree, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root := &jsonNode{}
+	if err := parseJSONNode(dec, root, unmodelled); err != nil {
+		return nil, err
+	}
+	t := &jsonTree{root: root}
+	t.flatten()
+	return t, nil
+}
+
+func parseJSONNode(dec *json.Decoder, n *jsonNode, unmodelled map[string]bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		n.kind = byte(tok)
+		n.folded = n.depth >= jsonFoldDepth
+		for i := 0; dec.More(); i++ {
+			child := &jsonNode{parent: n, depth: n.depth + 1}
+			if n.kind == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child.key = quoteJSON(key.(string))
+				child.path = key.(string)
+				if n.path != "" {
+					child.path = n.path + "." + child.path
+				}
+			} else {
+				child.path = fmt.Sprintf("%s[%d]", n.path, i)
+			}
+			child.unmodelled = unmodelled[child.path]
+			if err := parseJSONNode(dec, child, unmodelled); err != nil {
+				return err
+			}
+			if child.unmodelled {
+				n.flagged++
+			}
+			n.flagged += child.flagged
+			n.children = append(n.children, child)
+		}
+		_, err = dec.Token() // Closing delimiter
+		return err
+	case string:
+		n.literal = quoteJSON(tok)
+	case json.Number:
+		n.literal = tok.String()
+	case bool:
+		n.literal = strconv.FormatBool(tok)
+	case nil:
+		n.literal = "null"
+	}
+	return nil
+}
+
+// quoteJSON returns s as a JSON string, without escaping HTML characters.
+func quoteJSON(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// foldable reports whether n is a container with members.
+func (n *jsonNode) foldable() bool {
+	return n.kind != 0 && len(n.children) > 0
+}
+
+// flatten lists the rows of the unfolded nodes.
+func (t *jsonTree) flatten() {
+	t.rows = t.rows[:0]
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		t.rows = append(t.rows, jsonRow{node: n})
+		if !n.foldable() || n.folded {
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		t.rows = append(t.rows, jsonRow{node: n, closing: true})
+	}
+	walk(t.root)
+	t.cursor = min(t.cursor, len(t.rows)-1)
+	t.width = 0 // Render again
+}
+
+// moveTo puts the cursor on the row opening n.
+func (t *jsonTree) moveTo(n *jsonNode) {
+	for i, r := range t.rows {
+		if r.node == n && !r.closing {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+// setFolded folds or unfolds the node under the cursor. Folding a value
+// that cannot be folded folds its parent instead.
+func (t *jsonTree) setFolded(folded bool) {
+	n := t.rows[t.cursor].node
+	if folded && (!n.foldable() || n.folded) && n.parent != nil {
+		n = n.parent
+	}
+	if !n.foldable() || n.folded == folded {
+		t.moveTo(n)
+		return
+	}
+	n.folded = folded
+	t.flatten()
+	t.moveTo(n)
+}
+
+// toggle folds or unfolds the node under the cursor.
+func (t *jsonTree) toggle() {
+	n := t.rows[t.cursor].node
+	if n.foldable() {
+		t.setFolded(!n.folded)
+	}
+}
+
+// nextUnmodelled moves the cursor to the next unmodelled field after it,
+// wrapping around and unfolding the containers on the way.
+func (t *jsonTree) nextUnmodelled() {
+	var nodes []*jsonNode
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(t.root)
+
+	current := 0
+	for i, n := range nodes {
+		if n == t.rows[t.cursor].node {
+			current = i
+			break
+		}
+	}
+	for i := 1; i <= len(nodes); i++ {
+		n := nodes[(current+i)%len(nodes)]
+		if !n.unmodelled {
+			continue
+		}
+		for p := n.parent; p != nil; p = p.parent {
+			p.folded = false
+		}
+		t.flatten()
+		t.moveTo(n)
+		return
+	}
+}
+
+// render renders the rows at width.
+func (t *jsonTree) render(width int) {
+	punct := lipgloss.NewStyle().Foreground(mutedColor)
+	keyStyle := lipgloss.NewStyle().Foreground(highlight)
+	flagStyle := lipgloss.NewStyle().Foreground(warning)
+
+	t.width = width
+	t.lines = t.lines[:0]
+	t.rowLines = t.rowLines[:0]
+	for i, r := range t.rows {
+		n := r.node
+		t.rowLines = append(t.rowLines, len(t.lines))
+
+		cursor := "  "
+		if i == t.cursor {
+			cursor = lipgloss.NewStyle().Foreground(special).Render("> ")
+		}
+		marker := "  "
+		if n.foldable() && !r.closing {
+			marker = "▾ "
+			if n.folded {
+				marker = "▸ "
+			}
+		}
+		comma := ""
+		if n.parent != nil && n != n.parent.children[len(n.parent.children)-1] {
+			comma = ","
+		}
+
+		indent := strings.Repeat("  ", n.depth)
+		prefix := cursor + indent + punct.Render(marker)
+		prefixWidth := 4 + 2*n.depth
+		if n.key != "" && !r.closing {
+			style := keyStyle
+			if n.unmodelled {
+				style = flagStyle.Bold(true)
+			}
+			prefix += style.Render(n.key) + punct.Render(": ")
+			prefixWidth += ansi.StringWidth(n.key) + 2
+		}
+
+		open, close := "{", "}"
+		if n.kind == '[' {
+			open, close = "[", "]"
+		}
+		var value, note string
+		switch {
+		case r.closing:
+			value = punct.Render(close + comma)
+		case n.kind != 0 && len(n.children) == 0:
+			value = punct.Render(open + close + comma)
+		case n.kind != 0 && n.folded:
+			noun := "field"
+			if n.kind == '[' {
+				noun = "item"
+			}
+			value = punct.Render(open+"…"+close+comma) + punct.Render(" "+plural(len(n.children), noun))
+		case n.kind != 0:
+			value = punct.Render(open)
+		}
+		if !r.closing {
+			if n.unmodelled {
+				note = flagStyle.Render("  ⚑ not modelled")
+			} else if n.folded && n.flagged > 0 {
+				note = flagStyle.Render(fmt.Sprintf("  ⚑ %d not modelled", n.flagged))
+			}
+		}
+		if n.kind != 0 || r.closing {
+			t.lines = append(t.lines, prefix+value+note)
+			continue
+		}
+
+		// Scalars wrap under their first line.
+		style := scalarStyle(n.literal)
+		wrapped := strings.Split(ansi.Hardwrap(n.literal+comma, max(width-prefixWidth, 20), true), "\n")
+		t.lines = append(t.lines, prefix+style.Render(wrapped[0])+note)
+		for _, l := range wrapped[1:] {
+			t.lines = append(t.lines, strings.Repeat(" ", prefixWidth)+style.Render(l))
+		}
+	}
+}
+
+// scalarStyle returns the color of a JSON scalar by its type.
+func scalarStyle(literal string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch literal[0] {
+	case '"':
+		return style.Foreground(special)
+	case 't', 'f', 'n':
+		return style.Foreground(cautionColor)
+	}
+	return style.Foreground(infoColor)
+}
+
+// View renders height lines of the tree at width, scrolled to keep the
+// cursor in view.
+func (t *jsonTree) View(width, height int) string {
+	if t.width != width {
+		t.render(width)
+	}
+	first := t.rowLines[t.cursor]
+	last := len(t.lines) - 1
+	if t.cursor+1 < len(t.rowLines) {
+		last = t.rowLines[t.cursor+1] - 1
+	}
+	if first < t.offset {
+		t.offset = first
+	} else if last >= t.offset+height {
+		t.offset = min(first, last-height+1)
+	}
+	t.offset = max(0, min(t.offset, len(t.lines)-height))
+
+	lines := t.lines[t.offset:min(len(t.lines), t.offset+height)]
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Update handles the keys moving the cursor and folding.
+func (t *jsonTree) Update(msg tea.KeyMsg, height int) {
+	switch msg.String() {
+	case "up", "k":
+		t.cursor = max(0, t.cursor-1)
+	case "down", "j":
+		t.cursor = min(len(t.rows)-1, t.cursor+1)
+	case "pgup", "b":
+		t.cursor = max(0, t.cursor-height)
+	case "pgdown", "f":
+		t.cursor = min(len(t.rows)-1, t.cursor+height)
+	case "home", "g":
+		t.cursor = 0
+	case "end", "G":
+		t.cursor = len(t.rows) - 1
+	case "enter", " ":
+		t.toggle()
+	case "right", "l":
+		t.setFolded(false)
+	case "left", "h":
+		t.setFolded(true)
+	case "u":
+		t.nextUnmodelled()
+	default:
+		return
+	}
+	t.width = 0 // The cursor moved, render again
+}
+
+// --- Raw JSON sub-mode of the InspectModal ---
+
+// openFile shows the raw JSON of the i-th file backing the session.
+func (m *InspectModal) openFile(i int) {
+	m.fileIndex = i
+	m.tree, m.treeErr = nil, nil
+	data, err := os.ReadFile(m.Session.Files[i].Path)
+	if err != nil {
+		m.treeErr = err
+		return
+	}
+	fields, err := gemini.UnmodelledFields(data)
+	if err != nil {
+		m.treeErr = err
+		return
+	}
+	m.tree, m.treeErr = newJSONTree(data, fields)
+}
+
+// updateFiles handles the keys of the raw JSON sub-mode.
+func (m *InspectModal) updateFiles(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "J":
+		m.showFiles = false
+	case "q":
+		return func() tea.Msg { return ModalResult{Canceled: true} }
+	case "tab":
+		if len(m.Session.Files) > 1 {
+			m.openFile((m.fileIndex + 1) % len(m.Session.Files))
+		}
+	case "shift+tab":
+		if len(m.Session.Files) > 1 {
+			m.openFile((m.fileIndex + len(m.Session.Files) - 1) % len(m.Session.Files))
+		}
+	default:
+		if m.tree != nil {
+			m.tree.Update(msg, m.treeHeight())
+		}
+	}
+	return nil
+}
+
+// treeHeight returns the number of lines left to the tree below the list
+// of files.
+func (m *InspectModal) treeHeight() int {
+	return max(3, m.viewport.Height-len(m.Session.Files)-2)
+}
+
+// viewFiles renders the list of the files backing the session and the tree
+// of the selected one, in place of the viewport.
+func (m *InspectModal) viewFiles() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(mutedColor)
+	if len(m.Session.Files) == 0 {
+		return lipgloss.NewStyle().Height(m.viewport.Height).Render(
+			mutedStyle.Render("No session file backs this view."))
+	}
+
+	var b strings.Builder
+	b.WriteString(mutedStyle.Render(truncateMiddle(collapseHome(filepath.Dir(m.Session.Files[0].Path)), m.width)) + "\n")
+	for i, f := range m.Session.Files {
+		cursor, style := "  ", lipgloss.NewStyle()
+		if i == m.fileIndex {
+			cursor, style = "> ", style.Foreground(special)
+		}
+		messages := "no messages"
+		if f.Count > 0 {
+			messages = fmt.Sprintf("%s #%d–%d", plural(f.Count, "message"), f.First+1, f.Last+1)
+		}
+		if f.Duplicates > 0 {
+			messages += fmt.Sprintf(", %d duplicated", f.Duplicates)
+		}
+		details := fmt.Sprintf("  %s  %s  %s", humanize.Bytes(uint64(f.Size)), f.ModTime.Format("2006-01-02 15:04"), messages)
+		name := truncateMiddle(filepath.Base(f.Path), max(m.width-len(details)-2, 10))
+		b.WriteString(cursor + style.Render(name) + mutedStyle.Render(details) + "\n")
+	}
+	b.WriteString("\n")
+
+	if m.treeErr != nil {
+		b.WriteString(lipgloss.NewStyle().Height(m.treeHeight()).Render(
+			lipgloss.NewStyle().Foreground(warning).Render(m.treeErr.Error())))
+	} else {
+		b.WriteString(m.tree.View(m.width, m.treeHeight()))
+	}
+	return lipgloss.NewStyle().Width(m.viewport.Width).Render(b.String())
+}
+
+// filesStatusLine renders the number of unmodelled fields of the file.
+func (m *InspectModal) filesStatusLine() string {
+	if m.tree == nil || m.tree.root.flagged == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(warning).Render(fmt.Sprintf(
+		"⚑ %s not modelled by geminictl, dropped when it rewrites the file (u to jump)", plural(m.tree.root.flagged, "field")))
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"geminictl/internal/gemini"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// rawSession is a session file with an unmodelled field at the top level,
+// one in a message and one nested in a value that starts folded.
+const rawSession = `{"sessionId":"s","kind":"main","messages":[` +
+	`{"id":"m1","attachments":[{"name":"a"}],"tokens":{"total":1,"extra":2}}]}`
+
+// newRawTree returns the tree of rawSession.
+func newRawTree(t *testing.T) *jsonTree {
+	t.Helper()
+	fields, err := gemini.UnmodelledFields([]byte(rawSession))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := newJSONTree([]byte(rawSession), fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// node returns the node of tree at path.
+func node(tree *jsonTree, path string) *jsonNode {
+	var find func(n *jsonNode) *jsonNode
+	find = func(n *jsonNode) *jsonNode {
+		if n.path == path {
+			return n
+		}
+		for _, c := range n.children {
+			if found := find(c); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(tree.root)
+}
+
+// key returns the message of a key, by its name for special keys.
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestNewJSONTree(t *testing.T) {
+	tree := newRawTree(t)
+	tests := []struct {
+		path       string
+		unmodelled bool
+		flagged    int
+		folded     bool
+	}{
+		{"", false, 3, false},
+		{"kind", true, 0, false},
+		{"messages", false, 2, false},
+		{"messages[0]", false, 2, false},
+		{"messages[0].attachments", true, 0, true},
+		{"messages[0].attachments[0]", false, 0, true},
+		{"messages[0].tokens", false, 1, true},
+		{"messages[0].tokens.extra", true, 0, false},
+	}
+	for _, tt := range tests {
+		n := node(tree, tt.path)
+		if n == nil {
+			t.Errorf("no node at %q", tt.path)
+			continue
+		}
+		if n.unmodelled != tt.unmodelled || n.flagged != tt.flagged || n.folded != tt.folded {
+			t.Errorf("node %q: unmodelled %v, flagged %d, folded %v; want %v, %d, %v",
+				tt.path, n.unmodelled, n.flagged, n.folded, tt.unmodelled, tt.flagged, tt.folded)
+		}
+	}
+	// Root, 2 scalars, messages, its message with its id and 2 folded values,
+	// and 3 closing brackets.
+	if len(tree.rows) != 11 {
+		t.Errorf("%d rows, want 11", len(tree.rows))
+	}
+
+	view := ansi.Strip(tree.View(80, 20))
+	for _, want := range []string{
+		`"kind": "main",  ⚑ not modelled`,
+		`"attachments": […], 1 item  ⚑ not modelled`,
+		`"tokens": {…} 2 fields  ⚑ 1 not modelled`,
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	if _, err := newJSONTree([]byte(`{"a":`), nil); err == nil {
+		t.Errorf("newJSONTree of invalid JSON succeeded")
+	}
+}
+
+func TestJSONTreeUpdate(t *testing.T) {
+	tree := newRawTree(t)
+	steps := []struct {
+		key     string
+		path    string // Of the node under the cursor
+		closing bool
+		rows    int
+	}{
+		{"j", "sessionId", false, 11},
+		{"u", "kind", false, 11},
+		{"u", "messages[0].attachments", false, 11},
+		{"u", "messages[0].tokens.extra", false, 14}, // Unfolds tokens
+		{"u", "kind", false, 14},                     // Wraps around
+		{"G", "", true, 14},
+		{"g", "", false, 14},
+		{"enter", "", false, 1},
+		{"enter", "", false, 14},
+		{"j", "sessionId", false, 14},
+		{"h", "", false, 1}, // Folds the parent of a scalar
+		{"l", "", false, 14},
+		{"u", "kind", false, 14},
+		{"u", "messages[0].attachments", false, 14},
+		{"l", "messages[0].attachments", false, 16},
+		{"l", "messages[0].attachments", false, 16},
+		{"j", "messages[0].attachments[0]", false, 16},
+		{"h", "messages[0].attachments", false, 14}, // A folded value folds its parent
+		{"h", "messages[0]", false, 7},
+		{"k", "messages", false, 7},
+		{"x", "messages", false, 7},
+	}
+	for i, s := range steps {
+		tree.Update(key(s.key), 10)
+		r := tree.rows[tree.cursor]
+		if r.node.path != s.path || r.closing != s.closing || len(tree.rows) != s.rows {
+			t.Fatalf("step %d (%s): cursor on %q (closing %v) of %d rows, want %q (closing %v) of %d",
+				i, s.key, r.node.path, r.closing, len(tree.rows), s.path, s.closing, s.rows)
+		}
+	}
+}
+
+func TestInspectFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := session
+	for _, f := range []struct{ name, data string }{
+		{"a.json", rawSession},
+		{"b.json", `{"sessionId":"s"}`},
+		{"c.json", `{`},
+		{"missing.json", ""},
+	} {
+		path := filepath.Join(dir, f.name)
+		if f.data != "" {
+			if err := os.WriteFile(path, []byte(f.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s.Files = append(s.Files, gemini.SessionFile{Path: path, First: -1, Last: -1})
+	}
+	m := newInspect(s)
+
+	steps := []struct {
+		key    string
+		shown  bool
+		file   int
+		status string
+		err    bool
+	}{
+		{"J", true, 0, "⚑ 3 fields not modelled", false},
+		{"tab", true, 1, "", false},
+		{"tab", true, 2, "", true},
+		{"tab", true, 3, "", true},
+		{"tab", true, 0, "⚑ 3 fields not modelled", false},
+		{"shift+tab", true, 3, "", true},
+		{"esc", false, 3, "", true},
+		{"J", true, 3, "", true}, // Keeps the file shown before
+	}
+	for i, st := range steps {
+		m.Update(key(st.key))
+		status := ansi.Strip(m.filesStatusLine())
+		if m.showFiles != st.shown || m.fileIndex != st.file || (m.treeErr != nil) != st.err ||
+			!strings.HasPrefix(status, st.status) || (st.status == "") != (status == "") {
+			t.Errorf("step %d (%s): shown %v, file %d, error %v, status %q", i, st.key, m.showFiles, m.fileIndex, m.treeErr, status)
+		}
+	}
+
+	// Keys other than those of the modal move in the tree.
+	m.Update(key("tab"))
+	press(m, "j")
+	if m.fileIndex != 0 || m.tree.cursor != 1 {
+		t.Errorf("file %d, cursor %d; want the cursor moved in the first file", m.fileIndex, m.tree.cursor)
+	}
+	if view := ansi.Strip(m.viewFiles()); !strings.Contains(view, "> a.json") || !strings.Contains(view, `"kind": "main"`) {
+		t.Errorf("viewFiles:\n%s", view)
+	}
+
+	m = newInspect(session)
+	press(m, "J")
+	if view := ansi.Strip(m.viewFiles()); !strings.Contains(view, "No session file backs this view.") {
+		t.Errorf("viewFiles without files:\n%s", view)
+	}
+}
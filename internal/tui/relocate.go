@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"geminictl/internal/config"
+	"geminictl/internal/relocate"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+)
+
+// RelocateResultsMsg carries the candidates found for an orphaned project.
+type RelocateResultsMsg struct {
+	ProjectID string
+	Result    relocate.Result
+}
+
+// relocateCmd searches for the new location of an orphaned project in the
+// background.
+func relocateCmd(ctx context.Context, fp relocate.Fingerprint, id, oldPath string, r config.Resolve) tea.Cmd {
+	return func() tea.Msg {
+		res := relocate.Find(ctx, oldPath, fp, relocate.Options{Resolve: r})
+		return RelocateResultsMsg{ProjectID: id, Result: res}
+	}
+}
+
+// RelocateModal lists the candidate locations of an orphaned project while
+// they are searched for. Selecting a candidate returns its path as the result
+// value; the digits 1-9 select a candidate directly.
+type RelocateModal struct {
+	Title     string
+	OldPath   string
+	Searching bool
+	Result    relocate.Result
+	Cursor    int
+}
+
+func (m RelocateModal) Init() tea.Cmd { return nil }
+func (m RelocateModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		candidates := m.Result.Candidates
+		switch key := msg.String(); key {
+		case "up", "k":
+			if m.Cursor > 0 {
+				m.Cursor--
+			}
+		case "down", "j":
+			if m.Cursor < len(candidates)-1 {
+				m.Cursor++
+			}
+		case "enter":
+			if len(candidates) > 0 {
+				path := candidates[m.Cursor].Path
+				return m, func() tea.Msg { return ModalResult{Value: path} }
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if i := int(key[0] - '1'); i < len(candidates) {
+				path := candidates[i].Path
+				return m, func() tea.Msg { return ModalResult{Value: path} }
+			}
+		case "esc", "q":
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		}
+	}
+	return m, nil
+}
+
+func (m RelocateModal) View(w, h int) string {
+	subtleStyle := lipgloss.NewStyle().Foreground(subtle)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Former location: %s\n\n", truncateMiddle(collapseHome(m.OldPath), 44)))
+
+	if m.Searching {
+		b.WriteString("Searching for directories resembling the project...\n")
+		b.WriteString(subtleStyle.Render("\n(esc cancel)"))
+		return renderModal(w, h, m.Title, b.String())
+	}
+
+	candidates := m.Result.Candidates
+	if len(candidates) == 0 {
+		b.WriteString("No directory resembling the project was found.\n")
+	}
+	for i, c := range candidates {
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if m.Cursor == i {
+			cursor = "> "
+			style = style.Foreground(special)
+		}
+		b.WriteString(fmt.Sprintf("%s%d. %s %s\n", cursor, i+1,
+			style.Render(truncateMiddle(collapseHome(c.Path), 38)), subtleStyle.Render(fmt.Sprintf("%3.0f%%", c.Score*100))))
+		b.WriteString("     " + subtleStyle.Render(strings.Join(c.Reasons, ", ")) + "\n")
+	}
+
+	searched := fmt.Sprintf("%s directories searched", humanize.Comma(int64(m.Result.Dirs)))
+	if !m.Result.Complete {
+		searched += " (search stopped early)"
+	}
+	b.WriteString(subtleStyle.Render("\n" + searched))
+	b.WriteString(subtleStyle.Render("\n(enter or 1-9 move project there, j/k move, esc close)"))
+	return renderModal(w, h, m.Title, b.String())
+}
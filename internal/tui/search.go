@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"geminictl/internal/search"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSearchHits caps the number of hits shown in the SearchModal.
+const maxSearchHits = 200
+
+// SearchResultsMsg carries the outcome of a search run in the background.
+type SearchResultsMsg struct {
+	Query string
+	Hits  []search.Hit
+	Err   error
+}
+
+// searchCmd updates the index with the current storage state and runs the
+// query against it.
+func searchCmd(idx *search.Index, rootDir, query string) tea.Cmd {
+	return func() tea.Msg {
+		q, err := search.Parse(query)
+		if err != nil {
+			return SearchResultsMsg{Query: query, Err: err}
+		}
+		if _, err := idx.Update(rootDir); err != nil {
+			return SearchResultsMsg{Query: query, Err: err}
+		}
+		_ = idx.Save()
+		return SearchResultsMsg{Query: query, Hits: idx.Search(q, maxSearchHits)}
+	}
+}
+
+// SearchModal lists the hits of a search. Selecting a hit returns it as the
+// result value.
+type SearchModal struct {
+	Title  string
+	Hits   []search.Hit
+	Labels map[string]string // Project labels by project ID
+	Cursor int
+}
+
+func (m SearchModal) Init() tea.Cmd { return nil }
+func (m SearchModal) Update(msg tea.Msg) (Modal, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.Cursor > 0 {
+				m.Cursor--
+			}
+		case "down", "j":
+			if m.Cursor < len(m.Hits)-1 {
+				m.Cursor++
+			}
+		case "enter", " ":
+			if len(m.Hits) > 0 {
+				hit := m.Hits[m.Cursor]
+				return m, func() tea.Msg { return ModalResult{Value: hit} }
+			}
+		case "esc", "q":
+			return m, func() tea.Msg { return ModalResult{Canceled: true} }
+		}
+	}
+	return m, nil
+}
+
+func (m SearchModal) View(w, h int) string {
+	var b strings.Builder
+	if len(m.Hits) == 0 {
+		b.WriteString("No matches.\n")
+	}
+
+	// Each hit takes two lines; keep the cursor within the visible window.
+	visible := max(3, (h-14)/2)
+	start := 0
+	if m.Cursor >= visible {
+		start = m.Cursor - visible + 1
+	}
+	end := min(len(m.Hits), start+visible)
+
+	subtleStyle := lipgloss.NewStyle().Foreground(subtle)
+	for i := start; i < end; i++ {
+		hit := m.Hits[i]
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if m.Cursor == i {
+			cursor = "> "
+			style = style.Foreground(special)
+		}
+		label := truncateMiddle(m.Labels[hit.ProjectID], 20)
+		b.WriteString(fmt.Sprintf("%s%s %s %s\n", cursor, renderHash(hit.SessionID), style.Render(label),
+			subtleStyle.Render(fmt.Sprintf("#%d %s %s", hit.Index+1, hit.Role, formatRelativeTime(hit.Timestamp)))))
+		b.WriteString("    " + renderSnippet(hit, 52) + "\n")
+	}
+
+	if len(m.Hits) > visible {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("\n%d-%d of %d", start+1, end, len(m.Hits))))
+	}
+	b.WriteString(subtleStyle.Render("\n(enter inspect, j/k move, esc close)"))
+	return renderModal(w, h, m.Title, b.String())
+}
+
+// renderSnippet renders at most width runes of a hit's snippet with the
+// matched words highlighted.
+func renderSnippet(hit search.Hit, width int) string {
+	text, suffix := hit.Snippet, ""
+	if n := 0; len(text) > width {
+		for i := range text {
+			if n == width {
+				text, suffix = text[:i], "…"
+				break
+			}
+			n++
+		}
+	}
+
+	style := highlightStyle.Bold(true)
+	var b strings.Builder
+	pos := 0
+	for _, r := range hit.Highlights {
+		if r[0] >= len(text) {
+			break
+		}
+		end := min(r[1], len(text))
+		b.WriteString(text[pos:r[0]])
+		b.WriteString(style.Render(text[r[0]:end]))
+		pos = end
+	}
+	b.WriteString(text[pos:])
+	return b.String() + suffix
+}
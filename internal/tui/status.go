@@ -1,30 +1,36 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
 	"geminictl/internal/cache"
 	"geminictl/internal/gemini"
+	"geminictl/internal/relocate"
 	"geminictl/internal/scanner"
-	"sort"
+	"geminictl/internal/search"
+	"geminictl/internal/trash"
+	"geminictl/internal/watch"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 )
 
 // ProjectStatus represents the resolution state of a project.
-type ProjectStatus int
+type ProjectStatus = cache.Status
 
 const (
-	StatusScanning ProjectStatus = iota
-	StatusValid
-	StatusUnlocated
-	StatusOrphaned
+	StatusScanning  = cache.StatusScanning
+	StatusValid     = cache.StatusValid
+	StatusUnlocated = cache.StatusUnlocated
+	StatusOrphaned  = cache.StatusOrphaned
 )
 
 type Focus int
@@ -44,6 +50,16 @@ const (
 	ModeOpen
 	ModeDeleteSession
 	ModeMoveSession
+	ModeCheckpoints
+	ModeDeleteCheckpoint
+	ModeRenameCheckpoint
+	ModeCopyCheckpoint
+	ModeSearch
+	ModeSearchResults
+	ModeRelocate
+	ModeEditMeta
+	ModeTagFilter
+	ModeExport
 )
 
 // Style definitions
@@ -83,11 +99,7 @@ func renderCursor(focused bool, current bool) string {
 }
 
 func renderHash(id string) string {
-	shortID := id
-	if len(id) > 8 {
-		shortID = id[:8]
-	}
-	return highlightStyle.Render(fmt.Sprintf("[%s]", shortID))
+	return highlightStyle.Render(fmt.Sprintf("[%s]", gemini.ShortID(id)))
 }
 
 func getRowStyle(selected bool) lipgloss.Style {
@@ -117,11 +129,27 @@ func truncateMiddle(s string, max int) string {
 	return s[:half] + "..." + s[len(s)-half:]
 }
 
+// truncateEnd shortens s to max runes, ending it with an ellipsis.
+func truncateEnd(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	if max < 1 {
+		return ""
+	}
+	return string(r[:max-1]) + "…"
+}
+
 type projectView struct {
 	ID       string
 	Path     string
 	Status   ProjectStatus
 	Sessions []scanner.Session
+
+	LogEntries  int
+	LogsSize    int64
+	Checkpoints []scanner.Checkpoint
 }
 
 type Model struct {
@@ -135,34 +163,81 @@ type Model struct {
 	Height        int
 	Err           error
 
+	// ResolveOptions configures the resolution of project paths.
+	ResolveOptions scanner.ResolveOptions
+
+	// Fingerprints describe the directories of projects, for finding them
+	// again once they are orphaned. Nil disables the relocation assistant.
+	Fingerprints *relocate.Store
+
 	scanner *scanner.Scanner
 	cache   *cache.Cache
+	trash   *trash.Trash
+	index   *search.Index
+	watcher *watch.Watcher
 	spinner spinner.Model
 	modal   Modal
+	undo    []undoAction
+
+	relocating     string             // ID of the project searched for by the relocation assistant
+	cancelRelocate context.CancelFunc // Stops the search of the relocation assistant
+
+	// ctx is canceled when the TUI quits, stopping background work.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	progress *scanner.Progress
+
+	// checkpoint is the name of the checkpoint an operation applies to.
+	checkpoint string
+	// query is the last search query, offered again when searching.
+	query string
+
+	// tagFilter is the tag projects are filtered by, empty to show all.
+	tagFilter string
+	// metaField is the field of the metadata being edited, of the session
+	// metaSession or, if it is empty, of the selected project.
+	metaField   string
+	metaSession string
+	// exportSession is the session being exported or, if it is empty, the
+	// selected project whose sessions are all exported.
+	exportSession string
 }
 
 // Internal message to carry the channel along with the result
 type resolutionPacket struct {
-	res scanner.Resolution
-	ch  <-chan scanner.Resolution
+	ev  scanner.ResolveEvent
+	ch  <-chan scanner.ResolveEvent
+	ids []string
 }
 
-type ScanFinishedMsg struct{}
+// ScanFinishedMsg is sent when the resolution of the given projects ended.
+type ScanFinishedMsg struct {
+	IDs []string
+}
+
+// FilesChangedMsg carries a batch of changes reported by the storage watcher.
+type FilesChangedMsg struct {
+	Changes []watch.Change
+}
 
 type SessionOpenedMsg struct {
 	Err error
 }
 
-func NewModel(scanned []scanner.ProjectData, c *cache.Cache, sc *scanner.Scanner) *Model {
+// NewModel creates the TUI model. If w is not nil, the model applies the
+// changes it reports while running.
+func NewModel(scanned []scanner.ProjectData, c *cache.Cache, sc *scanner.Scanner, tr *trash.Trash, idx *search.Index, w *watch.Watcher) *Model {
 	var projects []projectView
 	for _, p := range scanned {
-		projects = append(projects, deriveProjectView(p.ID, p.Sessions, c))
+		projects = append(projects, deriveProjectView(p, c))
 	}
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(highlight)
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	m := &Model{
 		Projects: projects,
 		Selected: 0,
@@ -170,35 +245,44 @@ func NewModel(scanned []scanner.ProjectData, c *cache.Cache, sc *scanner.Scanner
 		Mode:     ModeNav,
 		scanner:  sc,
 		cache:    c,
+		trash:    tr,
+		index:    idx,
+		watcher:  w,
 		spinner:  s,
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 	m.sortProjects()
 	return m
 }
 
-func deriveProjectView(id string, sessions []scanner.Session, c *cache.Cache) projectView {
-	path, inCache := c.Get(id)
+// Close stops background work such as the resolution of project paths.
+func (m *Model) Close() {
+	m.cancel()
+}
 
-	var status ProjectStatus
-	if !inCache {
-		status = StatusScanning
-		path = id
-	} else if path == "" {
-		status = StatusUnlocated
-		path = id
-	} else {
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			status = StatusOrphaned
-		} else {
-			status = StatusValid
-		}
-	}
+func deriveProjectView(p scanner.ProjectData, c *cache.Cache) projectView {
+	path, status := c.Resolve(p.ID)
 
 	return projectView{
-		ID:       id,
-		Path:     path,
-		Status:   status,
-		Sessions: sessions,
+		ID:          p.ID,
+		Path:        path,
+		Status:      status,
+		Sessions:    p.Sessions,
+		LogEntries:  p.LogEntries,
+		LogsSize:    p.LogsSize,
+		Checkpoints: p.Checkpoints,
+	}
+}
+
+// data returns the scanner data the view was derived from.
+func (p projectView) data() scanner.ProjectData {
+	return scanner.ProjectData{
+		ID:          p.ID,
+		Sessions:    p.Sessions,
+		LogEntries:  p.LogEntries,
+		LogsSize:    p.LogsSize,
+		Checkpoints: p.Checkpoints,
 	}
 }
 
@@ -213,6 +297,10 @@ func (m *Model) sortProjects() {
 	}
 
 	sort.Slice(m.Projects, func(i, j int) bool {
+		pi, pj := m.cache.ProjectMeta(m.Projects[i].ID).Pinned, m.cache.ProjectMeta(m.Projects[j].ID).Pinned
+		if pi != pj {
+			return pi
+		}
 		return m.Projects[i].Path < m.Projects[j].Path
 	})
 
@@ -239,7 +327,7 @@ func (m *Model) syncState(scanned []scanner.ProjectData) {
 	// 1. Capture current selection by ID
 	var selectedProjectID string
 	var selectedSessionID string
-	
+
 	if len(m.Projects) > 0 && m.Selected < len(m.Projects) {
 		p := m.Projects[m.Selected]
 		selectedProjectID = p.ID
@@ -251,7 +339,7 @@ func (m *Model) syncState(scanned []scanner.ProjectData) {
 	// 2. Build new state
 	var projects []projectView
 	for _, p := range scanned {
-		projects = append(projects, deriveProjectView(p.ID, p.Sessions, m.cache))
+		projects = append(projects, deriveProjectView(p, m.cache))
 	}
 	m.Projects = projects
 	m.sortProjects()
@@ -262,7 +350,7 @@ func (m *Model) syncState(scanned []scanner.ProjectData) {
 			if p.ID == selectedProjectID {
 				m.Selected = i
 				m.Cursor = i // Keep cursor synced with selected
-				
+
 				// 4. Restore Session selection within the project
 				if selectedSessionID != "" {
 					for j, s := range p.Sessions {
@@ -284,10 +372,10 @@ func (m *Model) syncState(scanned []scanner.ProjectData) {
 		m.SessionCursor = 0
 		return
 	}
-	
+
 	m.Selected = min(m.Selected, len(m.Projects)-1)
 	m.Cursor = min(m.Cursor, len(m.Projects)-1)
-	
+
 	p := m.Projects[m.Selected]
 	if len(p.Sessions) > 0 {
 		m.SessionCursor = min(m.SessionCursor, len(p.Sessions)-1)
@@ -297,7 +385,7 @@ func (m *Model) syncState(scanned []scanner.ProjectData) {
 }
 
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.startScanningCmd())
+	return tea.Batch(m.spinner.Tick, m.startScanningCmd(), m.waitForChanges())
 }
 
 func (m *Model) startScanningCmd() tea.Cmd {
@@ -307,24 +395,84 @@ func (m *Model) startScanningCmd() tea.Cmd {
 			unknownIDs = append(unknownIDs, p.ID)
 		}
 	}
+	return m.resolveCmd(unknownIDs)
+}
 
-	if len(unknownIDs) == 0 {
+// resolveCmd starts resolving the paths of the given projects.
+func (m *Model) resolveCmd(ids []string) tea.Cmd {
+	if len(ids) == 0 {
 		return nil
 	}
 
-	c := m.scanner.ResolveBackground(unknownIDs)
+	opts := m.ResolveOptions
+	opts.KnownPaths = m.cache.Paths()
+	c := m.scanner.Resolve(m.ctx, ids, opts)
 
 	return func() tea.Msg {
-		return waitForResolution(c)
+		return waitForResolution(c, ids)
 	}
 }
 
-func waitForResolution(c <-chan scanner.Resolution) tea.Msg {
-	res, ok := <-c
+func waitForResolution(c <-chan scanner.ResolveEvent, ids []string) tea.Msg {
+	ev, ok := <-c
 	if !ok {
-		return ScanFinishedMsg{}
+		return ScanFinishedMsg{IDs: ids}
+	}
+	return resolutionPacket{ev, c, ids}
+}
+
+// waitForChanges waits for the next batch of changes from the watcher.
+func (m *Model) waitForChanges() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	ch := m.watcher.Changes()
+	return func() tea.Msg {
+		changes, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return FilesChangedMsg{Changes: changes}
 	}
-	return resolutionPacket{res, c}
+}
+
+// applyChanges rescans the projects affected by a batch of changes and
+// merges the results into the current state. It returns a command resolving
+// the paths of projects that appeared.
+func (m *Model) applyChanges(changes []watch.Change) tea.Cmd {
+	affected := make(map[string]bool)
+	for _, c := range changes {
+		affected[c.ProjectID] = true
+	}
+
+	known := make(map[string]bool)
+	var scanned []scanner.ProjectData
+	for _, p := range m.Projects {
+		known[p.ID] = true
+		if !affected[p.ID] {
+			scanned = append(scanned, p.data())
+			continue
+		}
+		if updated, err := m.scanner.ScanProject(p.ID); err == nil {
+			scanned = append(scanned, updated)
+		}
+	}
+
+	var added []string
+	for id := range affected {
+		if known[id] {
+			continue
+		}
+		if p, err := m.scanner.ScanProject(id); err == nil {
+			scanned = append(scanned, p)
+			if _, status := m.cache.Resolve(id); status == StatusScanning {
+				added = append(added, id)
+			}
+		}
+	}
+
+	m.syncState(scanned)
+	return m.resolveCmd(added)
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -350,6 +498,59 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.modal == nil {
 			return m, cmd
 		}
+
+	// Background results are applied even while a modal is open.
+	case resolutionPacket:
+		if msg.ev.Progress != nil {
+			m.progress = msg.ev.Progress
+		} else if res := msg.ev.Resolution; res != nil {
+			for i, p := range m.Projects {
+				if p.ID == res.Hash {
+					// A path mined from sessions or histories may no longer
+					// exist; the project is then orphaned at its former
+					// location.
+					m.cache.Set(res.Hash, res.Path)
+					m.cache.SetSource(res.Hash, res.Source)
+					m.cache.SetMatch(res.Hash, res.Variant)
+					_ = m.cache.Save()
+					m.Projects[i].Path, m.Projects[i].Status = m.cache.Resolve(res.Hash)
+					break
+				}
+			}
+			m.sortProjects()
+		}
+		return m, func() tea.Msg {
+			return waitForResolution(msg.ch, msg.ids)
+		}
+	case ScanFinishedMsg:
+		// A canceled resolution must not mark its projects as unlocated.
+		if m.ctx.Err() != nil {
+			return m, nil
+		}
+		m.progress = nil
+		pending := make(map[string]bool)
+		for _, id := range msg.IDs {
+			pending[id] = true
+		}
+		for i := range m.Projects {
+			if m.Projects[i].Status == StatusScanning && pending[m.Projects[i].ID] {
+				m.Projects[i].Status = StatusUnlocated
+				m.cache.Set(m.Projects[i].ID, "")
+				_ = m.cache.Save()
+			}
+		}
+		return m, nil
+	case FilesChangedMsg:
+		return m, tea.Batch(m.applyChanges(msg.Changes), m.waitForChanges())
+	case RelocateResultsMsg:
+		if m.Mode == ModeRelocate && msg.ProjectID == m.relocating {
+			if modal, ok := m.modal.(RelocateModal); ok {
+				modal.Searching = false
+				modal.Result = msg.Result
+				m.modal = modal
+			}
+		}
+		return m, nil
 	}
 
 	// 3. Handle Active Modal Update
@@ -363,6 +564,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q", "esc":
+			m.cancel()
 			return m, tea.Quit
 		case "h", "left", "H":
 			m.Focus = FocusProjects
@@ -370,11 +572,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Focus = FocusSessions
 		case "up", "k":
 			if m.Focus == FocusProjects {
-				if m.Cursor > 0 {
-					m.Cursor--
-					m.Selected = m.Cursor
-					m.SessionCursor = 0
-				}
+				m.moveCursor(-1)
 			} else {
 				if m.SessionCursor > 0 {
 					m.SessionCursor--
@@ -382,11 +580,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "down", "j":
 			if m.Focus == FocusProjects {
-				if m.Cursor < len(m.Projects)-1 {
-					m.Cursor++
-					m.Selected = m.Cursor
-					m.SessionCursor = 0
-				}
+				m.moveCursor(1)
 			} else {
 				p := m.Projects[m.Selected]
 				if m.SessionCursor < len(p.Sessions)-1 {
@@ -411,7 +605,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					s := p.Sessions[m.SessionCursor]
 					m.modal = ConfirmModal{
 						Title:  "Open Session",
-						Prompt: fmt.Sprintf("Open session [%s] in Gemini CLI?", s.ID[:8]),
+						Prompt: fmt.Sprintf("Open session [%s] in Gemini CLI?", gemini.ShortID(s.ID)),
 					}
 					m.Mode = ModeOpen
 					return m, m.modal.Init()
@@ -433,8 +627,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.Mode = ModeInspect
 						m.modal = NewInspectModal(fullSession)
 						// We need to trigger a resize for the modal to initialize viewport
-						return m, func() tea.Msg { 
-							return tea.WindowSizeMsg{Width: m.Width, Height: m.Height} 
+						return m, func() tea.Msg {
+							return tea.WindowSizeMsg{Width: m.Width, Height: m.Height}
 						}
 					}
 				}
@@ -450,7 +644,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if p.Status == StatusUnlocated || p.Status == StatusScanning {
 					startDir, _ = os.UserHomeDir()
 				}
-				m.modal = NewTextInputModal(fmt.Sprintf("Move [%s] to:", p.ID[:8]), startDir, "Absolute path...")
+				m.modal = NewTextInputModal(fmt.Sprintf("Move [%s] to:", gemini.ShortID(p.ID)), startDir, "Absolute path...")
 				return m, m.modal.Init()
 			} else {
 				// Move Session
@@ -465,7 +659,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					label := other.Path
 					if other.Status == StatusUnlocated {
-						label = fmt.Sprintf("[%s] Unlocated", other.ID[:8])
+						label = fmt.Sprintf("[%s] Unlocated", gemini.ShortID(other.ID))
 					}
 					options = append(options, ListOption{ID: other.ID, Label: label})
 				}
@@ -474,7 +668,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					break
 				}
 				m.modal = ListSelectorModal{
-					Title:   fmt.Sprintf("Move Session [%s] to:", s.ID[:8]),
+					Title:   fmt.Sprintf("Move Session [%s] to:", gemini.ShortID(s.ID)),
 					Options: options,
 				}
 				m.Mode = ModeMoveSession
@@ -492,7 +686,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.modal = ConfirmModal{
 					Title:  "Confirm Deletion",
-					Prompt: fmt.Sprintf("Permanently delete project [%s] and its %d sessions (%d messages)?", p.ID[:8], len(p.Sessions), totalMessages),
+					Prompt: fmt.Sprintf("Move project [%s] and its %d sessions (%d messages) to the trash?", gemini.ShortID(p.ID), len(p.Sessions), totalMessages),
 				}
 				m.Mode = ModeDelete
 				return m, m.modal.Init()
@@ -504,37 +698,90 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s := p.Sessions[m.SessionCursor]
 				m.modal = ConfirmModal{
 					Title:  "Delete Session",
-					Prompt: fmt.Sprintf("Permanently delete session [%s] (%d messages)?", s.ID[:8], s.MessageCount),
+					Prompt: fmt.Sprintf("Move session [%s] (%d messages) to the trash?", gemini.ShortID(s.ID), s.MessageCount),
 				}
 				m.Mode = ModeDeleteSession
 				return m, m.modal.Init()
 			}
-		}
-
-	case resolutionPacket:
-		for i, p := range m.Projects {
-			if p.ID == msg.res.Hash {
-				m.Projects[i].Path = msg.res.Path
-				m.Projects[i].Status = StatusValid
-
-				m.cache.Set(msg.res.Hash, msg.res.Path)
-				_ = m.cache.Save()
+		case "c":
+			if len(m.Projects) == 0 {
 				break
 			}
-		}
-		m.sortProjects()
-		return m, func() tea.Msg {
-			return waitForResolution(msg.ch)
+			p := m.Projects[m.Selected]
+			if len(p.Checkpoints) == 0 {
+				m.modal = ErrorModal{
+					Title: "No Checkpoints",
+					Err:   fmt.Errorf("project [%s] has no checkpoints. Create one with '/chat save <name>' in Gemini CLI.", gemini.ShortID(p.ID)),
+				}
+				return m, m.modal.Init()
+			}
+			m.modal = CheckpointModal{
+				Title:       fmt.Sprintf("Checkpoints of [%s]", gemini.ShortID(p.ID)),
+				Checkpoints: p.Checkpoints,
+			}
+			m.Mode = ModeCheckpoints
+			return m, m.modal.Init()
+		case "r":
+			if len(m.Projects) == 0 || m.Focus != FocusProjects {
+				break
+			}
+			return m.startRelocate(m.Projects[m.Selected])
+		case "a":
+			return m.startEditMeta(metaAlias)
+		case "e":
+			return m.startEditMeta(metaName)
+		case "t":
+			return m.startEditMeta(metaTags)
+		case "n":
+			return m.startEditMeta(metaNote)
+		case "p":
+			if len(m.Projects) == 0 || m.Focus != FocusProjects {
+				break
+			}
+			if err := m.togglePin(); err != nil {
+				m.modal = ErrorModal{Title: "Pin Failed", Err: err}
+				return m, m.modal.Init()
+			}
+		case "f":
+			return m.startTagFilter()
+		case "x":
+			return m.startExport()
+		case "/":
+			m.modal = NewTextInputModal("Search all sessions:", m.query, `words, "phrase", prefix*, -exclude, role:user...`)
+			m.Mode = ModeSearch
+			return m, m.modal.Init()
+		case "u":
+			if err := m.undoLast(); err != nil {
+				m.modal = ErrorModal{
+					Title: "Undo Failed",
+					Err:   err,
+				}
+				return m, m.modal.Init()
+			}
 		}
 
-	case ScanFinishedMsg:
-		for i := range m.Projects {
-			if m.Projects[i].Status == StatusScanning {
-				m.Projects[i].Status = StatusUnlocated
-				m.cache.Set(m.Projects[i].ID, "")
-				_ = m.cache.Save()
+	case SearchResultsMsg:
+		if msg.Err != nil {
+			m.modal = ErrorModal{Title: "Search Failed", Err: msg.Err}
+			return m, m.modal.Init()
+		}
+		labels := make(map[string]string)
+		for _, p := range m.Projects {
+			labels[p.ID] = collapseHome(p.Path)
+			if p.Status == StatusUnlocated {
+				labels[p.ID] = "[Unlocated]"
 			}
+			if alias := m.cache.ProjectMeta(p.ID).Alias; alias != "" {
+				labels[p.ID] = alias
+			}
+		}
+		m.modal = SearchModal{
+			Title:  fmt.Sprintf("%d matches for %q", len(msg.Hits), msg.Query),
+			Hits:   msg.Hits,
+			Labels: labels,
 		}
+		m.Mode = ModeSearchResults
+		return m, m.modal.Init()
 	case SessionOpenedMsg:
 		if msg.Err != nil {
 			m.modal = ErrorModal{
@@ -553,6 +800,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) handleModalResult(res ModalResult) (tea.Model, tea.Cmd) {
 	m.modal = nil
+	if m.cancelRelocate != nil {
+		m.cancelRelocate()
+		m.cancelRelocate = nil
+	}
 
 	if res.Canceled {
 		m.Mode = ModeNav
@@ -563,40 +814,59 @@ func (m *Model) handleModalResult(res ModalResult) (tea.Model, tea.Cmd) {
 	case ModeDelete:
 		if res.Value.(bool) {
 			p := m.Projects[m.Selected]
-			if err := gemini.DeleteProject(m.scanner.RootDir, p.ID); err != nil {
-				m.Err = err
-			} else {
-				_ = m.cache.Delete(p.ID)
-				m.Projects = append(m.Projects[:m.Selected], m.Projects[m.Selected+1:]...)
-				if m.Selected >= len(m.Projects) && len(m.Projects) > 0 {
-					m.Selected = len(m.Projects) - 1
-				}
-				m.Cursor = m.Selected
+			path, _ := m.cache.Get(p.ID)
+			meta := m.cache.ProjectMeta(p.ID)
+			sessionMeta := m.cache.SessionsMeta(p.data().SessionIDs()...)
+			e, err := m.trash.DeleteProject(m.scanner.RootDir, p.ID, path, meta, sessionMeta)
+			if err != nil {
+				m.modal = ErrorModal{Title: "Delete Failed", Err: err}
+				m.Mode = ModeNav
+				return m, m.modal.Init()
+			}
+			m.pushUndo(undoTrash(e))
+			m.Projects = append(m.Projects[:m.Selected], m.Projects[m.Selected+1:]...)
+			if m.Selected >= len(m.Projects) && len(m.Projects) > 0 {
+				m.Selected = len(m.Projects) - 1
 			}
+			m.Cursor = m.Selected
+			m.setTagFilter(m.tagFilter)
 		}
 	case ModeMove:
 		newPath := res.Value.(string)
 		oldID := m.Projects[m.Selected].ID
+		rec, _ := m.cache.Record(oldID)
 		newID, err := gemini.MoveProject(m.scanner.RootDir, oldID, newPath)
 		if err != nil {
 			m.Err = err
 		} else {
-			_ = m.cache.Delete(oldID)
-			m.cache.Set(newID, newPath)
+			if newID != oldID {
+				m.pushUndo(undoMoveProject(oldID, newID, rec))
+			}
+			m.cache.Move(oldID, newID, newPath)
 			_ = m.cache.Save()
-			m.Projects[m.Selected] = deriveProjectView(newID, m.Projects[m.Selected].Sessions, m.cache)
+			moved := m.Projects[m.Selected].data()
+			moved.ID = newID
+			m.Projects[m.Selected] = deriveProjectView(moved, m.cache)
 			m.sortProjects()
 		}
 	case ModeOpen:
 		if res.Value.(bool) {
 			p := m.Projects[m.Selected]
 			s := p.Sessions[m.SessionCursor]
-			
+
 			// Wrap in a shell to clear the screen and show a loading message
-			script := fmt.Sprintf("clear && echo 'Launching Gemini CLI for session [%s]...' && gemini --resume %s && clear", s.ID[:8], s.ID)
+			script := fmt.Sprintf("clear && echo 'Launching Gemini CLI for session [%s]...' && gemini --resume %s && clear", gemini.ShortID(s.ID), s.ID)
 			c := exec.Command("sh", "-c", script)
 			c.Dir = p.Path
-			
+			// Start in the spelling of the directory the session was hashed
+			// under, so that Gemini CLI finds it.
+			if a, ok := m.cache.Match(p.ID); ok {
+				if info, err := os.Stat(a.Path); err == nil && info.IsDir() {
+					c.Dir = a.Path
+					c.Env = append(os.Environ(), "PWD="+a.Path)
+				}
+			}
+
 			return m, tea.ExecProcess(c, func(err error) tea.Msg {
 				return SessionOpenedMsg{Err: err}
 			})
@@ -605,13 +875,17 @@ func (m *Model) handleModalResult(res ModalResult) (tea.Model, tea.Cmd) {
 		if res.Value.(bool) {
 			p := &m.Projects[m.Selected]
 			s := p.Sessions[m.SessionCursor]
-			if err := gemini.DeleteSession(m.scanner.RootDir, p.ID, s.ID); err != nil {
-				m.Err = err
-			} else {
-				// Refresh the entire state to be safe and simple
-				if updated, err := m.scanner.Scan(); err == nil {
-					m.syncState(updated)
-				}
+			path, _ := m.cache.Get(p.ID)
+			e, err := m.trash.DeleteSession(m.scanner.RootDir, p.ID, path, s.ID, m.cache.SessionMeta(s.ID))
+			if err != nil {
+				m.modal = ErrorModal{Title: "Delete Failed", Err: err}
+				m.Mode = ModeNav
+				return m, m.modal.Init()
+			}
+			m.pushUndo(undoTrash(e))
+			// Refresh the entire state to be safe and simple
+			if updated, err := m.scanner.Scan(); err == nil {
+				m.syncState(updated)
 			}
 		}
 	case ModeMoveSession:
@@ -621,17 +895,213 @@ func (m *Model) handleModalResult(res ModalResult) (tea.Model, tea.Cmd) {
 		if err := gemini.MoveSession(m.scanner.RootDir, p.ID, targetProjectID, s.ID); err != nil {
 			m.Err = err
 		} else {
+			m.pushUndo(undoMoveSession(p.ID, targetProjectID, s.ID))
 			// Refresh the entire state
 			if updated, err := m.scanner.Scan(); err == nil {
 				m.syncState(updated)
 			}
 		}
+	case ModeCheckpoints:
+		return m.handleCheckpointAction(res.Value.(CheckpointAction))
+	case ModeDeleteCheckpoint:
+		if res.Value.(bool) {
+			p := m.Projects[m.Selected]
+			if err := gemini.DeleteCheckpoint(m.scanner.RootDir, p.ID, m.checkpoint); err != nil {
+				m.modal = ErrorModal{Title: "Delete Failed", Err: err}
+				m.Mode = ModeNav
+				return m, m.modal.Init()
+			}
+			m.refresh()
+		}
+	case ModeRenameCheckpoint:
+		newName := res.Value.(string)
+		p := m.Projects[m.Selected]
+		if newName != "" && newName != m.checkpoint {
+			if err := gemini.RenameCheckpoint(m.scanner.RootDir, p.ID, m.checkpoint, newName); err != nil {
+				m.modal = ErrorModal{Title: "Rename Failed", Err: err}
+				m.Mode = ModeNav
+				return m, m.modal.Init()
+			}
+			m.refresh()
+		}
+	case ModeCopyCheckpoint:
+		targetProjectID := res.Value.(string)
+		p := m.Projects[m.Selected]
+		if err := gemini.CopyCheckpoint(m.scanner.RootDir, p.ID, m.checkpoint, targetProjectID, ""); err != nil {
+			m.modal = ErrorModal{Title: "Copy Failed", Err: err}
+			m.Mode = ModeNav
+			return m, m.modal.Init()
+		}
+		m.refresh()
+	case ModeSearch:
+		m.query = strings.TrimSpace(res.Value.(string))
+		m.Mode = ModeNav
+		if m.query == "" {
+			return m, nil
+		}
+		return m, searchCmd(m.index, m.scanner.RootDir, m.query)
+	case ModeSearchResults:
+		return m.openSearchHit(res.Value.(search.Hit))
+	case ModeEditMeta:
+		if err := m.applyMeta(res.Value.(string)); err != nil {
+			m.modal = ErrorModal{Title: "Cannot Save", Err: err}
+			m.Mode = ModeNav
+			return m, m.modal.Init()
+		}
+	case ModeTagFilter:
+		m.setTagFilter(res.Value.(string))
+	case ModeExport:
+		m.Mode = ModeNav
+		done, err := m.applyExport(res.Value.(exportRequest))
+		if err != nil {
+			m.modal = ErrorModal{Title: "Export Failed", Err: err}
+		} else {
+			m.modal = InfoModal{Title: "Exported", Message: done}
+		}
+		return m, m.modal.Init()
+	case ModeRelocate:
+		// Move the project searched for, which the selection may no longer
+		// point at.
+		for i, p := range m.Projects {
+			if p.ID == m.relocating {
+				m.Selected, m.Cursor = i, i
+				m.Mode = ModeMove
+				return m.handleModalResult(res)
+			}
+		}
 	}
 
 	m.Mode = ModeNav
 	return m, nil
 }
 
+// startRelocate opens the relocation assistant for an orphaned project and
+// starts searching for its new location.
+func (m *Model) startRelocate(p projectView) (tea.Model, tea.Cmd) {
+	if p.Status != StatusOrphaned || m.Fingerprints == nil {
+		m.modal = ErrorModal{
+			Title: "Cannot Relocate",
+			Err:   fmt.Errorf("project [%s] is not orphaned. The relocation assistant finds projects whose directory was moved or renamed.", gemini.ShortID(p.ID)),
+		}
+		return m, m.modal.Init()
+	}
+
+	fp, ok := m.Fingerprints.Get(p.ID)
+	if !ok {
+		fp = relocate.Fingerprint{Names: relocate.NamesFromSessions(m.scanner.RootDir, p.ID, p.Path)}
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancelRelocate = cancel
+	m.relocating = p.ID
+	m.modal = RelocateModal{
+		Title:     fmt.Sprintf("Relocate [%s]", gemini.ShortID(p.ID)),
+		OldPath:   p.Path,
+		Searching: true,
+	}
+	m.Mode = ModeRelocate
+	return m, relocateCmd(ctx, fp, p.ID, p.Path, m.ResolveOptions.Resolve)
+}
+
+// openSearchHit selects the project and session of a search hit and inspects
+// the session.
+func (m *Model) openSearchHit(hit search.Hit) (tea.Model, tea.Cmd) {
+	m.Mode = ModeNav
+	for i, p := range m.Projects {
+		if p.ID != hit.ProjectID {
+			continue
+		}
+		m.Selected, m.Cursor = i, i
+		for j, s := range p.Sessions {
+			if s.ID == hit.SessionID {
+				m.SessionCursor = j
+				m.Focus = FocusSessions
+				break
+			}
+		}
+		break
+	}
+
+	s, err := gemini.GetSession(m.scanner.RootDir, hit.ProjectID, hit.SessionID)
+	if err != nil {
+		m.modal = ErrorModal{Title: "Cannot Open Session", Err: err}
+		return m, m.modal.Init()
+	}
+	m.modal = NewInspectModal(s)
+	m.Mode = ModeInspect
+	return m, func() tea.Msg {
+		return tea.WindowSizeMsg{Width: m.Width, Height: m.Height}
+	}
+}
+
+// handleCheckpointAction performs or prepares the action chosen in the
+// CheckpointModal, opening a follow-up modal where needed.
+func (m *Model) handleCheckpointAction(a CheckpointAction) (tea.Model, tea.Cmd) {
+	p := m.Projects[m.Selected]
+	m.checkpoint = a.Name
+	m.Mode = ModeNav
+
+	switch a.Kind {
+	case CheckpointInspect:
+		cp, err := gemini.ReadCheckpoint(m.scanner.RootDir, p.ID, a.Name)
+		if err != nil {
+			m.modal = ErrorModal{Title: "Cannot Inspect", Err: err}
+			return m, m.modal.Init()
+		}
+		inspect := NewInspectModal(gemini.CheckpointSession(cp, p.ID))
+		inspect.Title = fmt.Sprintf("Inspect Checkpoint %q", a.Name)
+		m.modal = inspect
+		m.Mode = ModeInspect
+		return m, func() tea.Msg {
+			return tea.WindowSizeMsg{Width: m.Width, Height: m.Height}
+		}
+	case CheckpointDelete:
+		m.modal = ConfirmModal{
+			Title:  "Delete Checkpoint",
+			Prompt: fmt.Sprintf("Permanently delete checkpoint %q?", a.Name),
+		}
+		m.Mode = ModeDeleteCheckpoint
+	case CheckpointRename:
+		m.modal = NewTextInputModal(fmt.Sprintf("Rename checkpoint %q to:", a.Name), a.Name, "Checkpoint name...")
+		m.Mode = ModeRenameCheckpoint
+	case CheckpointCopy:
+		var options []ListOption
+		for _, other := range m.Projects {
+			if other.ID == p.ID {
+				continue
+			}
+			label := other.Path
+			if other.Status == StatusUnlocated {
+				label = fmt.Sprintf("[%s] Unlocated", gemini.ShortID(other.ID))
+			}
+			options = append(options, ListOption{ID: other.ID, Label: label})
+		}
+		if len(options) == 0 {
+			m.modal = ErrorModal{Title: "Cannot Copy", Err: fmt.Errorf("no other projects to copy checkpoint to")}
+			return m, m.modal.Init()
+		}
+		m.modal = ListSelectorModal{
+			Title:   fmt.Sprintf("Copy checkpoint %q to:", a.Name),
+			Options: options,
+		}
+		m.Mode = ModeCopyCheckpoint
+	case CheckpointPromote:
+		if _, err := gemini.PromoteCheckpoint(m.scanner.RootDir, p.ID, a.Name); err != nil {
+			m.modal = ErrorModal{Title: "Promote Failed", Err: err}
+			return m, m.modal.Init()
+		}
+		m.refresh()
+		return m, nil
+	}
+	return m, m.modal.Init()
+}
+
+// refresh rescans the storage and updates the state, preserving selection.
+func (m *Model) refresh() {
+	if updated, err := m.scanner.Scan(); err == nil {
+		m.syncState(updated)
+	}
+}
+
 func (m *Model) isScanningGlobal() bool {
 	for _, p := range m.Projects {
 		if p.Status == StatusScanning {
@@ -652,9 +1122,13 @@ func (m *Model) View() string {
 	paneHeight := m.Height - 6
 
 	var sidebar strings.Builder
-	sidebar.WriteString(titleStyle.Render("Projects") + "\n")
+	title := titleStyle.Render("Projects")
+	if m.tagFilter != allTags {
+		title += highlightStyle.Render(" #" + m.tagFilter)
+	}
+	sidebar.WriteString(title + "\n")
 	if m.isScanningGlobal() {
-		text := "Resolving directories... " + m.spinner.View()
+		text := renderProgress(m.progress) + " " + m.spinner.View()
 		padding := sidebarWidth - lipgloss.Width(text) - 4
 		if padding < 0 {
 			padding = 0
@@ -665,9 +1139,19 @@ func (m *Model) View() string {
 	}
 
 	for i, p := range m.Projects {
+		if !m.visible(p) {
+			continue
+		}
 		cursor := renderCursor(m.Focus == FocusProjects, m.Cursor == i)
 		style := getRowStyle(m.Selected == i)
 		idStr := renderHash(p.ID) + " "
+		meta := m.cache.ProjectMeta(p.ID)
+		if meta.Pinned {
+			idStr = lipgloss.NewStyle().Foreground(special).Render("★") + " " + idStr
+		}
+		if meta.Alias != "" {
+			idStr += style.Bold(true).Render(meta.Alias) + " "
+		}
 		pathStr := collapseHome(p.Path)
 
 		availableWidth := sidebarWidth - 6 - lipgloss.Width(idStr)
@@ -707,7 +1191,30 @@ func (m *Model) View() string {
 			}
 			displayPath = displayID
 		}
-		main.WriteString(titleStyle.Render(fmt.Sprintf("Sessions for %s", displayPath)) + "\n\n")
+		meta := m.cache.ProjectMeta(p.ID)
+		if meta.Alias != "" {
+			main.WriteString(titleStyle.Render(fmt.Sprintf("Sessions for %s (%s)", meta.Alias, displayPath)) + "\n")
+		} else {
+			main.WriteString(titleStyle.Render(fmt.Sprintf("Sessions for %s", displayPath)) + "\n")
+		}
+		main.WriteString(renderMeta(meta, mainWidth-4))
+		if p.Status == StatusOrphaned {
+			main.WriteString(lipgloss.NewStyle().Foreground(warning).Width(mainWidth-4).Render(
+				fmt.Sprintf("Former location %s no longer exists. Press [r] to find where it went or [m] to move the project.", displayPath)) + "\n")
+		}
+		if a, ok := m.cache.Match(p.ID); ok {
+			main.WriteString(lipgloss.NewStyle().Foreground(subtle).Width(mainWidth-4).Render(
+				fmt.Sprintf("Matched as %s (%s)", collapseHome(a.Path), a.Kind)) + "\n")
+		}
+		if rec, ok := m.cache.Record(p.ID); ok && len(rec.PreviousPaths) > 0 {
+			previous := make([]string, len(rec.PreviousPaths))
+			for i, path := range rec.PreviousPaths {
+				previous[i] = collapseHome(path)
+			}
+			main.WriteString(lipgloss.NewStyle().Foreground(subtle).Width(mainWidth-4).Render(
+				"Previously at "+strings.Join(previous, ", ")) + "\n")
+		}
+		main.WriteString(lipgloss.NewStyle().Foreground(subtle).Width(mainWidth-4).Render(renderArtifacts(p)) + "\n\n")
 
 		if len(p.Sessions) == 0 {
 			main.WriteString("No sessions found.")
@@ -717,13 +1224,31 @@ func (m *Model) View() string {
 				style := getRowStyle(m.Focus == FocusSessions && m.SessionCursor == i)
 
 				idStr := renderHash(s.ID)
-				content := fmt.Sprintf("%s %s | %s",
-					idStr,
-					style.Render(fmt.Sprintf("%d messages", s.MessageCount)),
-					style.Render(formatRelativeTime(s.LastUpdate)))
+				stats := fmt.Sprintf(" | %d messages | %s", s.MessageCount, formatRelativeTime(s.LastUpdate))
+				title := m.cache.SessionTitle(s.ID, s.Title)
+				titleWidth := mainWidth - 4 - 2 - lipgloss.Width(idStr) - 1 - lipgloss.Width(stats)
+				var titleStr string
+				if title == "" {
+					titleStr = lipgloss.NewStyle().Foreground(subtle).Render(truncateEnd("(no prompt)", titleWidth))
+				} else {
+					titleStr = style.Render(truncateEnd(title, titleWidth))
+				}
+				content := fmt.Sprintf("%s %s%s", idStr, titleStr, style.Render(stats))
+				sm := m.cache.SessionMeta(s.ID)
+				if sm.Alias != "" {
+					content += " | " + style.Bold(true).Render(sm.Alias)
+				}
+				if len(sm.Tags) > 0 {
+					content += " " + highlightStyle.Render(renderTags(sm.Tags))
+				}
 
 				main.WriteString(fmt.Sprintf("%s%s\n", cursor, content))
 			}
+			if m.Focus == FocusSessions && m.SessionCursor < len(p.Sessions) {
+				if note := m.cache.SessionMeta(p.Sessions[m.SessionCursor].ID).Note; note != "" {
+					main.WriteString("\n" + lipgloss.NewStyle().Width(mainWidth-4).Render(note) + "\n")
+				}
+			}
 		}
 	}
 
@@ -739,6 +1264,40 @@ func (m *Model) View() string {
 	return view
 }
 
+// renderArtifacts summarizes a project's logs.json and checkpoints.
+func renderArtifacts(p projectView) string {
+	logs := "No logs"
+	if p.LogsSize > 0 {
+		logs = fmt.Sprintf("Logs: %d entries (%s)", p.LogEntries, humanize.IBytes(uint64(p.LogsSize)))
+	}
+
+	if len(p.Checkpoints) == 0 {
+		return logs + " | No checkpoints"
+	}
+	var size int64
+	var names []string
+	for _, cp := range p.Checkpoints {
+		size += cp.Size
+		names = append(names, cp.Name)
+	}
+	return fmt.Sprintf("%s | Checkpoints: %d (%s): %s", logs, len(p.Checkpoints), humanize.IBytes(uint64(size)), strings.Join(names, ", "))
+}
+
+// renderProgress summarizes the state of the path resolution.
+func renderProgress(p *scanner.Progress) string {
+	if p != nil && p.Source != "" {
+		return fmt.Sprintf("Resolving: checking %s candidates", p.Source)
+	}
+	if p == nil || p.TierIndex == 0 {
+		return "Resolving directories..."
+	}
+	text := fmt.Sprintf("Resolving: tier %d/%d, %s dirs", p.TierIndex, p.Tiers, humanize.Comma(int64(p.DirsScanned)))
+	if p.ETA > 0 {
+		text += fmt.Sprintf(", ETA %s", p.ETA.Round(time.Second))
+	}
+	return text
+}
+
 func formatRelativeTime(t time.Time) string {
 	duration := time.Since(t)
 	switch {
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+
+	"geminictl/internal/cache"
+	"geminictl/internal/gemini"
+	"geminictl/internal/trash"
+)
+
+// maxUndo bounds the number of actions that can be undone.
+const maxUndo = 20
+
+// undoAction reverts a previously performed operation.
+type undoAction struct {
+	Description string
+	Undo        func(m *Model) error
+}
+
+func (m *Model) pushUndo(a undoAction) {
+	m.undo = append(m.undo, a)
+	if len(m.undo) > maxUndo {
+		m.undo = m.undo[len(m.undo)-maxUndo:]
+	}
+}
+
+// undoLast reverts the most recent action and refreshes the state.
+func (m *Model) undoLast() error {
+	if len(m.undo) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	a := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+
+	if err := a.Undo(m); err != nil {
+		return fmt.Errorf("undo %s: %w", a.Description, err)
+	}
+	if updated, err := m.scanner.Scan(); err == nil {
+		m.syncState(updated)
+	}
+	return nil
+}
+
+func undoTrash(e trash.Entry) undoAction {
+	desc := fmt.Sprintf("deletion of project [%s]", gemini.ShortID(e.ProjectID))
+	if e.Kind == trash.KindSession {
+		desc = fmt.Sprintf("deletion of session [%s]", gemini.ShortID(e.SessionID))
+	}
+	return undoAction{
+		Description: desc,
+		Undo: func(m *Model) error {
+			if _, err := m.trash.Restore(m.scanner.RootDir, e.ID); err != nil {
+				return err
+			}
+			metaErr := e.RestoreCache(m.cache)
+			if err := m.cache.Save(); err != nil {
+				return err
+			}
+			if metaErr != nil {
+				return fmt.Errorf("restored, but %w", metaErr)
+			}
+			return nil
+		},
+	}
+}
+
+// undoMoveProject moves a project back to its former ID, which need not be
+// the hash of the clean path it was recorded with, e.g. if it was hashed
+// under a symlink, and restores its record.
+func undoMoveProject(oldID, newID string, rec cache.Record) undoAction {
+	return undoAction{
+		Description: fmt.Sprintf("move of project [%s]", gemini.ShortID(newID)),
+		Undo: func(m *Model) error {
+			if err := gemini.MoveProjectToID(m.scanner.RootDir, newID, oldID); err != nil {
+				return err
+			}
+			m.cache.Move(newID, oldID, rec.Path)
+			m.cache.SetSource(oldID, rec.Source)
+			if rec.Matched != nil {
+				m.cache.SetMatch(oldID, *rec.Matched)
+			}
+			return m.cache.Save()
+		},
+	}
+}
+
+func undoMoveSession(fromID, toID, sessionID string) undoAction {
+	return undoAction{
+		Description: fmt.Sprintf("move of session [%s]", gemini.ShortID(sessionID)),
+		Undo: func(m *Model) error {
+			return gemini.MoveSession(m.scanner.RootDir, toID, fromID, sessionID)
+		},
+	}
+}
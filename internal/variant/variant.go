@@ -0,0 +1,166 @@
+// Package variant enumerates the spellings under which Gemini CLI may have
+// hashed a project directory.
+//
+// Gemini CLI hashes the working directory as the process saw it. A directory
+// reached through a symlink, with a trailing slash or through a bind mount at
+// another prefix hashes differently from the path geminictl finds on disk, so
+// a directory is matched against the hashes of all its variants.
+package variant
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"geminictl/internal/gemini"
+)
+
+// Kinds of variants.
+const (
+	KindExact         = ""
+	KindSymlink       = "symlink"
+	KindTrailingSlash = "trailing slash"
+	KindMount         = "mount"
+)
+
+// Mount maps a path prefix to another prefix under which the same directories
+// are reachable, e.g. a bind mount in a container or a symlinked directory.
+// Mounts apply in both directions.
+type Mount struct {
+	From string `yaml:"from" json:"from"`
+	To   string `yaml:"to" json:"to"`
+}
+
+// Variant is a spelling of a directory path, and how it was derived.
+type Variant struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+// Table indexes mounts by prefix, so that the variants of a path are found
+// in time proportional to its depth rather than to the number of mounts. It
+// is safe for concurrent use; a nil Table has no mounts.
+type Table struct {
+	mu sync.RWMutex
+	to map[string][]Variant // Prefixes reachable under other prefixes
+}
+
+// NewTable returns a table of the given mounts.
+func NewTable(mounts []Mount) *Table {
+	t := &Table{to: make(map[string][]Variant)}
+	for _, m := range mounts {
+		t.Add(m)
+	}
+	return t
+}
+
+// Add adds a mount in both directions.
+func (t *Table) Add(m Mount) {
+	t.add(m.From, m.To, KindMount)
+}
+
+// AddSymlink adds a symlink to a directory, so that the directories below
+// either are also matched under the other.
+func (t *Table) AddSymlink(target, link string) {
+	t.add(target, link, KindSymlink)
+}
+
+func (t *Table) add(from, to, kind string) {
+	from, to = filepath.Clean(from), filepath.Clean(to)
+	if from == to || !filepath.IsAbs(from) || !filepath.IsAbs(to) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.to[from] = append(t.to[from], Variant{Path: to, Kind: kind})
+	t.to[to] = append(t.to[to], Variant{Path: from, Kind: kind})
+}
+
+// Variants returns the spellings of the absolute path of a directory: the
+// path itself first, then the path with symlinks resolved, the paths under
+// the other side of each mount containing it, and each of those with a
+// trailing slash.
+func (t *Table) Variants(path string) []Variant {
+	return t.variants(path, true)
+}
+
+// Lexical returns the variants of a path that can be derived without
+// touching the filesystem, i.e. all but the one with symlinks resolved.
+func (t *Table) Lexical(path string) []Variant {
+	return t.variants(path, false)
+}
+
+func (t *Table) variants(path string, evalSymlinks bool) []Variant {
+	path = filepath.Clean(path)
+	seen := map[string]bool{path: true}
+	out := []Variant{{Path: path, Kind: KindExact}}
+	add := func(p, kind string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, Variant{Path: p, Kind: kind})
+		}
+	}
+
+	if evalSymlinks {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			add(real, KindSymlink)
+		}
+	}
+	for _, v := range out {
+		for _, a := range t.rebase(v.Path) {
+			add(a.Path, a.Kind)
+		}
+	}
+	for _, v := range out {
+		if v.Path != "/" {
+			kind := v.Kind
+			if kind == KindExact {
+				kind = KindTrailingSlash
+			}
+			add(v.Path+"/", kind)
+		}
+	}
+	return out
+}
+
+// rebase returns path under the other side of each mount whose prefix
+// contains it.
+func (t *Table) rebase(path string) []Variant {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.to) == 0 {
+		return nil
+	}
+	var out []Variant
+	for prefix := path; ; prefix = filepath.Dir(prefix) {
+		for _, to := range t.to[prefix] {
+			out = append(out, Variant{Path: filepath.Join(to.Path, strings.TrimPrefix(path, prefix)), Kind: to.Kind})
+		}
+		if prefix == "/" || prefix == "." {
+			return out
+		}
+	}
+}
+
+// Of returns the variants of a path under the given mounts; see
+// Table.Variants.
+func Of(path string, mounts []Mount) []Variant {
+	return NewTable(mounts).Variants(path)
+}
+
+// Match reports which variant of path hashes to the project ID.
+func Match(id, path string, mounts []Mount) (Variant, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Variant{}, false
+	}
+	for _, v := range Of(abs, mounts) {
+		if gemini.HashPath(v.Path) == id {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
@@ -0,0 +1,123 @@
+package variant
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"geminictl/internal/gemini"
+)
+
+func TestLexical(t *testing.T) {
+	mounts := []Mount{
+		{From: "/srv/data", To: "/mnt/data"},
+		{From: "/home/u/work/", To: "/work"},
+		{From: "relative", To: "/ignored"},
+		{From: "/same", To: "/same/"},
+	}
+	tests := []struct {
+		path string
+		want []Variant
+	}{
+		{"/other/dir", []Variant{
+			{"/other/dir", KindExact},
+			{"/other/dir/", KindTrailingSlash},
+		}},
+		{"/srv/data/proj", []Variant{
+			{"/srv/data/proj", KindExact},
+			{"/mnt/data/proj", KindMount},
+			{"/srv/data/proj/", KindTrailingSlash},
+			{"/mnt/data/proj/", KindMount},
+		}},
+		// Mounts apply in both directions, and to the mount point itself.
+		{"/mnt/data/", []Variant{
+			{"/mnt/data", KindExact},
+			{"/srv/data", KindMount},
+			{"/mnt/data/", KindTrailingSlash},
+			{"/srv/data/", KindMount},
+		}},
+		{"/work/app", []Variant{
+			{"/work/app", KindExact},
+			{"/home/u/work/app", KindMount},
+			{"/work/app/", KindTrailingSlash},
+			{"/home/u/work/app/", KindMount},
+		}},
+		// A prefix only matches whole path elements.
+		{"/srv/database", []Variant{
+			{"/srv/database", KindExact},
+			{"/srv/database/", KindTrailingSlash},
+		}},
+		{"/same/x", []Variant{
+			{"/same/x", KindExact},
+			{"/same/x/", KindTrailingSlash},
+		}},
+		{"/", []Variant{{"/", KindExact}}},
+	}
+	table := NewTable(mounts)
+	for _, tt := range tests {
+		if got := table.Lexical(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lexical(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	var none *Table
+	if got := none.Lexical("/a"); len(got) != 2 {
+		t.Errorf("nil table: Lexical(/a) = %v", got)
+	}
+}
+
+func TestVariantsSymlink(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := filepath.Join(dir, "real")
+	link := filepath.Join(dir, "link")
+	if err := os.Mkdir(real, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Variant{
+		{link, KindExact},
+		{real, KindSymlink},
+		{link + "/", KindTrailingSlash},
+		{real + "/", KindSymlink},
+	}
+	if got := Of(link, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Of(%q) = %v, want %v", link, got, want)
+	}
+
+	// A symlink added to the table also matches the directories below it.
+	table := NewTable(nil)
+	table.AddSymlink(real, link)
+	sub := filepath.Join(real, "sub")
+	if got := table.Lexical(sub); len(got) != 4 || got[1] != (Variant{filepath.Join(link, "sub"), KindSymlink}) {
+		t.Errorf("Lexical(%q) = %v", sub, got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	mounts := []Mount{{From: "/srv/data", To: "/mnt/data"}}
+	tests := []struct {
+		hashed string // Path Gemini CLI hashed
+		path   string // Path geminictl found
+		want   Variant
+		ok     bool
+	}{
+		{"/srv/data/p", "/srv/data/p", Variant{"/srv/data/p", KindExact}, true},
+		{"/srv/data/p/", "/srv/data/p", Variant{"/srv/data/p/", KindTrailingSlash}, true},
+		{"/mnt/data/p", "/srv/data/p", Variant{"/mnt/data/p", KindMount}, true},
+		{"/mnt/data/p/", "/srv/data/p", Variant{"/mnt/data/p/", KindMount}, true},
+		{"/elsewhere/p", "/srv/data/p", Variant{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Match(gemini.HashPath(tt.hashed), tt.path, mounts)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Match(hash of %q, %q) = %v, %v; want %v, %v", tt.hashed, tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
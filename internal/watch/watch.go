@@ -0,0 +1,322 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"geminictl/internal/gemini"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Defaults used by the TUI.
+const (
+	DefaultDebounce     = 250 * time.Millisecond
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Op describes what happened to a file.
+type Op int
+
+const (
+	Created Op = iota
+	Modified
+	Removed
+)
+
+func (o Op) String() string {
+	switch o {
+	case Created:
+		return "created"
+	case Modified:
+		return "modified"
+	case Removed:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Change is a created, modified or removed file or project directory in the
+// storage root.
+type Change struct {
+	ProjectID string
+	Path      string
+	Op        Op
+}
+
+// Watcher reports changes to session files, logs.json, checkpoints and
+// project directories in the Gemini storage. Changes are debounced and
+// delivered in batches. It uses filesystem notifications where available
+// and falls back to polling otherwise.
+type Watcher struct {
+	rootDir  string
+	debounce time.Duration
+	interval time.Duration
+	notify   *fsnotify.Watcher // nil when polling
+
+	changes chan []Change
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New starts watching rootDir. If filesystem notifications are unavailable,
+// e.g. on network filesystems or when the root does not exist yet, the
+// watcher polls every DefaultPollInterval instead.
+func New(rootDir string, debounce time.Duration) *Watcher {
+	w := &Watcher{
+		rootDir:  rootDir,
+		debounce: debounce,
+		interval: DefaultPollInterval,
+		changes:  make(chan []Change),
+		done:     make(chan struct{}),
+	}
+
+	if n, err := fsnotify.NewWatcher(); err == nil {
+		if err := w.addTree(n); err == nil {
+			w.notify = n
+			go w.runNotify()
+			return w
+		}
+		n.Close()
+	}
+	go w.runPoll(w.snapshot())
+	return w
+}
+
+// NewPolling starts watching rootDir by comparing file sizes and modification
+// times every interval.
+func NewPolling(rootDir string, interval time.Duration) *Watcher {
+	w := &Watcher{
+		rootDir:  rootDir,
+		interval: interval,
+		changes:  make(chan []Change),
+		done:     make(chan struct{}),
+	}
+	go w.runPoll(w.snapshot())
+	return w
+}
+
+// Changes returns the channel batches of changes are delivered on. It is
+// closed when the watcher is closed.
+func (w *Watcher) Changes() <-chan []Change {
+	return w.changes
+}
+
+// Polling reports whether the watcher fell back to polling.
+func (w *Watcher) Polling() bool {
+	return w.notify == nil
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+// addTree watches the root, every project directory and every chats
+// directory, since notifications are not recursive.
+func (w *Watcher) addTree(n *fsnotify.Watcher) error {
+	if err := n.Add(w.rootDir); err != nil {
+		return err
+	}
+	ids, err := gemini.ListProjectIDs(w.rootDir)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		w.addProject(n, filepath.Join(w.rootDir, id))
+	}
+	return nil
+}
+
+func (w *Watcher) addProject(n *fsnotify.Watcher, dir string) {
+	_ = n.Add(dir)
+	_ = n.Add(filepath.Join(dir, gemini.SessionDir))
+}
+
+func (w *Watcher) runNotify() {
+	defer close(w.changes)
+	defer w.notify.Close()
+
+	pending := make(map[string]Change)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			c, ok := w.classify(ev.Name)
+			if !ok {
+				continue
+			}
+			switch {
+			case ev.Has(fsnotify.Create):
+				c.Op = Created
+				// New project and chats directories must be watched, too.
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if filepath.Dir(ev.Name) == w.rootDir {
+						w.addProject(w.notify, ev.Name)
+					} else {
+						_ = w.notify.Add(ev.Name)
+					}
+				}
+			case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+				c.Op = Removed
+			case ev.Has(fsnotify.Write):
+				c.Op = Modified
+			default:
+				continue
+			}
+			if prev, ok := pending[c.Path]; ok && prev.Op == Created && c.Op == Modified {
+				c.Op = Created
+			}
+			pending[c.Path] = c
+			timer.Reset(w.debounce)
+		case <-w.notify.Errors:
+			// Overflows and similar errors are not fatal; the next event
+			// triggers a refresh of the affected project anyway.
+		case <-timer.C:
+			if !w.send(pending) {
+				return
+			}
+			pending = make(map[string]Change)
+		}
+	}
+}
+
+// fileState is what the poller compares between two snapshots.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// runPoll compares snapshots every interval, starting from prev, which is
+// taken when the watcher is created so that no later change is missed.
+func (w *Watcher) runPoll(prev map[string]fileState) {
+	defer close(w.changes)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		cur := w.snapshot()
+		pending := make(map[string]Change)
+		for path, st := range cur {
+			old, ok := prev[path]
+			switch {
+			case !ok:
+				c, _ := w.classify(path)
+				c.Op = Created
+				pending[path] = c
+			case old != st:
+				c, _ := w.classify(path)
+				c.Op = Modified
+				pending[path] = c
+			}
+		}
+		for path := range prev {
+			if _, ok := cur[path]; !ok {
+				c, _ := w.classify(path)
+				c.Op = Removed
+				pending[path] = c
+			}
+		}
+		prev = cur
+
+		if len(pending) > 0 && !w.send(pending) {
+			return
+		}
+	}
+}
+
+// snapshot collects the state of all relevant files and directories.
+func (w *Watcher) snapshot() map[string]fileState {
+	files := make(map[string]fileState)
+	ids, err := gemini.ListProjectIDs(w.rootDir)
+	if err != nil {
+		return files
+	}
+	for _, id := range ids {
+		dir := filepath.Join(w.rootDir, id)
+		files[dir] = fileState{}
+		for _, sub := range []string{dir, filepath.Join(dir, gemini.SessionDir)} {
+			entries, err := os.ReadDir(sub)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				path := filepath.Join(sub, e.Name())
+				if _, ok := w.classify(path); !ok || e.IsDir() {
+					continue
+				}
+				if info, err := e.Info(); err == nil {
+					files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+				}
+			}
+		}
+	}
+	return files
+}
+
+// classify returns the change for a path if it is relevant: a project
+// directory, its logs.json or checkpoints, its chats directory or a session
+// file in it. Temporary files of atomic writes and the journal are not.
+func (w *Watcher) classify(path string) (Change, bool) {
+	rel, err := filepath.Rel(w.rootDir, path)
+	if err != nil {
+		return Change{}, false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts[0]) != 64 || len(parts) > 3 {
+		return Change{}, false
+	}
+	c := Change{ProjectID: parts[0], Path: path}
+	name := parts[len(parts)-1]
+
+	switch len(parts) {
+	case 1:
+		return c, true
+	case 2:
+		if name == gemini.SessionDir || name == gemini.LogsFile ||
+			(strings.HasPrefix(name, gemini.CheckpointPrefix) && strings.HasSuffix(name, gemini.CheckpointSuffix)) {
+			return c, true
+		}
+	case 3:
+		if parts[1] == gemini.SessionDir && strings.HasPrefix(name, gemini.SessionPrefix) &&
+			strings.HasSuffix(name, gemini.SessionSuffix) {
+			return c, true
+		}
+	}
+	return Change{}, false
+}
+
+// send delivers a batch of changes. It returns false if the watcher was
+// closed in the meantime.
+func (w *Watcher) send(pending map[string]Change) bool {
+	if len(pending) == 0 {
+		return true
+	}
+	batch := make([]Change, 0, len(pending))
+	for _, c := range pending {
+		batch = append(batch, c)
+	}
+	select {
+	case w.changes <- batch:
+		return true
+	case <-w.done:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"geminictl/internal/gemini"
+)
+
+func TestClassify(t *testing.T) {
+	root := "/root"
+	id := strings.Repeat("a", 64)
+	tests := []struct {
+		rel string
+		ok  bool
+	}{
+		{id, true},
+		{id + "/" + gemini.LogsFile, true},
+		{id + "/" + gemini.SessionDir, true},
+		{id + "/checkpoint-x.json", true},
+		{id + "/" + gemini.SessionDir + "/session-2025-01-01T10-00-s1.json", true},
+		{id + "/" + gemini.SessionDir + "/session-s1.json.tmp", false},
+		{id + "/" + gemini.SessionDir + "/notes.txt", false},
+		{id + "/.geminictl-journal", false},
+		{id + "/other.json", false},
+		{id + "/sub/" + gemini.SessionDir + "/session-s1.json", false},
+		{"bin", false},
+		{"../" + id, false},
+	}
+	w := &Watcher{rootDir: root}
+	for _, tt := range tests {
+		c, ok := w.classify(filepath.Join(root, tt.rel))
+		if ok != tt.ok || (ok && c.ProjectID != id) {
+			t.Errorf("classify(%s) = %+v, %v; want %v", tt.rel, c, ok, tt.ok)
+		}
+	}
+}
+
+// expect reads batches from w until every path of want was reported with
+// its op.
+func expect(t *testing.T, w *Watcher, want map[string]Op) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for len(want) > 0 {
+		select {
+		case batch, ok := <-w.Changes():
+			if !ok {
+				t.Fatalf("changes closed, still waiting for %v", want)
+			}
+			for _, c := range batch {
+				if op, ok := want[c.Path]; ok && op == c.Op {
+					delete(want, c.Path)
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no change reported for %v", want)
+		}
+	}
+}
+
+// testWatcher exercises a watcher on root, which holds the project dir.
+func testWatcher(t *testing.T, w *Watcher, root, dir string) {
+	defer w.Close()
+	session := filepath.Join(dir, gemini.SessionDir, "session-2025-01-01T10-00-s1.json")
+	os.WriteFile(session, []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(dir, "unrelated.txt"), []byte("x"), 0644)
+	expect(t, w, map[string]Op{session: Created})
+
+	later := time.Now().Add(time.Hour)
+	os.WriteFile(session, []byte(`{"sessionId":"s1"}`), 0644)
+	os.Chtimes(session, later, later)
+	expect(t, w, map[string]Op{session: Modified})
+
+	os.Remove(session)
+	expect(t, w, map[string]Op{session: Removed})
+
+	// Sessions of new projects are watched, too.
+	other := filepath.Join(root, strings.Repeat("b", 64))
+	os.MkdirAll(filepath.Join(other, gemini.SessionDir), 0755)
+	expect(t, w, map[string]Op{other: Created})
+	otherSession := filepath.Join(other, gemini.SessionDir, "session-2025-01-02T10-00-s2.json")
+	time.Sleep(50 * time.Millisecond) // Let the watcher add the new directories
+	os.WriteFile(otherSession, []byte("{}"), 0644)
+	expect(t, w, map[string]Op{otherSession: Created})
+
+	w.Close()
+	for range w.Changes() {
+	}
+}
+
+// setup creates a storage root with a project and returns both.
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, strings.Repeat("a", 64))
+	if err := os.MkdirAll(filepath.Join(dir, gemini.SessionDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root, dir
+}
+
+func TestNotify(t *testing.T) {
+	root, dir := setup(t)
+	w := New(root, 10*time.Millisecond)
+	if w.Polling() {
+		t.Skip("filesystem notifications are unavailable")
+	}
+	testWatcher(t, w, root, dir)
+}
+
+func TestPolling(t *testing.T) {
+	root, dir := setup(t)
+	w := NewPolling(root, 10*time.Millisecond)
+	if !w.Polling() {
+		t.Fatal("NewPolling does not poll")
+	}
+	testWatcher(t, w, root, dir)
+}
+
+func TestNewFallsBackToPolling(t *testing.T) {
+	w := New(filepath.Join(t.TempDir(), "missing"), time.Millisecond)
+	defer w.Close()
+	if !w.Polling() {
+		t.Errorf("watcher of a missing root does not poll")
+	}
+}